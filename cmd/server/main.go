@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	_ "tfidf-app/docs"
@@ -8,6 +9,8 @@ import (
 	"tfidf-app/internal/database"
 	"tfidf-app/internal/middleware"
 	"tfidf-app/internal/routes"
+	"tfidf-app/internal/services"
+	"tfidf-app/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -23,10 +26,24 @@ import (
 // @tag.name Users
 // @tag.name Collections
 // @tag.name Documents
+// @tag.name Vocabulary
+// @tag.name Compare
+// @tag.name Classifiers
+// @tag.name Watches
+// @tag.name Webhooks
+// @tag.name Events
 // @tag.name Metrics
 // @tag.name Health
 func main() {
 	database.ConnectDatabase()
+	storage.ConnectStorage()
+	services.BackfillContentHashes(context.Background())
+	services.BackfillDocumentVersions()
+	services.BackfillDocumentMetadata(context.Background())
+	services.BackfillContentIndex(context.Background())
+
+	// Очередь вебхуков лежит в базе, недоставленное до перезапуска отправится сейчас
+	go services.RunWebhookDeliveries(context.Background())
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -39,6 +56,13 @@ func main() {
 	routes.UserRoutes(router)
 	routes.DocumentRoute(router)
 	routes.CollectionRoute(router)
+	routes.VocabularyRoute(router)
+	routes.TermRoute(router)
+	routes.CompareRoute(router)
+	routes.ClassifierRoute(router)
+	routes.WatchRoute(router)
+	routes.WebhookRoute(router)
+	routes.EventRoute(router)
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -15,19 +15,19 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/collections": {
+        "/classifiers": {
             "get": {
-                "description": "Returns all collections belonging to the authenticated user",
+                "description": "Gets all classifiers of the current user with their labels",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Get all collections",
+                "summary": "Get all classifiers",
                 "responses": {
                     "200": {
-                        "description": "List of collections",
+                        "description": "List of classifiers",
                         "schema": {
                             "allOf": [
                                 {
@@ -39,7 +39,7 @@ const docTemplate = `{
                                         "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/models.Collection"
+                                                "$ref": "#/definitions/models.Classifier"
                                             }
                                         }
                                     }
@@ -62,7 +62,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Creates a new document collection",
+                "description": "Trains a multinomial Naive Bayes classifier where every given collection is a label and its documents are training examples (at least 2, at most 50 collections, each with indexed documents). Word frequencies are taken from the positional index. With auto_file new uploads are added to the predicted collection when its probability is at least min_confidence (default 0.5)",
                 "consumes": [
                     "application/json"
                 ],
@@ -70,23 +70,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Create a new collection",
+                "summary": "Train a classifier on collections",
                 "parameters": [
                     {
-                        "description": "Collection details",
-                        "name": "collection",
+                        "description": "Classifier name and label collections",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateCollectionReq"
+                            "$ref": "#/definitions/dto.CreateClassifierReq"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created collection",
+                        "description": "Trained classifier",
                         "schema": {
                             "allOf": [
                                 {
@@ -96,7 +96,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Collection"
+                                            "$ref": "#/definitions/models.Classifier"
                                         }
                                     }
                                 }
@@ -115,6 +115,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -124,41 +130,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/collections/add-many": {
-            "post": {
-                "description": "Adds a document to several collections at once",
-                "consumes": [
-                    "application/json"
-                ],
+        "/classifiers/{classifier_id}": {
+            "get": {
+                "description": "Gets a classifier with its labels: documents, words and distinct words each label was trained on",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Add document to multiple collections",
+                "summary": "Get classifier by ID",
                 "parameters": [
                     {
-                        "description": "Collection IDs",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.AddDocumentToCollectionsReq"
-                        }
+                        "type": "string",
+                        "description": "Classifier ID",
+                        "name": "classifier_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/helper.Response"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                        "description": "Classifier",
                         "schema": {
-                            "$ref": "#/definitions/helper.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Classifier"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "401": {
@@ -180,48 +187,28 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/collections/{collection_id}": {
-            "get": {
-                "description": "Returns a specific collection with its documents",
+            },
+            "delete": {
+                "description": "Deletes a classifier, its collections and documents are not changed",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Get collection by ID",
+                "summary": "Delete classifier",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Collection ID",
-                        "name": "collection_id",
+                        "description": "Classifier ID",
+                        "name": "classifier_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Collection details",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/helper.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.Collection"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/helper.Response"
                         }
@@ -246,8 +233,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Updates collection name",
+            "patch": {
+                "description": "Renames a classifier and changes its auto filing settings. Fields missing in the request are not changed. The model itself is changed only by retraining",
                 "consumes": [
                     "application/json"
                 ],
@@ -255,30 +242,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Update collection",
+                "summary": "Update classifier settings",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Collection ID",
-                        "name": "collection_id",
+                        "description": "Classifier ID",
+                        "name": "classifier_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "New collection name",
-                        "name": "collection",
+                        "description": "Settings to change",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateCollectionReq"
+                            "$ref": "#/definitions/dto.UpdateClassifierReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated collection",
+                        "description": "Updated classifier",
                         "schema": {
                             "allOf": [
                                 {
@@ -288,7 +275,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Collection"
+                                            "$ref": "#/definitions/models.Classifier"
                                         }
                                     }
                                 }
@@ -320,30 +307,68 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a collection (does not delete documents)",
+            }
+        },
+        "/classifiers/{classifier_id}/predict": {
+            "post": {
+                "description": "Returns the probability of every label collection for an existing document (JSON body with document_id) or for an uploaded file (multipart field \"file\", the file is not saved). Words not seen in training are ignored, known_tokens tells how many were used",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Delete collection",
+                "summary": "Predict the collection of a document",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Collection ID",
-                        "name": "collection_id",
+                        "description": "Classifier ID",
+                        "name": "classifier_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Existing document",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PredictReq"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to classify",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of upload.progress events in GET /events, random by default",
+                        "name": "progress_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Label probabilities",
                         "schema": {
-                            "$ref": "#/definitions/helper.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.ClassifierPrediction"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -364,6 +389,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
+                    "413": {
+                        "description": "File is too large",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -373,28 +404,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/collections/{collection_id}/statistics": {
-            "get": {
-                "description": "Gets statistics for the collection: TF is calculated as if all documents in the collection were one document, IDF unchanged (gives top 50 most frequent words and their idf)",
+        "/classifiers/{classifier_id}/train": {
+            "post": {
+                "description": "Retrains a classifier on the current documents of its label collections. Fails with 404 if one of the collections was deleted",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Collections"
+                    "Classifiers"
                 ],
-                "summary": "Get collection statistics",
+                "summary": "Retrain a classifier",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Collection ID",
-                        "name": "collection_id",
+                        "description": "Classifier ID",
+                        "name": "classifier_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Collection statistics",
+                        "description": "Retrained classifier",
                         "schema": {
                             "allOf": [
                                 {
@@ -404,7 +435,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object"
+                                            "$ref": "#/definitions/models.Classifier"
                                         }
                                     }
                                 }
@@ -438,37 +469,100 @@ const docTemplate = `{
                 }
             }
         },
-        "/collections/{collection_id}/{document_id}": {
-            "post": {
-                "description": "Adds an existing document to a collection",
+        "/collections": {
+            "get": {
+                "description": "Returns a page of collections belonging to the authenticated user with the number of documents in each. Documents themselves are returned only with include=documents. Sorting by size means by the number of documents",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Collections"
                 ],
-                "summary": "Add document to collection",
+                "summary": "Get all collections",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Collection ID",
-                        "name": "collection_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Name substring, case insensitive",
+                        "name": "name",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "document_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Created at or after, YYYY-MM-DD or RFC 3339",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before, YYYY-MM-DD (inclusive) or RFC 3339",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "created_at",
+                            "size"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort by",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, by default asc for name and desc otherwise",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, 1-100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from meta.next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "documents - include documents of each collection",
+                        "name": "include",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Page of collections",
                         "schema": {
-                            "$ref": "#/definitions/helper.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.CollectionListItem"
+                                            }
+                                        },
+                                        "meta": {
+                                            "$ref": "#/definitions/helper.PageMeta"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -483,12 +577,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/helper.Response"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -497,36 +585,98 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Removes a document from a collection",
+            "post": {
+                "description": "Creates a new document collection",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Collections"
                 ],
-                "summary": "Remove document from collection",
+                "summary": "Create a new collection",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Collection ID",
-                        "name": "collection_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Document ID",
-                        "name": "document_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Collection details",
+                        "name": "collection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateCollectionReq"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created collection",
                         "schema": {
-                            "$ref": "#/definitions/helper.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Collection"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/add-many": {
+            "post": {
+                "description": "Adds a document to several collections at once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Add document to multiple collections",
+                "parameters": [
+                    {
+                        "description": "Collection IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddDocumentToCollectionsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
                         }
                     },
                     "400": {
@@ -556,19 +706,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents": {
+        "/collections/{collection_id}": {
             "get": {
-                "description": "Returns a list of all documents belonging to the authenticated user",
+                "description": "Returns a specific collection with its documents",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Collections"
+                ],
+                "summary": "Get collection by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all user documents",
                 "responses": {
                     "200": {
-                        "description": "List of documents",
+                        "description": "Collection details",
                         "schema": {
                             "allOf": [
                                 {
@@ -578,22 +737,31 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/models.Document"
-                                            }
+                                            "$ref": "#/definitions/models.Collection"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -601,30 +769,40 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/documents/{document_id}": {
-            "get": {
-                "description": "Returns document details and content by ID",
+            },
+            "put": {
+                "description": "Updates collection name",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Collections"
                 ],
-                "summary": "Get a specific document",
+                "summary": "Update collection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "document_id",
+                        "description": "Collection ID",
+                        "name": "collection_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New collection name",
+                        "name": "collection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateCollectionReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Document details",
+                        "description": "Updated collection",
                         "schema": {
                             "allOf": [
                                 {
@@ -634,7 +812,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.DocumentResponse"
+                                            "$ref": "#/definitions/models.Collection"
                                         }
                                     }
                                 }
@@ -653,12 +831,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/helper.Response"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -674,19 +846,19 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Deletes a document by ID (both file and database record)",
+                "description": "Deletes a collection (does not delete documents)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Collections"
                 ],
-                "summary": "Delete a document",
+                "summary": "Delete collection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "document_id",
+                        "description": "Collection ID",
+                        "name": "collection_id",
                         "in": "path",
                         "required": true
                     }
@@ -725,28 +897,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents/{document_id}/huffman": {
+        "/collections/{collection_id}/concordance": {
             "get": {
-                "description": "Encodes the document content using Huffman algorithm",
+                "description": "Finds every occurrence of the word in the collection documents using the positional index and returns it with window words of left and right context and byte offsets, grouped by document. Only documents containing the word are read",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Collections"
                 ],
-                "summary": "Get Huffman encoded and decoded content of a document",
+                "summary": "Get keyword in context across a collection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "document_id",
+                        "description": "Collection ID",
+                        "name": "collection_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Word to find",
+                        "name": "term",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Context words on each side, 0-50",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Max number of occurrences in total, 1-1000",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Encoded and decoded content",
+                        "description": "Occurrences with context",
                         "schema": {
                             "allOf": [
                                 {
@@ -756,7 +949,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object"
+                                            "$ref": "#/definitions/services.ConcordanceResult"
                                         }
                                     }
                                 }
@@ -790,28 +983,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents/{document_id}/statistics": {
+        "/collections/{collection_id}/statistics": {
             "get": {
-                "description": "Calculates TF statistics for a given document, and IDF calculated as if all documents in collections, where the document we specified is, is in one collection",
+                "description": "Gets statistics for the collection: TF is calculated as if all documents in the collection were one document, IDF unchanged (gives top 50 most frequent words and their idf). The summary section describes all documents of the collection together",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Documents"
+                    "Collections"
                 ],
-                "summary": "Get document statistics",
+                "summary": "Get collection statistics",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Document ID",
-                        "name": "document_id",
+                        "description": "Collection ID",
+                        "name": "collection_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Document statistics",
+                        "description": "Collection statistics",
                         "schema": {
                             "allOf": [
                                 {
@@ -855,19 +1048,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/metrics": {
+        "/collections/{collection_id}/trends": {
             "get": {
-                "description": "Retrieves aggregated metrics including processing time, file size, and top 10 most seen words",
+                "description": "Groups collection documents into time buckets by document_date (upload date if not set) and returns, for each requested word, its count, relative frequency and TF-IDF (IDF over the collection documents) in every bucket, empty buckets included. emerging lists words occurring significantly more often in the latest bucket than in all earlier ones together (log-likelihood \u003e= 3.84). Served from the positional index",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Metrics"
+                    "Collections"
+                ],
+                "summary": "Get term trends over time in a collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated words, up to 20",
+                        "name": "terms",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week",
+                            "month",
+                            "quarter",
+                            "year"
+                        ],
+                        "type": "string",
+                        "default": "month",
+                        "description": "Bucket size",
+                        "name": "bucket",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get application metrics",
                 "responses": {
                     "200": {
-                        "description": "Application metrics",
+                        "description": "Term trends",
                         "schema": {
                             "allOf": [
                                 {
@@ -877,13 +1099,25 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.Metric"
+                                            "$ref": "#/definitions/services.TrendResult"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -899,19 +1133,61 @@ const docTemplate = `{
                 }
             }
         },
-        "/status": {
+        "/collections/{collection_id}/vocabulary": {
             "get": {
-                "description": "Provides the current status of the API",
+                "description": "Lists every distinct word of the collection documents with df (documents containing it), cf (total count) and IDF, from the most frequent to the rarest. zipf_rank is the word's place by cf over the whole vocabulary, hapax marks words occurring exactly once. Served from the positional index",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Collections"
+                ],
+                "summary": "Get collection vocabulary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only words starting with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Minimum document frequency",
+                        "name": "min_df",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum document frequency",
+                        "name": "max_df",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Page size, 1-1000",
+                        "name": "per_page",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get API status",
                 "responses": {
                     "200": {
-                        "description": "API status",
+                        "description": "Collection vocabulary",
                         "schema": {
                             "allOf": [
                                 {
@@ -921,59 +1197,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "properties": {
-                                                "status": {
-                                                    "type": "string"
-                                                }
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    }
-                }
-            }
-        },
-        "/upload": {
-            "post": {
-                "description": "Uploads a file, processes it for TF and IDF, gives top 50 rare words, sets up metrics, and saves to database. Only in this case: IDF = log(total words / count)",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Upload document"
-                ],
-                "summary": "Upload and process a document",
-                "parameters": [
-                    {
-                        "type": "file",
-                        "description": "Document file to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "TF-IDF statistics",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/helper.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/services.WordStat"
-                                            }
+                                            "$ref": "#/definitions/services.VocabularyPage"
                                         }
                                     }
                                 }
@@ -992,8 +1216,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/helper.Response"
                         }
@@ -1007,27 +1231,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/login": {
+        "/collections/{collection_id}/{document_id}": {
             "post": {
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Adds an existing document to a collection",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Collections"
                 ],
-                "summary": "User login and setting JWT cookie",
+                "summary": "Add document to collection",
                 "parameters": [
                     {
-                        "description": "Данные для входа",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1049,6 +1276,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1056,50 +1289,43 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/logout": {
-            "get": {
+            },
+            "delete": {
+                "description": "Removes a document from a collection",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Collections"
+                ],
+                "summary": "Remove document from collection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection ID",
+                        "name": "collection_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "User logout (delete cookies)",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/helper.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/users/me": {
-            "get": {
-                "tags": [
-                    "Users"
-                ],
-                "summary": "Get information about the current user",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/helper.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/models.User"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/helper.Response"
                         }
                     },
                     "401": {
@@ -1123,32 +1349,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/register": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/compare": {
+            "get": {
+                "description": "Each side is a document (doc:ID) or a collection (collection:ID), a collection counts as all its documents together. Returns words distinctive to each side by log-likelihood keyness (only significant ones, LL \u003e= 3.84, p \u003c 0.05) with chi-square, shared words with the ratio of relative frequencies, and cosine similarity of the sides' TF-IDF vectors (IDF over the whole library of the user) and of plain TF vectors",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Compare"
                 ],
-                "summary": "New user registration",
+                "summary": "Compare two documents or collections term by term",
                 "parameters": [
                     {
-                        "description": "Регистрационные данные",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.RegisterUserRequest"
-                        }
+                        "type": "string",
+                        "description": "Left side: doc:ID or collection:ID",
+                        "name": "left",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Right side: doc:ID or collection:ID",
+                        "name": "right",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 25,
+                        "description": "Max number of words in each list, 1-200",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Comparison",
                         "schema": {
                             "allOf": [
                                 {
@@ -1158,7 +1394,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/models.User"
+                                            "$ref": "#/definitions/services.CompareResult"
                                         }
                                     }
                                 }
@@ -1171,6 +1407,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1180,29 +1428,110 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{user_id}": {
-            "delete": {
+        "/documents": {
+            "get": {
+                "description": "Returns a page of documents belonging to the authenticated user with their metadata and tags. Can be filtered by tags (documents having all given tags), MIME type (\"text/plain\" or \"text/*\"), name substring and creation date range. The next page is requested with meta.next_cursor",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Documents"
                 ],
-                "summary": "Delete user (everything related)",
+                "summary": "Get all user documents",
                 "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag, can be repeated",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "MIME type, e.g. text/plain or text/*",
+                        "name": "mime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name substring, case insensitive",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after, YYYY-MM-DD or RFC 3339",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before, YYYY-MM-DD (inclusive) or RFC 3339",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "created_at",
+                            "size"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort by",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, by default asc for name and desc otherwise",
+                        "name": "order",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "default": 20,
+                        "description": "Page size, 1-100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from meta.next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Page of documents",
                         "schema": {
-                            "$ref": "#/definitions/helper.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Document"
+                                            }
+                                        },
+                                        "meta": {
+                                            "$ref": "#/definitions/helper.PageMeta"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1224,8 +1553,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/documents/from-text": {
+            "post": {
+                "description": "Saves the text from the request body as a document and processes it the same way as an uploaded file. A document with the same name gets a new version",
                 "consumes": [
                     "application/json"
                 ],
@@ -1233,32 +1565,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Documents"
                 ],
-                "summary": "Update user password",
+                "summary": "Create a document from text",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "ID пользователя",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Новый пароль",
-                        "name": "update",
+                        "description": "Document name and content",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateUserRequest"
+                            "$ref": "#/definitions/dto.CreateDocumentFromTextReq"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of upload.progress events in GET /events, random by default",
+                        "name": "progress_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "TF-IDF statistics",
                         "schema": {
-                            "$ref": "#/definitions/helper.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1273,28 +1616,156 @@ const docTemplate = `{
                             "$ref": "#/definitions/helper.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Name conflict with a concurrent request",
                         "schema": {
                             "$ref": "#/definitions/helper.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/version": {
+                    },
+                    "413": {
+                        "description": "Text is too large or storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/from-url": {
+            "post": {
+                "description": "Downloads the page by the link (http or https, hosts from FETCH_ALLOWLIST if it is set), extracts the readable text from HTML and processes it the same way as an uploaded file. The name defaults to the last segment of the URL path",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Create a document from a URL",
+                "parameters": [
+                    {
+                        "description": "Link and optional document name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateDocumentFromURLReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of upload.progress events in GET /events, random by default",
+                        "name": "progress_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TF-IDF statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or not allowed URL",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Name conflict with a concurrent request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "Page is too large or storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "Not a text page",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to download the page",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/search": {
             "get": {
-                "description": "Provides the current version of the application",
+                "description": "Finds user's documents (or documents of the collection) matching the query using the positional index. Supports words, \"exact phrases\", a NEAR/n b (at most n words between, any order), AND (or just a space), OR, NOT and parentheses. Operators are uppercase. Documents with more matches come first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Documents"
+                ],
+                "summary": "Search documents with a query language",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Search only in this collection",
+                        "name": "collection_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Max number of documents, 1-100",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get application version",
                 "responses": {
                     "200": {
-                        "description": "Application version",
+                        "description": "Matching documents",
                         "schema": {
                             "allOf": [
                                 {
@@ -1304,238 +1775,4239 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "properties": {
-                                                "version": {
-                                                    "type": "string"
-                                                }
-                                            }
+                                            "$ref": "#/definitions/services.SearchResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}": {
+            "get": {
+                "description": "Returns document details and content by ID as a text preview. With max_length the content is cut to that many bytes (on a character boundary) and truncated is set. Binary content is not returned, use /documents/{document_id}/download for the original bytes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get a specific document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum content length in bytes",
+                        "name": "max_length",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document details",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.DocumentResponse"
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a document by ID (both file and database record)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Delete a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
                     }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Renames a document and updates its description, source, date (document_date, empty string removes it) and tags. Fields missing in the request are not changed, tags replace all current tags of the document. Document names are unique per user, renaming to a taken name returns 409",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Update document name and metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Metadata to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateDocumentReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated document",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Document"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Name is taken by another document",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/concordance": {
+            "get": {
+                "description": "Finds every occurrence of the word in the document using the positional index and returns it with window words of left and right context and byte offsets. Context is cut from the original text as is",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get keyword in context for a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Word to find",
+                        "name": "term",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Context words on each side, 0-50",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Max number of occurrences, 1-1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Occurrences with context",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.ConcordanceResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/content": {
+            "put": {
+                "description": "Uploads new content for an existing document as its new version, previous versions are kept",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Replace document content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "New document content",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of upload.progress events in GET /events, random by default",
+                        "name": "progress_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TF-IDF statistics of the new version",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "File is too large or storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/download": {
+            "get": {
+                "description": "Streams the original bytes of the document with its MIME type and file name. Supports Range requests for partial downloads and resuming, and ETag (content hash) with If-None-Match",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Download document content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Show in the browser instead of saving as a file",
+                        "name": "inline",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Byte range, e.g. bytes=0-1023",
+                        "name": "Range",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Requested range",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "416": {
+                        "description": "Range not satisfiable"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/highlight": {
+            "get": {
+                "description": "Splits the document text into words and the text between them, each word with its TF-IDF weight. IDF is calculated over the documents of the given collection, or over all user's documents if collection_id is not set. The top terms get a highlight level from 1 to 5. With format=html returns a page where the top terms are wrapped in \u003cmark class=\"tfidf-N\"\u003e",
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get document text with TF-IDF heat map",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Collection to calculate IDF over",
+                        "name": "collection_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "How many top terms to highlight",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "html"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document segments with weights",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.Highlight"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/huffman": {
+            "get": {
+                "description": "Encodes the document content using Huffman algorithm",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get Huffman encoded and decoded content of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Encoded and decoded content",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/statistics": {
+            "get": {
+                "description": "Calculates TF statistics for a given document, and IDF calculated as if all documents in collections, where the document we specified is, is in one collection. The summary section describes the whole text: tokens, types, type-token ratio, hapaxes, average word and sentence length, Zipf exponent, Shannon entropy and Flesch readability (Oborneva's adaptation for Russian)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get document statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/summary": {
+            "get": {
+                "description": "Splits the document into sentences, scores each by the sum of its words' TF-IDF weights (IDF over the given collection, or over all user's documents if collection_id is not set) and picks sentences with MMR: every step takes the sentence with the highest lambda * score - (1 - lambda) * similarity to already picked ones. The picked sentences are returned in the original order",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get extractive summary of a document",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of sentences, 1-50",
+                        "name": "sentences",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.7,
+                        "description": "Relevance vs diversity, 0-1",
+                        "name": "lambda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Collection to calculate IDF over",
+                        "name": "collection_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Summary",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.ExtractiveSummary"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/versions": {
+            "get": {
+                "description": "Returns the version history of a document, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get document versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document versions",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.DocumentVersion"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/versions/diff": {
+            "get": {
+                "description": "Term-level diff between TF-IDF profiles of two versions. IDF is calculated over the documents of the document's collections, or over the whole library if it is in none",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Diff two document versions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Old version (default: previous, required to be given for a document with one version)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "New version (default: current)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of terms (default: 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed terms",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{document_id}/versions/{version}": {
+            "get": {
+                "description": "Returns the content of the given version of a document as a text preview. With max_length the content is cut to that many bytes (on a character boundary) and truncated is set. Binary content is not returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Documents"
+                ],
+                "summary": "Get a specific document version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "document_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum content length in bytes",
+                        "name": "max_length",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document version",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.DocumentVersionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Server-Sent Events stream of the current user. Events: upload.progress (id, name, processed bytes, total and percent when the size is known, done, error) while an upload is tokenized, the id is the progress_id query parameter of the upload or a random one; statistics.ready when document or collection statistics are computed (not when the word counts come from the cache); metrics.updated with service metrics (without top words) after every processed file; document.created, document.deleted and collection.updated as for webhooks. The first event is \"ready\", a comment is sent every 25 seconds to keep the connection open. Events that happen while the client is disconnected are not replayed",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Stream processing events",
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Retrieves aggregated metrics including processing time, file size, bytes saved by compression at rest and deduplication, and top 10 most seen words",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Get application metrics",
+                "responses": {
+                    "200": {
+                        "description": "Application metrics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Metric"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "description": "Provides the current status of the API",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Get API status",
+                "responses": {
+                    "200": {
+                        "description": "API status",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "properties": {
+                                                "status": {
+                                                    "type": "string"
+                                                }
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/terms/{term}": {
+            "get": {
+                "description": "Returns in how many documents the word occurs (df), its IDF, total count and the list of documents containing it with per-document count, TF and TF-IDF, highest TF-IDF first. The corpus is the given collection or all user's documents. Served from the positional index, document files are not read",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vocabulary"
+                ],
+                "summary": "Get a word's statistics across documents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Word",
+                        "name": "term",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Collection to use as the corpus",
+                        "name": "collection_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Max number of documents in postings, 1-1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Word statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.TermLookup"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload": {
+            "post": {
+                "description": "Uploads a file, processes it for TF and IDF, gives top 50 rare words, sets up metrics, and saves to database. Only in this case: IDF = log(total words / count). Identical content is stored once; if the user already has a document with the same content, its ID is returned in duplicate_of. Uploading a file with the name of an existing document creates its new version",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upload document"
+                ],
+                "summary": "Upload and process a document",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Document file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of upload.progress events in GET /events, random by default",
+                        "name": "progress_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "TF-IDF statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/services.WordStat"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Name conflict with a concurrent request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "File is too large or storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "description": "Creates a resumable (tus 1.0.0) upload of a file of Upload-Length bytes. The file name is passed in Upload-Metadata as \"filename \u003cbase64\u003e\" and must be 1-100 characters long, it is checked before any data is sent. The response Location header is the URL to send chunks to with PATCH",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upload document"
+                ],
+                "summary": "Start a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Total file size in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "filename \u003cbase64 file name\u003e",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Upload created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.UploadSession"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Unsupported protocol version",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "File is too large or storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{upload_id}": {
+            "head": {
+                "description": "Returns how many bytes of the upload were received in the Upload-Offset header and the total size in Upload-Length",
+                "tags": [
+                    "Upload document"
+                ],
+                "summary": "Get resumable upload offset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload-Offset and Upload-Length headers"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Unsupported protocol version",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Appends the request body to the upload starting at Upload-Offset, which must equal the number of bytes already received (see HEAD). After an interrupted request, ask HEAD for the offset and continue from it. When the last byte is received the document is processed and its TF-IDF statistics are returned, otherwise the response is 204 with the new Upload-Offset",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Upload document"
+                ],
+                "summary": "Upload a chunk of a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "upload_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Protocol version, 1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset of this chunk",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload completed, TF-IDF statistics",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "204": {
+                        "description": "Chunk accepted"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Upload-Offset does not match",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "Unsupported protocol version",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "More data than Upload-Length or storage quota exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User login and setting JWT cookie",
+                "parameters": [
+                    {
+                        "description": "Данные для входа",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/logout": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User logout (delete cookies)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get information about the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/storage": {
+            "get": {
+                "description": "Returns original and stored size of the user's documents and how many bytes compression at rest saved",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get storage usage of the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/services.StorageUsage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/register": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "New user registration",
+                "parameters": [
+                    {
+                        "description": "Регистрационные данные",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete user (everything related)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый пароль",
+                        "name": "update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Provides the current version of the application",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Get application version",
+                "responses": {
+                    "200": {
+                        "description": "Application version",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "properties": {
+                                                "version": {
+                                                    "type": "string"
+                                                }
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/vocabulary/suggest": {
+            "get": {
+                "description": "\"Did you mean\": finds words from all user's documents within max_distance Levenshtein edits of q. Closer words come first, equally close ones - by the number of documents they occur in (df). By default one typo is allowed for words up to 4 letters and two for longer ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Vocabulary"
+                ],
+                "summary": "Suggest similar words from the user's vocabulary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Word to check",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max number of edits, 1-3",
+                        "name": "max_distance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Max number of suggestions, 1-50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Suggestions",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.SuggestResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/watches": {
+            "get": {
+                "description": "Gets all watches of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watches"
+                ],
+                "summary": "Get all watches",
+                "responses": {
+                    "200": {
+                        "description": "List of watches",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Watch"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a watch: a word that must be among the top_n (default 20, max 100) TF-IDF words of a newly uploaded document (IDF over all user's documents), or a search query in the /documents/search syntax. Every match is recorded as an alert; if webhook_url is set, a JSON payload is POSTed there, signed with HMAC-SHA256 in X-Webhook-Signature (\"sha256=\u003chex\u003e\") using the secret returned only in this response. Failed deliveries are retried with exponential backoff",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watches"
+                ],
+                "summary": "Watch new uploads for a word or a query",
+                "parameters": [
+                    {
+                        "description": "Watch",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWatchReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created watch with its signing secret",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CreateWatchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/watches/{watch_id}": {
+            "get": {
+                "description": "Gets a watch of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watches"
+                ],
+                "summary": "Get watch by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watch ID",
+                        "name": "watch_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Watch",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Watch"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a watch with its alerts and webhook deliveries, including ones not delivered yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watches"
+                ],
+                "summary": "Delete watch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watch ID",
+                        "name": "watch_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/watches/{watch_id}/alerts": {
+            "get": {
+                "description": "Gets documents the watch matched, newest first: for a word its rank and TF-IDF in the document, for a query the number of hits",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watches"
+                ],
+                "summary": "Get watch alerts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watch ID",
+                        "name": "watch_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Max number of alerts, 1-500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Alerts",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WatchAlert"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/watches/{watch_id}/deliveries": {
+            "get": {
+                "description": "Gets the delivery log of the watch webhook, newest first: payload, status (pending, delivered, failed), number of attempts, last response code and error, time of the next attempt",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Watches"
+                ],
+                "summary": "Get watch webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Watch ID",
+                        "name": "watch_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Max number of deliveries, 1-500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Gets all webhook subscriptions of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "List of webhooks",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.Webhook"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes a URL to lifecycle events: document.created (new document uploaded), document.deleted, collection.updated (renamed, document added or removed, including auto filing) and statistics.ready (document or collection statistics computed, not served from the cache). Each event is POSTed as JSON {event, created_at, data} with headers X-Webhook-Event, X-Webhook-Delivery and X-Webhook-Signature (\"sha256=\" + HMAC-SHA256 of the body with the secret returned only in this response). Failed deliveries are retried with exponential backoff",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Subscribe a webhook to events",
+                "parameters": [
+                    {
+                        "description": "Webhook",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created webhook with its signing secret",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CreateWebhookResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhook_id}": {
+            "get": {
+                "description": "Gets a webhook subscription of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get webhook by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a webhook subscription with its delivery log, including events not delivered yet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Changes the URL, events, description of a webhook or pauses it (active=false, events are not queued while paused). Fields missing in the request are not changed, events replace the whole list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Update webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated webhook",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/models.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{webhook_id}/deliveries": {
+            "get": {
+                "description": "Gets the delivery log of the webhook, newest first: event, payload, status (pending, delivered, failed), number of attempts, last response code and error, time of the next attempt",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Max number of deliveries, 1-500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/helper.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/models.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/helper.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AddDocumentToCollectionsReq": {
+            "type": "object",
+            "required": [
+                "collection_ids",
+                "document_id"
+            ],
+            "properties": {
+                "collection_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "document_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CollectionListItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "document_count": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Document"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CreateClassifierReq": {
+            "type": "object",
+            "required": [
+                "collection_ids",
+                "name"
+            ],
+            "properties": {
+                "auto_file": {
+                    "type": "boolean"
+                },
+                "collection_ids": {
+                    "description": "метки классификатора, от 2 до 50 коллекций",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "min_confidence": {
+                    "description": "по умолчанию 0.5",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateCollectionReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateDocumentFromTextReq": {
+            "type": "object",
+            "required": [
+                "content",
+                "name"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateDocumentFromURLReq": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "name": {
+                    "description": "по умолчанию - последний сегмент пути ссылки",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateWatchReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "description": "поисковый запрос, как в /documents/search",
+                    "type": "string"
+                },
+                "term": {
+                    "description": "слово, которое должно попасть в топ TF-IDF нового документа",
+                    "type": "string"
+                },
+                "top_n": {
+                    "description": "размер топа для term, 1-100, по умолчанию 20",
+                    "type": "integer"
+                },
+                "webhook_url": {
+                    "description": "куда слать срабатывания, пусто - только записывать",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateWatchResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "term": {
+                    "type": "string"
+                },
+                "top_n": {
+                    "description": "для слова: в скольких самых весомых словах документа искать",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateWebhookReq": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "document.created, document.deleted, collection.updated, statistics.ready",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.DocumentResponse": {
+            "type": "object",
+            "properties": {
+                "binary": {
+                    "description": "содержимое не текст, content пустой",
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_hash": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "document_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_count": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "content обрезан до max_length",
+                    "type": "boolean"
+                },
+                "unique_terms": {
+                    "type": "integer"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.DocumentVersionResponse": {
+            "type": "object",
+            "properties": {
+                "binary": {
+                    "description": "содержимое не текст, content пустой",
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "document_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "content обрезан до max_length",
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PredictReq": {
+            "type": "object",
+            "required": [
+                "document_id"
+            ],
+            "properties": {
+                "document_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RegisterUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SuggestResponse": {
+            "type": "object",
+            "properties": {
+                "df": {
+                    "type": "integer"
+                },
+                "found": {
+                    "description": "слово есть в документах пользователя как есть",
+                    "type": "boolean"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.Suggestion"
+                    }
+                }
+            }
+        },
+        "dto.UpdateClassifierReq": {
+            "type": "object",
+            "properties": {
+                "auto_file": {
+                    "type": "boolean"
+                },
+                "min_confidence": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UpdateCollectionReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UpdateDocumentReq": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "document_date": {
+                    "description": "2024-03-31, пустая строка убирает дату",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UpdateWebhookReq": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "helper.PageMeta": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "description": "размер страницы",
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "description": "передать в cursor, чтобы получить следующую страницу",
+                    "type": "string"
+                },
+                "order": {
+                    "type": "string"
+                },
+                "sort": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "сколько всего записей под фильтрами",
+                    "type": "integer"
+                }
+            }
+        },
+        "helper.Response": {
+            "description": "This is the standard response format for all API endpoints",
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "is_success": {
+                    "type": "boolean"
+                },
+                "meta": {
+                    "description": "пагинация для списков"
+                }
+            }
+        },
+        "models.Classifier": {
+            "type": "object",
+            "properties": {
+                "auto_file": {
+                    "description": "Раскладывать ли новые загрузки в предсказанную коллекцию, если вероятность не ниже MinConfidence",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ClassifierLabel"
+                    }
+                },
+                "min_confidence": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "trained_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "vocabulary": {
+                    "description": "число разных слов во всех метках",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ClassifierLabel": {
+            "type": "object",
+            "properties": {
+                "collection_id": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "description": "обучающих документов, из них априорная вероятность",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "terms": {
+                    "type": "integer"
+                },
+                "tokens": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Collection": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Document"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Document": {
+            "type": "object",
+            "properties": {
+                "content_hash": {
+                    "description": "SHA-256 содержимого, он же ключ blob'а",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "document_date": {
+                    "description": "дата самого текста (отчета, письма), задает пользователь",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mime_type": {
+                    "description": "тип сохраненного содержимого",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "имя файла или произвольное название, у пользователя уникально",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "исходный размер в байтах",
+                    "type": "integer"
+                },
+                "source": {
+                    "description": "откуда взят: upload, text или ссылка",
+                    "type": "string"
+                },
+                "stored_size": {
+                    "description": "размер на диске в байтах",
+                    "type": "integer"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Tag"
+                    }
+                },
+                "token_count": {
+                    "description": "сколько всего слов",
+                    "type": "integer"
+                },
+                "unique_terms": {
+                    "description": "сколько разных слов",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "номер текущей версии",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DocumentVersion": {
+            "type": "object",
+            "properties": {
+                "content_hash": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "document_id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "stored_size": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Metric": {
+            "type": "object",
+            "properties": {
+                "avg_file_size_mb": {
+                    "type": "number"
+                },
+                "avg_time_processed": {
+                    "type": "number"
+                },
+                "files_processed": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latest_file_processed_timestamp": {
+                    "type": "string"
+                },
+                "max_time_processed": {
+                    "type": "number"
+                },
+                "min_time_processed": {
+                    "type": "number"
+                },
+                "storage_saved_mb": {
+                    "description": "считается по документам при запросе",
+                    "type": "number"
+                },
+                "top_10_most_freq_words": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Word"
+                    }
+                },
+                "total_file_size_mb": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Tag": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UploadSession": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "length": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Watch": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "term": {
+                    "type": "string"
+                },
+                "top_n": {
+                    "description": "для слова: в скольких самых весомых словах документа искать",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "webhook_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WatchAlert": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "document_id": {
+                    "type": "integer"
+                },
+                "document_name": {
+                    "type": "string"
+                },
+                "hits": {
+                    "description": "для запроса: число совпадений",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "description": "для слова: место в топе TF-IDF документа, с 1",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "для слова: его TF-IDF в документе",
+                    "type": "number"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "watch_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "response_status": {
+                    "description": "HTTP-код последней попытки",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "watch_id": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Word": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "word": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ClassifierPrediction": {
+            "type": "object",
+            "properties": {
+                "classifier_id": {
+                    "type": "integer"
+                },
+                "known_tokens": {
+                    "description": "из них встречались при обучении, остальные не учитываются",
+                    "type": "integer"
+                },
+                "label": {
+                    "description": "самая вероятная метка",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/services.LabelProbability"
+                        }
+                    ]
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.LabelProbability"
+                    }
+                },
+                "tokens": {
+                    "description": "слов в документе",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.CompareResult": {
+            "type": "object",
+            "properties": {
+                "cosine_similarity": {
+                    "description": "по векторам TF-IDF, IDF по всей библиотеке пользователя",
+                    "type": "number"
+                },
+                "cosine_tf": {
+                    "description": "по векторам TF, имеет смысл и когда документ всего один",
+                    "type": "number"
+                },
+                "left": {
+                    "$ref": "#/definitions/services.CompareSide"
+                },
+                "left_distinctive": {
+                    "description": "по убыванию log-likelihood",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.KeyTerm"
+                    }
+                },
+                "right": {
+                    "$ref": "#/definitions/services.CompareSide"
+                },
+                "right_distinctive": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.KeyTerm"
+                    }
+                },
+                "shared": {
+                    "description": "самые частые на обеих сторонах вместе",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SharedTerm"
+                    }
+                }
+            }
+        },
+        "services.CompareSide": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "description": "document или collection",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tokens": {
+                    "type": "integer"
+                },
+                "types": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.Concordance": {
+            "type": "object",
+            "properties": {
+                "context_end": {
+                    "type": "integer"
+                },
+                "context_start": {
+                    "description": "Границы всего фрагмента вместе с контекстом",
+                    "type": "integer"
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "left": {
+                    "type": "string"
+                },
+                "match": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "номер слова в тексте",
+                    "type": "integer"
+                },
+                "right": {
+                    "type": "string"
+                },
+                "start": {
+                    "description": "смещение слова в байтах",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.ConcordanceResult": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.DocumentConcordance"
+                    }
+                },
+                "term": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "description": "вхождений больше, чем limit",
+                    "type": "boolean"
+                },
+                "window": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.DocumentConcordance": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "сколько всего вхождений, даже если в lines попали не все",
+                    "type": "integer"
+                },
+                "document_id": {
+                    "type": "integer"
+                },
+                "document_name": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.Concordance"
+                    }
+                }
+            }
+        },
+        "services.EmergingTerm": {
+            "type": "object",
+            "properties": {
+                "baseline_count": {
+                    "type": "integer"
+                },
+                "baseline_frequency": {
+                    "type": "number"
+                },
+                "latest_count": {
+                    "type": "integer"
+                },
+                "latest_frequency": {
+                    "type": "number"
+                },
+                "log_likelihood": {
+                    "type": "number"
+                },
+                "term": {
+                    "type": "string"
+                }
+            }
+        },
+        "services.ExtractiveSummary": {
+            "type": "object",
+            "properties": {
+                "lambda": {
+                    "type": "number"
+                },
+                "sentences": {
+                    "description": "в порядке текста",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SummarySentence"
+                    }
+                },
+                "total_sentences": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.Highlight": {
+            "type": "object",
+            "properties": {
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.HighlightSegment"
+                    }
+                },
+                "top_terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TermWeight"
+                    }
+                }
+            }
+        },
+        "services.HighlightSegment": {
+            "type": "object",
+            "properties": {
+                "level": {
+                    "description": "1..HighlightLevels для топ-слов, 0 - не выделяется",
+                    "type": "integer"
+                },
+                "text": {
+                    "description": "как в документе, с исходным регистром",
+                    "type": "string"
+                },
+                "tf_idf": {
+                    "type": "number"
+                },
+                "word": {
+                    "description": "слово в нижнем регистре, пусто для текста между словами",
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.AddDocumentToCollectionsReq": {
+        },
+        "services.KeyTerm": {
             "type": "object",
-            "required": [
-                "collection_ids",
-                "document_id"
-            ],
             "properties": {
-                "collection_ids": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "chi_square": {
+                    "type": "number"
                 },
-                "document_id": {
+                "left_count": {
+                    "type": "integer"
+                },
+                "left_frequency": {
+                    "description": "доля от всех слов стороны",
+                    "type": "number"
+                },
+                "log_likelihood": {
+                    "type": "number"
+                },
+                "right_count": {
                     "type": "integer"
+                },
+                "right_frequency": {
+                    "type": "number"
+                },
+                "word": {
+                    "type": "string"
                 }
             }
         },
-        "dto.CreateCollectionReq": {
+        "services.LabelProbability": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
+                "collection_id": {
+                    "type": "integer"
+                },
+                "log_score": {
+                    "description": "ненормированный логарифм P(метка) * P(слова | метка)",
+                    "type": "number"
+                },
                 "name": {
                     "type": "string"
+                },
+                "probability": {
+                    "type": "number"
                 }
             }
         },
-        "dto.DocumentResponse": {
+        "services.SearchHit": {
             "type": "object",
             "properties": {
-                "content": {
+                "document_id": {
+                    "type": "integer"
+                },
+                "document_name": {
                     "type": "string"
                 },
-                "id": {
+                "hits": {
+                    "description": "сколько совпадений слов, фраз и NEAR нашлось",
                     "type": "integer"
+                }
+            }
+        },
+        "services.SearchResult": {
+            "type": "object",
+            "properties": {
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.SearchHit"
+                    }
                 },
-                "name": {
+                "query": {
                     "type": "string"
                 },
-                "uploaded_at": {
-                    "type": "string"
+                "total": {
+                    "description": "сколько документов подошло, даже если в documents попали не все",
+                    "type": "integer"
                 }
             }
         },
-        "dto.LoginRequest": {
+        "services.SharedTerm": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "left_count": {
+                    "type": "integer"
                 },
-                "password": {
+                "left_frequency": {
+                    "type": "number"
+                },
+                "ratio": {
+                    "description": "left_frequency / right_frequency",
+                    "type": "number"
+                },
+                "right_count": {
+                    "type": "integer"
+                },
+                "right_frequency": {
+                    "type": "number"
+                },
+                "word": {
                     "type": "string"
                 }
             }
         },
-        "dto.RegisterUserRequest": {
+        "services.StorageUsage": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "documents": {
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string"
+                "original_bytes": {
+                    "type": "integer"
+                },
+                "saved_bytes": {
+                    "type": "integer"
+                },
+                "stored_bytes": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.UpdateCollectionReq": {
+        "services.Suggestion": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
+                "df": {
+                    "description": "в скольких документах встречается",
+                    "type": "integer"
+                },
+                "distance": {
+                    "description": "сколько правок до запроса",
+                    "type": "integer"
+                },
+                "term": {
                     "type": "string"
                 }
             }
         },
-        "dto.UpdateUserRequest": {
+        "services.SummarySentence": {
             "type": "object",
             "properties": {
-                "password": {
+                "end": {
+                    "type": "integer"
+                },
+                "index": {
+                    "description": "номер предложения в тексте",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "сумма весов TF-IDF слов предложения",
+                    "type": "number"
+                },
+                "start": {
+                    "description": "смещения в байтах",
+                    "type": "integer"
+                },
+                "text": {
                     "type": "string"
                 }
             }
         },
-        "helper.Response": {
-            "description": "This is the standard response format for all API endpoints",
+        "services.TermDocument": {
             "type": "object",
             "properties": {
-                "data": {},
-                "error": {
+                "count": {
+                    "type": "integer"
+                },
+                "document_id": {
+                    "type": "integer"
+                },
+                "document_name": {
                     "type": "string"
                 },
-                "is_success": {
-                    "type": "boolean"
+                "tf": {
+                    "type": "number"
+                },
+                "tf_idf": {
+                    "type": "number"
                 }
             }
         },
-        "models.Collection": {
+        "services.TermLookup": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
+                "df": {
+                    "description": "в скольких документах есть слово",
+                    "type": "integer"
                 },
                 "documents": {
+                    "description": "размер корпуса",
+                    "type": "integer"
+                },
+                "idf": {
+                    "description": "как в статистике: ln(documents / df)",
+                    "type": "number"
+                },
+                "postings": {
+                    "description": "по убыванию TF-IDF",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Document"
+                        "$ref": "#/definitions/services.TermDocument"
                     }
                 },
-                "id": {
+                "term": {
+                    "type": "string"
+                },
+                "total_count": {
+                    "description": "сколько раз встречается во всех документах",
                     "type": "integer"
+                }
+            }
+        },
+        "services.TermTrend": {
+            "type": "object",
+            "properties": {
+                "idf": {
+                    "type": "number"
                 },
-                "name": {
-                    "type": "string"
+                "points": {
+                    "description": "по всем интервалам подряд, в том числе пустым",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TrendPoint"
+                    }
                 },
-                "updated_at": {
+                "term": {
                     "type": "string"
-                },
-                "user_id": {
+                }
+            }
+        },
+        "services.TermWeight": {
+            "type": "object",
+            "properties": {
+                "level": {
                     "type": "integer"
+                },
+                "tf_idf": {
+                    "type": "number"
+                },
+                "word": {
+                    "type": "string"
                 }
             }
         },
-        "models.Document": {
+        "services.TrendBucket": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "bucket": {
+                    "description": "2024-03, 2024-W09, 2024-Q1...",
                     "type": "string"
                 },
-                "id": {
+                "documents": {
                     "type": "integer"
                 },
-                "name": {
-                    "description": "имя файла или произвольное название",
-                    "type": "string"
-                },
-                "updated_at": {
+                "start": {
                     "type": "string"
                 },
-                "user_id": {
+                "tokens": {
                     "type": "integer"
                 }
             }
         },
-        "models.Metric": {
+        "services.TrendPoint": {
             "type": "object",
             "properties": {
-                "avg_file_size_mb": {
-                    "type": "number"
-                },
-                "avg_time_processed": {
-                    "type": "number"
-                },
-                "files_processed": {
-                    "type": "integer"
+                "bucket": {
+                    "type": "string"
                 },
-                "id": {
+                "count": {
                     "type": "integer"
                 },
-                "latest_file_processed_timestamp": {
-                    "type": "string"
-                },
-                "max_time_processed": {
+                "relative_frequency": {
+                    "description": "доля от всех слов интервала",
                     "type": "number"
                 },
-                "min_time_processed": {
+                "tf_idf": {
+                    "description": "relative_frequency * IDF слова по документам коллекции",
                     "type": "number"
+                }
+            }
+        },
+        "services.TrendResult": {
+            "type": "object",
+            "properties": {
+                "bucket_size": {
+                    "type": "string"
                 },
-                "top_10_most_freq_words": {
+                "buckets": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Word"
+                        "$ref": "#/definitions/services.TrendBucket"
                     }
                 },
-                "total_file_size_mb": {
-                    "type": "number"
+                "emerging": {
+                    "description": "по убыванию log-likelihood, пусто, если интервал всего один",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.EmergingTerm"
+                    }
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.TermTrend"
+                    }
                 }
             }
         },
-        "models.User": {
+        "services.VocabularyPage": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
+                "documents": {
+                    "description": "документов в коллекции",
+                    "type": "integer"
                 },
-                "email": {
-                    "type": "string"
+                "hapaxes": {
+                    "description": "слов, встретившихся один раз",
+                    "type": "integer"
                 },
-                "id": {
+                "page": {
                     "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string"
+                "per_page": {
+                    "type": "integer"
+                },
+                "terms": {
+                    "description": "от частых к редким",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.VocabularyTerm"
+                    }
+                },
+                "tokens": {
+                    "description": "слов во всей коллекции",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "слов, подходящих под фильтр",
+                    "type": "integer"
+                },
+                "vocabulary_size": {
+                    "description": "разных слов во всей коллекции",
+                    "type": "integer"
                 }
             }
         },
-        "models.Word": {
+        "services.VocabularyTerm": {
             "type": "object",
             "properties": {
-                "count": {
+                "cf": {
+                    "description": "сколько раз встречается во всей коллекции",
                     "type": "integer"
                 },
-                "word": {
+                "df": {
+                    "description": "в скольких документах встречается",
+                    "type": "integer"
+                },
+                "hapax": {
+                    "description": "встречается в коллекции ровно один раз",
+                    "type": "boolean"
+                },
+                "idf": {
+                    "description": "ln(documents / df)",
+                    "type": "number"
+                },
+                "term": {
                     "type": "string"
+                },
+                "zipf_rank": {
+                    "description": "место по частоте cf, 1 - самое частое",
+                    "type": "integer"
                 }
             }
         },
@@ -1570,6 +6042,24 @@ const docTemplate = `{
         {
             "name": "Documents"
         },
+        {
+            "name": "Vocabulary"
+        },
+        {
+            "name": "Compare"
+        },
+        {
+            "name": "Classifiers"
+        },
+        {
+            "name": "Watches"
+        },
+        {
+            "name": "Webhooks"
+        },
+        {
+            "name": "Events"
+        },
         {
             "name": "Metrics"
         },
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +18,34 @@ type Config struct {
 	DB_USER     string
 	DB_PASSWORD string
 	JWTSecret   string
+	CacheDir    string // папка для дискового кэша (хаффман, подсчеты слов)
+	CacheSize   int    // сколько записей держим в памяти (LRU)
+
+	CacheMemoryBytes int64 // сколько байт записей кэша держим в памяти
+	CacheDiskBytes   int64 // сколько байт может занимать кэш на диске, 0 - без ограничения
+
+	StorageCompression string // "" - хранить как есть, "gzip" - сжимать документы на диске
+	StorageBackend     string // "local" или "s3"
+	StorageLocalDir    string // корень локального хранилища
+	UploadsDir         string // временные файлы загрузок (в т.ч. докачиваемых)
+	MaxUploadSize      int64  // максимальный размер одного файла в байтах
+	MaxUserStorage     int64  // сколько байт всех версий документов может хранить пользователь, 0 - без лимита
+
+	// Загрузка документов по ссылке
+	FetchAllowlist []string      // разрешенные хосты ("example.com", "*.example.com"), пусто - любые публичные
+	FetchTimeout   time.Duration // таймаут всего запроса
+
+	// Исходящие вебхуки
+	WebhookAllowlist   []string      // хосты, куда можно слать вебхуки даже во внутренней сети (например, localhost)
+	WebhookTimeout     time.Duration // таймаут одной попытки доставки
+	WebhookMaxAttempts int           // после стольких неудачных попыток доставка считается проваленной
+
+	// S3-совместимое хранилище (AWS S3, MinIO)
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
 }
 
 var Init Config
@@ -33,5 +64,74 @@ func init() {
 		DB_USER:     os.Getenv("DB_USER"),
 		DB_PASSWORD: os.Getenv("DB_PASSWORD"),
 		JWTSecret:   os.Getenv("JWT_SECRET"),
+		CacheDir:    getEnv("CACHE_DIR", "/app/cache"),
+		CacheSize:   getEnvInt("CACHE_SIZE", 128),
+
+		CacheMemoryBytes: int64(getEnvInt("CACHE_MEMORY_BYTES", 64*1024*1024)),
+		CacheDiskBytes:   int64(getEnvInt("CACHE_DISK_BYTES", 1024*1024*1024)),
+
+		StorageCompression: getStorageCompression(),
+		StorageBackend:     getEnv("STORAGE_BACKEND", "local"),
+		StorageLocalDir:    getEnv("STORAGE_LOCAL_DIR", "/app/documents"),
+		UploadsDir:         getEnv("UPLOADS_DIR", "/app/uploads"),
+		MaxUploadSize:      int64(getEnvInt("MAX_UPLOAD_SIZE", 100*1024*1024)),
+		MaxUserStorage:     int64(getEnvInt("MAX_USER_STORAGE", 0)),
+
+		FetchAllowlist: getEnvList("FETCH_ALLOWLIST"),
+		FetchTimeout:   time.Duration(getEnvInt("FETCH_TIMEOUT", 15)) * time.Second,
+
+		WebhookAllowlist:   getEnvList("WEBHOOK_ALLOWLIST"),
+		WebhookTimeout:     time.Duration(getEnvInt("WEBHOOK_TIMEOUT", 10)) * time.Second,
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 6),
+
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3Region:    os.Getenv("S3_REGION"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+	}
+}
+
+func getStorageCompression() string {
+	switch value := os.Getenv("STORAGE_COMPRESSION"); value {
+	case "", "none":
+		return ""
+	case "gzip":
+		return value
+	default:
+		log.Printf("Unknown STORAGE_COMPRESSION %q, documents will be stored uncompressed", value)
+		return ""
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value for %s: %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvList разбирает список через запятую, пустые элементы пропускаются
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
 	}
+	return list
 }
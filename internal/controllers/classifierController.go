@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"tfidf-app/internal/dto"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ClassifierController interface {
+	CreateClassifier(c *gin.Context)
+	GetClassifiers(c *gin.Context)
+	GetClassifierByID(c *gin.Context)
+	UpdateClassifier(c *gin.Context)
+	DeleteClassifier(c *gin.Context)
+	TrainClassifier(c *gin.Context)
+	Predict(c *gin.Context)
+}
+
+type classifierController struct {
+	DB *gorm.DB
+}
+
+func NewClassifierController(db *gorm.DB) ClassifierController {
+	return &classifierController{DB: db}
+}
+
+// Частоты слов меток нужны только для предсказания, в ответах их нет
+func withoutCounts(db *gorm.DB) *gorm.DB {
+	return db.Omit("counts").Order("id")
+}
+
+// CreateClassifier godoc
+// @Summary Train a classifier on collections
+// @Description Trains a multinomial Naive Bayes classifier where every given collection is a label and its documents are training examples (at least 2, at most 50 collections, each with indexed documents). Word frequencies are taken from the positional index. With auto_file new uploads are added to the predicted collection when its probability is at least min_confidence (default 0.5)
+// @Tags Classifiers
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateClassifierReq true "Classifier name and label collections"
+// @Success 201 {object} helper.Response{data=models.Classifier} "Trained classifier"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /classifiers [post]
+func (cl *classifierController) CreateClassifier(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.CreateClassifierReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	if !validClassifierName(req.Name) {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("name must be from 1 to 100 characters"))
+		return
+	}
+
+	classifier := models.Classifier{Name: req.Name, UserID: userID, AutoFile: req.AutoFile, MinConfidence: 0.5}
+	if req.MinConfidence != nil {
+		if *req.MinConfidence < 0 || *req.MinConfidence > 1 {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("min_confidence must be from 0 to 1"))
+			return
+		}
+		classifier.MinConfidence = *req.MinConfidence
+	}
+
+	collections, ok := cl.loadCollections(c, userID, req.CollectionIDs)
+	if !ok {
+		return
+	}
+
+	if err := services.TrainClassifier(cl.DB, &classifier, collections); err != nil {
+		respondTrainError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.NewSuccessResponse(classifier))
+}
+
+// GetClassifiers godoc
+// @Summary Get all classifiers
+// @Description Gets all classifiers of the current user with their labels
+// @Tags Classifiers
+// @Produce json
+// @Success 200 {object} helper.Response{data=[]models.Classifier} "List of classifiers"
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /classifiers [get]
+func (cl *classifierController) GetClassifiers(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var classifiers []models.Classifier
+	if err := cl.DB.Preload("Labels", withoutCounts).
+		Where("user_id = ?", userID).Order("id").Find(&classifiers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get classifiers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(classifiers))
+}
+
+// GetClassifierByID godoc
+// @Summary Get classifier by ID
+// @Description Gets a classifier with its labels: documents, words and distinct words each label was trained on
+// @Tags Classifiers
+// @Produce json
+// @Param classifier_id path string true "Classifier ID"
+// @Success 200 {object} helper.Response{data=models.Classifier} "Classifier"
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /classifiers/{classifier_id} [get]
+func (cl *classifierController) GetClassifierByID(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	classifier, ok := cl.findClassifier(c, userID, withoutCounts)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(classifier))
+}
+
+// UpdateClassifier godoc
+// @Summary Update classifier settings
+// @Description Renames a classifier and changes its auto filing settings. Fields missing in the request are not changed. The model itself is changed only by retraining
+// @Tags Classifiers
+// @Accept json
+// @Produce json
+// @Param classifier_id path string true "Classifier ID"
+// @Param request body dto.UpdateClassifierReq true "Settings to change"
+// @Success 200 {object} helper.Response{data=models.Classifier} "Updated classifier"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /classifiers/{classifier_id} [patch]
+func (cl *classifierController) UpdateClassifier(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.UpdateClassifierReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	updates := map[string]any{}
+	if req.Name != nil {
+		if !validClassifierName(*req.Name) {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("name must be from 1 to 100 characters"))
+			return
+		}
+		updates["name"] = *req.Name
+	}
+	if req.AutoFile != nil {
+		updates["auto_file"] = *req.AutoFile
+	}
+	if req.MinConfidence != nil {
+		if *req.MinConfidence < 0 || *req.MinConfidence > 1 {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("min_confidence must be from 0 to 1"))
+			return
+		}
+		updates["min_confidence"] = *req.MinConfidence
+	}
+
+	classifier, ok := cl.findClassifier(c, userID, withoutCounts)
+	if !ok {
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := cl.DB.Model(&classifier).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to update classifier"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(classifier))
+}
+
+// DeleteClassifier godoc
+// @Summary Delete classifier
+// @Description Deletes a classifier, its collections and documents are not changed
+// @Tags Classifiers
+// @Produce json
+// @Param classifier_id path string true "Classifier ID"
+// @Success 200 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /classifiers/{classifier_id} [delete]
+func (cl *classifierController) DeleteClassifier(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	classifier, ok := cl.findClassifier(c, userID, nil)
+	if !ok {
+		return
+	}
+
+	if err := cl.DB.Delete(&classifier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete classifier"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse("Classifier deleted successfully"))
+}
+
+// TrainClassifier godoc
+// @Summary Retrain a classifier
+// @Description Retrains a classifier on the current documents of its label collections. Fails with 404 if one of the collections was deleted
+// @Tags Classifiers
+// @Produce json
+// @Param classifier_id path string true "Classifier ID"
+// @Success 200 {object} helper.Response{data=models.Classifier} "Retrained classifier"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /classifiers/{classifier_id}/train [post]
+func (cl *classifierController) TrainClassifier(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	classifier, ok := cl.findClassifier(c, userID, withoutCounts)
+	if !ok {
+		return
+	}
+
+	ids := make([]uint, 0, len(classifier.Labels))
+	for _, label := range classifier.Labels {
+		ids = append(ids, label.CollectionID)
+	}
+	collections, ok := cl.loadCollections(c, userID, ids)
+	if !ok {
+		return
+	}
+
+	if err := services.TrainClassifier(cl.DB, &classifier, collections); err != nil {
+		respondTrainError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(classifier))
+}
+
+// Predict godoc
+// @Summary Predict the collection of a document
+// @Description Returns the probability of every label collection for an existing document (JSON body with document_id) or for an uploaded file (multipart field "file", the file is not saved). Words not seen in training are ignored, known_tokens tells how many were used
+// @Tags Classifiers
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param classifier_id path string true "Classifier ID"
+// @Param request body dto.PredictReq false "Existing document"
+// @Param file formData file false "File to classify"
+// @Param progress_id query string false "ID of upload.progress events in GET /events, random by default"
+// @Success 200 {object} helper.Response{data=services.ClassifierPrediction} "Label probabilities"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 413 {object} helper.Response "File is too large"
+// @Failure 500 {object} helper.Response
+// @Router /classifiers/{classifier_id}/predict [post]
+func (cl *classifierController) Predict(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	classifier, ok := cl.findClassifier(c, userID, func(db *gorm.DB) *gorm.DB { return db.Order("id") })
+	if !ok {
+		return
+	}
+
+	var counts map[string]int
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		// Файл только классифицируется, документ не создается
+		staged, _, ok := stageMultipartFile(c, userID)
+		if !ok {
+			return
+		}
+		defer staged.Remove()
+		counts = staged.Counts.Counts
+	} else {
+		var req dto.PredictReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Provide document_id or a file"))
+			return
+		}
+
+		var document models.Document
+		if err := cl.DB.Where("id = ? AND user_id = ?", req.DocumentID, userID).First(&document).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+			return
+		}
+
+		if counts, err = services.DocumentTermCounts(cl.DB, document.ContentHash); err != nil {
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document words"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(services.Classify(classifier, counts)))
+}
+
+// findClassifier находит классификатор пользователя из пути вместе с метками (labels настраивает их запрос, nil - без меток).
+// При ошибке сам отвечает клиенту.
+func (cl *classifierController) findClassifier(c *gin.Context, userID int, labels func(*gorm.DB) *gorm.DB) (models.Classifier, bool) {
+	query := cl.DB
+	if labels != nil {
+		query = query.Preload("Labels", labels)
+	}
+
+	var classifier models.Classifier
+	if err := query.Where("id = ? AND user_id = ?", c.Param("classifier_id"), userID).First(&classifier).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Classifier not found"))
+			return classifier, false
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get classifier"))
+		return classifier, false
+	}
+	return classifier, true
+}
+
+// loadCollections находит коллекции пользователя в порядке ids, повторы отбрасываются.
+// При ошибке сам отвечает клиенту.
+func (cl *classifierController) loadCollections(c *gin.Context, userID int, ids []uint) ([]models.Collection, bool) {
+	unique := make([]uint, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) < services.MinClassifierLabels || len(unique) > services.MaxClassifierLabels {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(fmt.Sprintf("collection_ids must contain from %d to %d collections", services.MinClassifierLabels, services.MaxClassifierLabels)))
+		return nil, false
+	}
+
+	var found []models.Collection
+	if err := cl.DB.Where("id IN ? AND user_id = ?", unique, userID).Find(&found).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collections"))
+		return nil, false
+	}
+
+	byID := make(map[uint]models.Collection, len(found))
+	for _, collection := range found {
+		byID[collection.ID] = collection
+	}
+
+	collections := make([]models.Collection, 0, len(unique))
+	for _, id := range unique {
+		collection, ok := byID[id]
+		if !ok {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse(fmt.Sprintf("Collection %d not found", id)))
+			return nil, false
+		}
+		collections = append(collections, collection)
+	}
+	return collections, true
+}
+
+// validClassifierName проверяет длину имени (колонка classifiers.name - 100 символов)
+func validClassifierName(name string) bool {
+	return strings.TrimSpace(name) != "" && utf8.RuneCountInString(name) <= 100
+}
+
+// respondTrainError отвечает на ошибку обучения: неподходящие коллекции - 400
+func respondTrainError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrTooFewLabels) || errors.Is(err, services.ErrTooManyLabels) || errors.Is(err, services.ErrEmptyLabel) {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to train classifier"))
+}
@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"tfidf-app/internal/dto"
 	"tfidf-app/internal/helper"
 	"tfidf-app/internal/models"
@@ -22,6 +26,9 @@ type CollectionController interface {
 	UpdateCollection(c *gin.Context)
 	DeleteCollection(c *gin.Context)
 	GetCollectionStatistics(c *gin.Context)
+	GetCollectionConcordance(c *gin.Context)
+	GetCollectionVocabulary(c *gin.Context)
+	GetCollectionTrends(c *gin.Context)
 }
 
 type collectionController struct {
@@ -116,6 +123,9 @@ func (col *collectionController) AddDocumentToCollection(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to add document to collection"))
 		return
 	}
+	services.EmitEvent(col.DB, userID, services.EventCollectionUpdated, services.CollectionEvent{
+		CollectionID: collection.ID, Name: collection.Name, Change: services.CollectionDocumentAdded, DocumentID: document.ID,
+	})
 
 	c.JSON(http.StatusOK, helper.NewSuccessResponse("Document successfully added to collection"))
 }
@@ -157,6 +167,7 @@ func (col *collectionController) AddDocumentToCollections(c *gin.Context) {
 		return
 	}
 
+	var updated []models.Collection
 	err = col.DB.Transaction(func(tx *gorm.DB) error {
 		for _, collectionID := range req.CollectionIDs {
 			var collection models.Collection
@@ -167,6 +178,7 @@ func (col *collectionController) AddDocumentToCollections(c *gin.Context) {
 			if err := tx.Model(&collection).Association("Documents").Append(&document); err != nil {
 				return err
 			}
+			updated = append(updated, collection)
 		}
 		return nil
 	})
@@ -176,6 +188,13 @@ func (col *collectionController) AddDocumentToCollections(c *gin.Context) {
 		return
 	}
 
+	// События - только после фиксации транзакции
+	for _, collection := range updated {
+		services.EmitEvent(col.DB, userID, services.EventCollectionUpdated, services.CollectionEvent{
+			CollectionID: collection.ID, Name: collection.Name, Change: services.CollectionDocumentAdded, DocumentID: document.ID,
+		})
+	}
+
 	c.JSON(http.StatusOK, helper.NewSuccessResponse("Document successfully added to all specified collections"))
 }
 
@@ -222,16 +241,28 @@ func (col *collectionController) DeleteDocumentFromCollection(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to remove document from collection"))
 		return
 	}
+	services.EmitEvent(col.DB, userID, services.EventCollectionUpdated, services.CollectionEvent{
+		CollectionID: collection.ID, Name: collection.Name, Change: services.CollectionDocumentRemoved, DocumentID: document.ID,
+	})
 
 	c.JSON(http.StatusOK, helper.NewSuccessResponse("Document successfully deleted from collection"))
 }
 
 // GetCollections godoc
 // @Summary Get all collections
-// @Description Returns all collections belonging to the authenticated user
+// @Description Returns a page of collections belonging to the authenticated user with the number of documents in each. Documents themselves are returned only with include=documents. Sorting by size means by the number of documents
 // @Tags Collections
 // @Produce json
-// @Success 200 {object} helper.Response{data=[]models.Collection} "List of collections"
+// @Param name query string false "Name substring, case insensitive"
+// @Param created_from query string false "Created at or after, YYYY-MM-DD or RFC 3339"
+// @Param created_to query string false "Created before, YYYY-MM-DD (inclusive) or RFC 3339"
+// @Param sort query string false "Sort by" Enums(name, created_at, size) default(created_at)
+// @Param order query string false "Sort order, by default asc for name and desc otherwise" Enums(asc, desc)
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from meta.next_cursor of the previous page"
+// @Param include query string false "documents - include documents of each collection"
+// @Success 200 {object} helper.Response{data=[]dto.CollectionListItem,meta=helper.PageMeta} "Page of collections"
+// @Failure 400 {object} helper.Response
 // @Failure 401 {object} helper.Response
 // @Failure 500 {object} helper.Response
 // @Router /collections [get]
@@ -242,13 +273,84 @@ func (col *collectionController) GetCollections(c *gin.Context) {
 		return
 	}
 
-	var collections []models.Collection
-	if err := col.DB.Preload("Documents").Where("user_id = ?", userID).Find(&collections).Error; err != nil {
+	params, err := helper.ParseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		return
+	}
+
+	query := params.Filter(col.DB.Model(&models.Collection{}).Where("collections.user_id = ?", userID), "collections")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collections"))
+		return
+	}
+
+	const documentCount = "(SELECT COUNT(*) FROM collection_documents cd WHERE cd.collection_id = collections.id)"
+	page, err := params.Page(query.Select("collections.*, "+documentCount+" AS document_count"), "collections", map[string]string{
+		helper.SortName:      "collections.name",
+		helper.SortCreatedAt: "collections.created_at",
+		helper.SortSize:      documentCount,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid cursor"))
+		return
+	}
+
+	var collections []dto.CollectionListItem
+	if err := page.Find(&collections).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collections"))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.NewSuccessResponse(collections))
+	hasMore := len(collections) > params.Limit
+	if hasMore {
+		collections = collections[:params.Limit]
+	}
+
+	if c.Query("include") == "documents" && len(collections) > 0 {
+		ids := make([]uint, 0, len(collections))
+		for _, collection := range collections {
+			ids = append(ids, collection.ID)
+		}
+
+		var withDocuments []models.Collection
+		if err := col.DB.Preload("Documents").Where("id IN ?", ids).Find(&withDocuments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collections"))
+			return
+		}
+
+		documents := make(map[uint][]*models.Document, len(withDocuments))
+		for _, collection := range withDocuments {
+			documents[collection.ID] = collection.Documents
+		}
+		for i := range collections {
+			collections[i].Documents = documents[collections[i].ID]
+		}
+	}
+
+	var meta helper.PageMeta
+	if hasMore {
+		last := collections[len(collections)-1]
+		meta = params.Meta(total, true, collectionSortValue(last, params.Sort), last.ID)
+	} else {
+		meta = params.Meta(total, false, nil, 0)
+	}
+
+	c.JSON(http.StatusOK, helper.NewPageResponse(collections, meta))
+}
+
+// Значение поля, по которому отсортирован список коллекций
+func collectionSortValue(collection dto.CollectionListItem, sort string) any {
+	switch sort {
+	case helper.SortName:
+		return collection.Name
+	case helper.SortSize:
+		return collection.DocumentCount
+	default:
+		return collection.CreatedAt
+	}
 }
 
 // GetCollectionByID godoc
@@ -333,6 +435,9 @@ func (col *collectionController) UpdateCollection(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to update collection"))
 		return
 	}
+	services.EmitEvent(col.DB, userID, services.EventCollectionUpdated, services.CollectionEvent{
+		CollectionID: collection.ID, Name: collection.Name, Change: services.CollectionRenamed,
+	})
 
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(collection))
 }
@@ -382,7 +487,7 @@ func (col *collectionController) DeleteCollection(c *gin.Context) {
 
 // GetCollectionStatistics godoc
 // @Summary Get collection statistics
-// @Description Gets statistics for the collection: TF is calculated as if all documents in the collection were one document, IDF unchanged (gives top 50 most frequent words and their idf)
+// @Description Gets statistics for the collection: TF is calculated as if all documents in the collection were one document, IDF unchanged (gives top 50 most frequent words and their idf). The summary section describes all documents of the collection together
 // @Tags Collections
 // @Produce json
 // @Param collection_id path string true "Collection ID"
@@ -416,19 +521,26 @@ func (col *collectionController) GetCollectionStatistics(c *gin.Context) {
 	}
 
 	var collectionDocuments []map[string]int
-	var tfWords []string
+	wordCount := make(map[string]int)
+	totalWords := 0
+	totalSentences := 0
+	computed := false
 	for _, doc := range collection.Documents {
-		words, err := services.ProcessFile(doc.FilePath)
+		counts, sentences, docComputed, err := services.CountDocumentText(c.Request.Context(), *doc)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Cannot process file: "+err.Error()))
 			return
 		}
-		collectionDocuments = append(collectionDocuments, services.CountWords(words))
-		tfWords = append(tfWords, words...)
+		collectionDocuments = append(collectionDocuments, counts.Counts)
+		for word, count := range counts.Counts {
+			wordCount[word] += count
+		}
+		totalWords += counts.Total
+		totalSentences += sentences
+		computed = computed || docComputed
 	}
 
-	wordCount := services.CountWords(tfWords)
-	tf := services.CalculateTF(wordCount, len(tfWords))
+	tf := services.CalculateTF(wordCount, totalWords)
 
 	idf := services.CalculateIDF(collectionDocuments)
 
@@ -444,10 +556,213 @@ func (col *collectionController) GetCollectionStatistics(c *gin.Context) {
 		rareWords[i].Count = wordCount[rareWords[i].Word]
 	}
 
+	summary := services.Summarize(services.WordCounts{Counts: wordCount, Total: totalWords}, totalSentences)
+	// Событие - только когда хоть один документ разобран заново, а не взят из кэша
+	if computed {
+		services.EmitEvent(col.DB, userID, services.EventStatisticsReady, services.StatisticsEvent{
+			Kind: "collection", ID: collection.ID, Name: collection.Name, Summary: summary,
+		})
+	}
+
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(gin.H{
 		"statistics": rareWords,
+		"summary":    summary,
 		"meta": gin.H{
 			"total_documents": len(collection.Documents),
 		},
 	}))
 }
+
+// GetCollectionConcordance godoc
+// @Summary Get keyword in context across a collection
+// @Description Finds every occurrence of the word in the collection documents using the positional index and returns it with window words of left and right context and byte offsets, grouped by document. Only documents containing the word are read
+// @Tags Collections
+// @Produce json
+// @Param collection_id path string true "Collection ID"
+// @Param term query string true "Word to find"
+// @Param window query int false "Context words on each side, 0-50" default(5)
+// @Param limit query int false "Max number of occurrences in total, 1-1000" default(100)
+// @Success 200 {object} helper.Response{data=services.ConcordanceResult} "Occurrences with context"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /collections/{collection_id}/concordance [get]
+func (col *collectionController) GetCollectionConcordance(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	term, window, limit, ok := parseConcordanceQuery(c)
+	if !ok {
+		return
+	}
+
+	var collection models.Collection
+	err = col.DB.Preload("Documents", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("id = ? AND user_id = ?", c.Param("collection_id"), userID).First(&collection).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Collection not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collection"))
+		return
+	}
+
+	documents := make([]models.Document, 0, len(collection.Documents))
+	for _, document := range collection.Documents {
+		documents = append(documents, *document)
+	}
+
+	result, err := services.FindConcordance(c.Request.Context(), col.DB, documents, term, window, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to build concordance"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(result))
+}
+
+// GetCollectionVocabulary godoc
+// @Summary Get collection vocabulary
+// @Description Lists every distinct word of the collection documents with df (documents containing it), cf (total count) and IDF, from the most frequent to the rarest. zipf_rank is the word's place by cf over the whole vocabulary, hapax marks words occurring exactly once. Served from the positional index
+// @Tags Collections
+// @Produce json
+// @Param collection_id path string true "Collection ID"
+// @Param prefix query string false "Only words starting with this prefix"
+// @Param min_df query int false "Minimum document frequency" default(1)
+// @Param max_df query int false "Maximum document frequency"
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Page size, 1-1000" default(100)
+// @Success 200 {object} helper.Response{data=services.VocabularyPage} "Collection vocabulary"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /collections/{collection_id}/vocabulary [get]
+func (col *collectionController) GetCollectionVocabulary(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	filter := services.VocabularyFilter{}
+	if prefix := c.Query("prefix"); prefix != "" {
+		if filter.Prefix, err = services.NormalizeTerm(prefix); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("prefix must be a single word"))
+			return
+		}
+	}
+
+	var errMin, errMax, errPage, errPerPage error
+	filter.MinDF, errMin = strconv.Atoi(c.DefaultQuery("min_df", "1"))
+	filter.MaxDF, errMax = strconv.Atoi(c.DefaultQuery("max_df", "0"))
+	filter.Page, errPage = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PerPage, errPerPage = strconv.Atoi(c.DefaultQuery("per_page", "100"))
+	if errMin != nil || errMax != nil || errPage != nil || filter.MinDF < 1 || filter.MaxDF < 0 || filter.Page < 1 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("min_df, max_df and page must be positive numbers"))
+		return
+	}
+	if errPerPage != nil || filter.PerPage < 1 || filter.PerPage > 1000 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("per_page must be a number from 1 to 1000"))
+		return
+	}
+
+	var collection models.Collection
+	if err := col.DB.Where("id = ? AND user_id = ?", c.Param("collection_id"), userID).First(&collection).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Collection not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collection"))
+		return
+	}
+
+	page, err := services.CollectionVocabulary(col.DB, collection.ID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collection vocabulary"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(page))
+}
+
+// Сколько слов можно запросить в /trends за раз
+const maxTrendTerms = 20
+
+// GetCollectionTrends godoc
+// @Summary Get term trends over time in a collection
+// @Description Groups collection documents into time buckets by document_date (upload date if not set) and returns, for each requested word, its count, relative frequency and TF-IDF (IDF over the collection documents) in every bucket, empty buckets included. emerging lists words occurring significantly more often in the latest bucket than in all earlier ones together (log-likelihood >= 3.84). Served from the positional index
+// @Tags Collections
+// @Produce json
+// @Param collection_id path string true "Collection ID"
+// @Param terms query string false "Comma-separated words, up to 20"
+// @Param bucket query string false "Bucket size" Enums(day, week, month, quarter, year) default(month)
+// @Success 200 {object} helper.Response{data=services.TrendResult} "Term trends"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /collections/{collection_id}/trends [get]
+func (col *collectionController) GetCollectionTrends(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	// terms=a,b и terms=a&terms=b - одно и то же
+	var terms []string
+	seen := make(map[string]bool)
+	for _, value := range c.QueryArray("terms") {
+		for _, raw := range strings.Split(value, ",") {
+			if strings.TrimSpace(raw) == "" {
+				continue
+			}
+			term, err := services.NormalizeTerm(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, helper.NewErrorResponse("terms must be single words separated by commas"))
+				return
+			}
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	if len(terms) > maxTrendTerms {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(fmt.Sprintf("at most %d terms are allowed", maxTrendTerms)))
+		return
+	}
+
+	var collection models.Collection
+	if err := col.DB.Preload("Documents").Where("id = ? AND user_id = ?", c.Param("collection_id"), userID).First(&collection).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Collection not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collection"))
+		return
+	}
+
+	documents := make([]models.Document, 0, len(collection.Documents))
+	for _, doc := range collection.Documents {
+		documents = append(documents, *doc)
+	}
+
+	result, err := services.CollectionTrends(col.DB, documents, terms, c.DefaultQuery("bucket", services.BucketMonth))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBucket) || errors.Is(err, services.ErrTooManyBuckets) {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to build trends"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(result))
+}
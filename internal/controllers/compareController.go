@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CompareController interface {
+	Compare(c *gin.Context)
+}
+
+type compareController struct {
+	DB *gorm.DB
+}
+
+func NewCompareController(db *gorm.DB) CompareController {
+	return &compareController{DB: db}
+}
+
+const invalidCompareSideMessage = "left and right must look like doc:12 or collection:4"
+
+// Compare godoc
+// @Summary Compare two documents or collections term by term
+// @Description Each side is a document (doc:ID) or a collection (collection:ID), a collection counts as all its documents together. Returns words distinctive to each side by log-likelihood keyness (only significant ones, LL >= 3.84, p < 0.05) with chi-square, shared words with the ratio of relative frequencies, and cosine similarity of the sides' TF-IDF vectors (IDF over the whole library of the user) and of plain TF vectors
+// @Tags Compare
+// @Produce json
+// @Param left query string true "Left side: doc:ID or collection:ID"
+// @Param right query string true "Right side: doc:ID or collection:ID"
+// @Param limit query int false "Max number of words in each list, 1-200" default(25)
+// @Success 200 {object} helper.Response{data=services.CompareResult} "Comparison"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /compare [get]
+func (cmp *compareController) Compare(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "25"))
+	if err != nil || limit < 1 || limit > 200 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("limit must be a number from 1 to 200"))
+		return
+	}
+
+	left, ok := cmp.loadSide(c, userID, c.Query("left"))
+	if !ok {
+		return
+	}
+	right, ok := cmp.loadSide(c, userID, c.Query("right"))
+	if !ok {
+		return
+	}
+
+	idf, err := services.LibraryIDF(cmp.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get corpus documents"))
+		return
+	}
+
+	result, err := services.Compare(c.Request.Context(), left, right, idf, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Cannot process file: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(result))
+}
+
+// loadSide находит документ или коллекцию пользователя по "doc:12" / "collection:4".
+// При ошибке сам отвечает клиенту.
+func (cmp *compareController) loadSide(c *gin.Context, userID int, spec string) (*services.CompareSide, bool) {
+	kind, rawID, _ := strings.Cut(spec, ":")
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(invalidCompareSideMessage))
+		return nil, false
+	}
+
+	switch kind {
+	case "doc", "document":
+		var document models.Document
+		if err := cmp.DB.Where("id = ? AND user_id = ?", id, userID).First(&document).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+				return nil, false
+			}
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+			return nil, false
+		}
+		return services.NewCompareSide("document", document.ID, document.Name, []models.Document{document}), true
+
+	case "collection":
+		var collection models.Collection
+		if err := cmp.DB.Preload("Documents").Where("id = ? AND user_id = ?", id, userID).First(&collection).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, helper.NewErrorResponse("Collection not found"))
+				return nil, false
+			}
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collection"))
+			return nil, false
+		}
+		documents := make([]models.Document, 0, len(collection.Documents))
+		for _, doc := range collection.Documents {
+			documents = append(documents, *doc)
+		}
+		return services.NewCompareSide("collection", collection.ID, collection.Name, documents), true
+	}
+
+	c.JSON(http.StatusBadRequest, helper.NewErrorResponse(invalidCompareSideMessage))
+	return nil, false
+}
@@ -1,16 +1,23 @@
 package controllers
 
 import (
-	"bytes"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"mime"
 	"net/http"
-	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"tfidf-app/internal/config"
 	"tfidf-app/internal/dto"
 	"tfidf-app/internal/helper"
 	"tfidf-app/internal/models"
 	"tfidf-app/internal/services"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -18,10 +25,22 @@ import (
 
 type DocumentController interface {
 	GetDocumentHuffman(c *gin.Context)
+	GetDocumentHighlight(c *gin.Context)
+	GetDocumentSummary(c *gin.Context)
+	GetDocumentConcordance(c *gin.Context)
 	GetDocuments(c *gin.Context)
+	SearchDocuments(c *gin.Context)
 	GetDocumentByID(c *gin.Context)
+	DownloadDocument(c *gin.Context)
+	UpdateDocument(c *gin.Context)
 	DeleteDocument(c *gin.Context)
 	GetDocumentStatistics(c *gin.Context)
+	ReplaceDocumentContent(c *gin.Context)
+	CreateDocumentFromText(c *gin.Context)
+	CreateDocumentFromURL(c *gin.Context)
+	GetDocumentVersions(c *gin.Context)
+	GetDocumentVersion(c *gin.Context)
+	GetDocumentVersionsDiff(c *gin.Context)
 }
 
 type documentController struct {
@@ -67,25 +86,12 @@ func (d *documentController) GetDocumentHuffman(c *gin.Context) {
 		return
 	}
 
-	content, err := os.ReadFile(document.FilePath)
+	encodedContent, err := services.HuffmanEncodingCached(c.Request.Context(), document)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to read document content"))
-		return
-	}
-
-	encodedContent, root, err := services.HuffmanEncoding(content)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to encode the conten: "+err.Error()))
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Huffman error: "+err.Error()))
 		return
 	}
 
-	decoded, err := services.HuffmanDecoding(encodedContent, root)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Decoding error: "+err.Error()))
-	} else if !bytes.Equal(decoded, content) {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Decoded content doesn't match original!"))
-	}
-
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(gin.H{
 		"encoded_content": encodedContent,
 	}))
@@ -93,10 +99,20 @@ func (d *documentController) GetDocumentHuffman(c *gin.Context) {
 
 // GetDocuments godoc
 // @Summary Get all user documents
-// @Description Returns a list of all documents belonging to the authenticated user
+// @Description Returns a page of documents belonging to the authenticated user with their metadata and tags. Can be filtered by tags (documents having all given tags), MIME type ("text/plain" or "text/*"), name substring and creation date range. The next page is requested with meta.next_cursor
 // @Tags Documents
 // @Produce json
-// @Success 200 {object} helper.Response{data=[]models.Document} "List of documents"
+// @Param tag query []string false "Tag, can be repeated" collectionFormat(multi)
+// @Param mime query string false "MIME type, e.g. text/plain or text/*"
+// @Param name query string false "Name substring, case insensitive"
+// @Param created_from query string false "Created at or after, YYYY-MM-DD or RFC 3339"
+// @Param created_to query string false "Created before, YYYY-MM-DD (inclusive) or RFC 3339"
+// @Param sort query string false "Sort by" Enums(name, created_at, size) default(created_at)
+// @Param order query string false "Sort order, by default asc for name and desc otherwise" Enums(asc, desc)
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from meta.next_cursor of the previous page"
+// @Success 200 {object} helper.Response{data=[]models.Document,meta=helper.PageMeta} "Page of documents"
+// @Failure 400 {object} helper.Response
 // @Failure 401 {object} helper.Response
 // @Failure 500 {object} helper.Response
 // @Router /documents [get]
@@ -107,21 +123,80 @@ func (d *documentController) GetDocuments(c *gin.Context) {
 		return
 	}
 
+	params, err := helper.ParseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		return
+	}
+
+	query := d.DB.Model(&models.Document{}).Where("documents.user_id = ?", userID)
+	for _, tag := range c.QueryArray("tag") {
+		query = query.Where("documents.id IN (SELECT dt.document_id FROM document_tags dt JOIN tags t ON t.id = dt.tag_id WHERE t.name = ? AND t.user_id = ?)",
+			strings.ToLower(strings.TrimSpace(tag)), userID)
+	}
+	if mimeType := c.Query("mime"); mimeType != "" {
+		if prefix, ok := strings.CutSuffix(mimeType, "/*"); ok {
+			query = query.Where("documents.mime_type LIKE ?", prefix+"/%")
+		} else {
+			query = query.Where("documents.mime_type = ?", mimeType)
+		}
+	}
+	query = params.Filter(query, "documents")
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to fetch documents"))
+		return
+	}
+
+	page, err := params.Page(query.Preload("Tags"), "documents", map[string]string{
+		helper.SortName:      "documents.name",
+		helper.SortCreatedAt: "documents.created_at",
+		helper.SortSize:      "documents.size",
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid cursor"))
+		return
+	}
+
 	var documents []models.Document
-	if err := d.DB.Where("user_id = ?", userID).Find(&documents).Error; err != nil {
+	if err := page.Find(&documents).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to fetch documents"))
 		return
 	}
 
-	c.JSON(http.StatusOK, helper.NewSuccessResponse(documents))
+	hasMore := len(documents) > params.Limit
+	var meta helper.PageMeta
+	if hasMore {
+		documents = documents[:params.Limit]
+		last := documents[len(documents)-1]
+		meta = params.Meta(total, true, documentSortValue(last, params.Sort), last.ID)
+	} else {
+		meta = params.Meta(total, false, nil, 0)
+	}
+
+	c.JSON(http.StatusOK, helper.NewPageResponse(documents, meta))
+}
+
+// Значение поля, по которому отсортирован список документов
+func documentSortValue(document models.Document, sort string) any {
+	switch sort {
+	case helper.SortName:
+		return document.Name
+	case helper.SortSize:
+		return document.Size
+	default:
+		return document.CreatedAt
+	}
 }
 
 // GetDocumentByID godoc
 // @Summary Get a specific document
-// @Description Returns document details and content by ID
+// @Description Returns document details and content by ID as a text preview. With max_length the content is cut to that many bytes (on a character boundary) and truncated is set. Binary content is not returned, use /documents/{document_id}/download for the original bytes
 // @Tags Documents
 // @Produce json
 // @Param document_id path string true "Document ID"
+// @Param max_length query int false "Maximum content length in bytes"
 // @Success 200 {object} helper.Response{data=dto.DocumentResponse} "Document details"
 // @Failure 400 {object} helper.Response
 // @Failure 401 {object} helper.Response
@@ -143,7 +218,7 @@ func (d *documentController) GetDocumentByID(c *gin.Context) {
 	}
 
 	var document models.Document
-	if err := d.DB.Where("id = ? AND user_id = ?", docID, userID).First(&document).Error; err != nil {
+	if err := d.DB.Preload("Tags").Where("id = ? AND user_id = ?", docID, userID).First(&document).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
 			return
@@ -152,22 +227,261 @@ func (d *documentController) GetDocumentByID(c *gin.Context) {
 		return
 	}
 
-	content, err := os.ReadFile(document.FilePath)
+	maxLength, ok := parseMaxLength(c)
+	if !ok {
+		return
+	}
+
+	content, truncated, err := readContentPreview(func() (io.ReadCloser, error) {
+		return services.OpenDocumentContent(c.Request.Context(), document)
+	}, document.Size, maxLength)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to read document content"))
 		return
 	}
 
+	// Бинарное содержимое в JSON-строку не кладем, его отдает /download
+	binary := !utf8.Valid(content)
+	if binary {
+		content = nil
+	}
+
+	tags := make([]string, 0, len(document.Tags))
+	for _, tag := range document.Tags {
+		tags = append(tags, tag.Name)
+	}
+
 	response := dto.DocumentResponse{
-		ID:          document.ID,
-		Name:        document.Name,
-		Content:     string(content),
-		UplodadedAt: document.CreatedAt,
+		ID:           document.ID,
+		Name:         document.Name,
+		Content:      string(content),
+		Description:  document.Description,
+		Source:       document.Source,
+		MimeType:     document.MimeType,
+		Size:         document.Size,
+		TokenCount:   document.TokenCount,
+		UniqueTerms:  document.UniqueTerms,
+		ContentHash:  document.ContentHash,
+		Version:      document.Version,
+		Tags:         tags,
+		DocumentDate: document.DocumentDate,
+		Truncated:    truncated,
+		Binary:       binary,
+		UplodadedAt:  document.CreatedAt,
 	}
 
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(response))
 }
 
+// parseMaxLength разбирает max_length превью содержимого, без него -1 (целиком). При ошибке сам отвечает клиенту.
+func parseMaxLength(c *gin.Context) (int64, bool) {
+	value := c.Query("max_length")
+	if value == "" {
+		return -1, true
+	}
+	maxLength, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || maxLength < 0 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid max_length"))
+		return 0, false
+	}
+	return maxLength, true
+}
+
+// readContentPreview читает не больше maxLength байт содержимого размером size (maxLength < 0 - целиком).
+// Обрезает по границе UTF-8 символа, чтобы в превью не было половины буквы.
+func readContentPreview(open func() (io.ReadCloser, error), size, maxLength int64) ([]byte, bool, error) {
+	rc, err := open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	if maxLength < 0 || maxLength >= size {
+		content, err := io.ReadAll(rc)
+		return content, false, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(rc, maxLength+utf8.UTFMax))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(content)) <= maxLength {
+		return content, false, nil
+	}
+
+	// Если сразу за обрезом начинается новый символ, обрез уже на границе
+	cut := int(maxLength)
+	for i := 1; i < utf8.UTFMax && cut > 0 && !utf8.RuneStart(content[cut]); i++ {
+		cut--
+	}
+	return content[:cut], true, nil
+}
+
+// DownloadDocument godoc
+// @Summary Download document content
+// @Description Streams the original bytes of the document with its MIME type and file name. Supports Range requests for partial downloads and resuming, and ETag (content hash) with If-None-Match
+// @Tags Documents
+// @Produce octet-stream
+// @Param document_id path string true "Document ID"
+// @Param inline query bool false "Show in the browser instead of saving as a file"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {file} file "Document content"
+// @Success 206 {file} file "Requested range"
+// @Success 304 "Not modified"
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 416 "Range not satisfiable"
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/download [get]
+func (d *documentController) DownloadDocument(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", c.Param("document_id"), userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	contentType := document.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	} else if strings.HasPrefix(contentType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+
+	disposition := "attachment"
+	if inline, _ := strconv.ParseBool(c.Query("inline")); inline {
+		disposition = "inline"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": document.Name}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	if document.ContentHash != "" {
+		// Содержимое адресуется хэшем, поэтому он и есть ETag
+		c.Header("ETag", `"`+document.ContentHash+`"`)
+		c.Header("Cache-Control", "private, no-cache")
+	}
+
+	content := services.NewContentSeeker(c.Request.Context(), document)
+	defer content.Close()
+
+	// ServeContent сам отвечает на Range, If-Range, If-None-Match и HEAD
+	http.ServeContent(c.Writer, c.Request, document.Name, document.UpdatedAt, content)
+}
+
+// UpdateDocument godoc
+// @Summary Update document name and metadata
+// @Description Renames a document and updates its description, source, date (document_date, empty string removes it) and tags. Fields missing in the request are not changed, tags replace all current tags of the document. Document names are unique per user, renaming to a taken name returns 409
+// @Tags Documents
+// @Accept json
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Param request body dto.UpdateDocumentReq true "Metadata to change"
+// @Success 200 {object} helper.Response{data=models.Document} "Updated document"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 409 {object} helper.Response "Name is taken by another document"
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id} [patch]
+func (d *documentController) UpdateDocument(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	documentID := c.Param("document_id")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document ID is required"))
+		return
+	}
+
+	var req dto.UpdateDocumentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	var tags []string
+	if req.Tags != nil {
+		if tags, err = services.NormalizeTags(*req.Tags); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+	}
+
+	var documentDate *time.Time
+	if req.DocumentDate != nil {
+		if documentDate, err = services.ParseDocumentDate(*req.DocumentDate); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+	}
+
+	var document models.Document
+	err = d.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+			return err
+		}
+
+		if req.Name != nil {
+			if err := services.RenameDocument(tx, &document, *req.Name); err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]any{}
+		if req.Description != nil {
+			updates["description"] = *req.Description
+		}
+		if req.Source != nil {
+			updates["source"] = *req.Source
+		}
+		if req.DocumentDate != nil {
+			updates["document_date"] = documentDate
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&document).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if req.Tags != nil {
+			if err := services.SetDocumentTags(tx, &document, tags); err != nil {
+				return err
+			}
+		}
+
+		return tx.Preload("Tags").First(&document, document.ID).Error
+	})
+	if err != nil {
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+		case errors.Is(err, services.ErrDocumentNameTaken):
+			c.JSON(http.StatusConflict, helper.NewErrorResponse(err.Error()))
+		case errors.Is(err, services.ErrInvalidDocumentName):
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		default:
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to update document"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(document))
+}
+
 // DeleteDocument godoc
 // @Summary Delete a document
 // @Description Deletes a document by ID (both file and database record)
@@ -203,15 +517,29 @@ func (d *documentController) DeleteDocument(c *gin.Context) {
 		return
 	}
 
-	// Удаление файла с диска
-	if err := os.Remove(document.FilePath); err != nil && !os.IsNotExist(err) {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete file from disk: "+err.Error()))
+	// Удаление записи из базы данных вместе со ссылкой на содержимое
+	var deleteContent func(ctx context.Context) error
+	err = d.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if deleteContent, err = services.ReleaseDocumentContent(tx, document); err != nil {
+			return err
+		}
+		if err := tx.Delete(&document).Error; err != nil {
+			return err
+		}
+		return services.DeleteUnusedTags(tx, userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete document from database"))
 		return
 	}
+	services.EmitEvent(d.DB, userID, services.EventDocumentDeleted, services.DocumentEvent{
+		DocumentID: document.ID, Name: document.Name, Version: document.Version,
+	})
 
-	// Удаление записи из базы данных
-	if err := d.DB.Delete(&document).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete document from database"))
+	// Удаление файла из хранилища, если на него больше никто не ссылается
+	if err := deleteContent(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete file from storage: "+err.Error()))
 		return
 	}
 
@@ -220,7 +548,7 @@ func (d *documentController) DeleteDocument(c *gin.Context) {
 
 // GetDocumentStatistics godoc
 // @Summary Get document statistics
-// @Description Calculates TF statistics for a given document, and IDF calculated as if all documents in collections, where the document we specified is, is in one collection
+// @Description Calculates TF statistics for a given document, and IDF calculated as if all documents in collections, where the document we specified is, is in one collection. The summary section describes the whole text: tokens, types, type-token ratio, hapaxes, average word and sentence length, Zipf exponent, Shannon entropy and Flesch readability (Oborneva's adaptation for Russian)
 // @Tags Documents
 // @Produce json
 // @Param document_id path string true "Document ID"
@@ -253,15 +581,16 @@ func (d *documentController) GetDocumentStatistics(c *gin.Context) {
 		return
 	}
 
-	words, err := services.ProcessFile(document.FilePath)
+	counts, sentences, computed, err := services.CountDocumentText(c.Request.Context(), document)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Cannot process file: "+err.Error()))
 		return
 	}
+	summary := services.Summarize(counts, sentences)
 
 	// 4. Расчет TF для текущего документа
-	wordCount := services.CountWords(words)
-	tf := services.CalculateTF(wordCount, len(words))
+	wordCount := counts.Counts
+	tf := services.CalculateTF(wordCount, counts.Total)
 
 	// 5. Обработка случая с коллекциями
 	if len(document.Collections) > 0 {
@@ -272,16 +601,7 @@ func (d *documentController) GetDocumentStatistics(c *gin.Context) {
 		}
 
 		// Подготовка данных для IDF
-		var collectionWords []map[string]int
-		for _, doc := range allDocs {
-			words, err := services.ProcessFile(doc.FilePath)
-			if err != nil {
-				// эррор будет нужжен только мне
-				log.Printf("Failed to process file %s: %v", doc.FilePath, err)
-				continue
-			}
-			collectionWords = append(collectionWords, services.CountWords(words))
-		}
+		collectionWords := services.CorpusWordCounts(c.Request.Context(), allDocs)
 
 		// Расчет статистики
 		idf := services.CalculateIDF(collectionWords)
@@ -297,8 +617,16 @@ func (d *documentController) GetDocumentStatistics(c *gin.Context) {
 			rareWords[i].Count = wordCount[rareWords[i].Word]
 		}
 
+		// Событие - только когда статистика действительно посчитана, а не взята из кэша
+		if computed {
+			services.EmitEvent(d.DB, userID, services.EventStatisticsReady, services.StatisticsEvent{
+				Kind: "document", ID: document.ID, Name: document.Name, Summary: summary,
+			})
+		}
+
 		c.JSON(http.StatusOK, helper.NewSuccessResponse(gin.H{
 			"statistics": rareWords,
+			"summary":    summary,
 			"meta": gin.H{
 				"total_collections": len(document.Collections),
 				"total_documents":   len(allDocs),
@@ -327,11 +655,702 @@ func (d *documentController) GetDocumentStatistics(c *gin.Context) {
 		tfOnlyStats = tfOnlyStats[:50]
 	}
 
+	if computed {
+		services.EmitEvent(d.DB, userID, services.EventStatisticsReady, services.StatisticsEvent{
+			Kind: "document", ID: document.ID, Name: document.Name, Summary: summary,
+		})
+	}
+
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(gin.H{
 		"meta": gin.H{
 			"message": "Document is not in any collections - showing TF only",
 		},
 		"statistics": tfOnlyStats,
+		"summary":    summary,
 	}),
 	)
 }
+
+// ReplaceDocumentContent godoc
+// @Summary Replace document content
+// @Description Uploads new content for an existing document as its new version, previous versions are kept
+// @Tags Documents
+// @Accept multipart/form-data
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Param file formData file true "New document content"
+// @Param progress_id query string false "ID of upload.progress events in GET /events, random by default"
+// @Success 200 {object} helper.Response{data=object} "TF-IDF statistics of the new version"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 413 {object} helper.Response "File is too large or storage quota exceeded"
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/content [put]
+func (d *documentController) ReplaceDocumentContent(c *gin.Context) {
+	startTime := time.Now()
+
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	documentID := c.Param("document_id")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document ID is required"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	staged, _, ok := stageMultipartFile(c, userID)
+	if !ok {
+		return
+	}
+	defer staged.Remove()
+
+	result, err := services.ReplaceDocumentContent(c.Request.Context(), document, staged, startTime)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(uploadResponse(result)))
+}
+
+// CreateDocumentFromText godoc
+// @Summary Create a document from text
+// @Description Saves the text from the request body as a document and processes it the same way as an uploaded file. A document with the same name gets a new version
+// @Tags Documents
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateDocumentFromTextReq true "Document name and content"
+// @Param progress_id query string false "ID of upload.progress events in GET /events, random by default"
+// @Success 200 {object} helper.Response{data=object} "TF-IDF statistics"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 409 {object} helper.Response "Name conflict with a concurrent request"
+// @Failure 413 {object} helper.Response "Text is too large or storage quota exceeded"
+// @Failure 500 {object} helper.Response
+// @Router /documents/from-text [post]
+func (d *documentController) CreateDocumentFromText(c *gin.Context) {
+	startTime := time.Now()
+
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.CreateDocumentFromTextReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	progress := newUploadProgress(c, userID, req.Name, int64(len(req.Content)))
+	staged, err := services.StageContent(strings.NewReader(req.Content), config.Init.MaxUploadSize, progress)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+	defer staged.Remove()
+	staged.Source = services.SourceText
+
+	result, err := services.IngestDocument(c.Request.Context(), userID, req.Name, staged, startTime)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(uploadResponse(result)))
+}
+
+// CreateDocumentFromURL godoc
+// @Summary Create a document from a URL
+// @Description Downloads the page by the link (http or https, hosts from FETCH_ALLOWLIST if it is set), extracts the readable text from HTML and processes it the same way as an uploaded file. The name defaults to the last segment of the URL path
+// @Tags Documents
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateDocumentFromURLReq true "Link and optional document name"
+// @Param progress_id query string false "ID of upload.progress events in GET /events, random by default"
+// @Success 200 {object} helper.Response{data=object} "TF-IDF statistics"
+// @Failure 400 {object} helper.Response "Invalid or not allowed URL"
+// @Failure 401 {object} helper.Response
+// @Failure 409 {object} helper.Response "Name conflict with a concurrent request"
+// @Failure 413 {object} helper.Response "Page is too large or storage quota exceeded"
+// @Failure 415 {object} helper.Response "Not a text page"
+// @Failure 500 {object} helper.Response
+// @Failure 502 {object} helper.Response "Failed to download the page"
+// @Router /documents/from-url [post]
+func (d *documentController) CreateDocumentFromURL(c *gin.Context) {
+	startTime := time.Now()
+
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.CreateDocumentFromURLReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	progress := newUploadProgress(c, userID, req.URL, 0)
+	staged, name, err := services.StageURL(c.Request.Context(), req.URL, config.Init.MaxUploadSize, progress)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+	defer staged.Remove()
+
+	if req.Name != "" {
+		name = req.Name
+	}
+
+	result, err := services.IngestDocument(c.Request.Context(), userID, name, staged, startTime)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(uploadResponse(result)))
+}
+
+// GetDocumentVersions godoc
+// @Summary Get document versions
+// @Description Returns the version history of a document, oldest first
+// @Tags Documents
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Success 200 {object} helper.Response{data=[]models.DocumentVersion} "Document versions"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/versions [get]
+func (d *documentController) GetDocumentVersions(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	documentID := c.Param("document_id")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document ID is required"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	var versions []models.DocumentVersion
+	if err := d.DB.Where("document_id = ?", document.ID).Order("version").Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document versions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(versions))
+}
+
+// GetDocumentVersion godoc
+// @Summary Get a specific document version
+// @Description Returns the content of the given version of a document as a text preview. With max_length the content is cut to that many bytes (on a character boundary) and truncated is set. Binary content is not returned
+// @Tags Documents
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Param version path int true "Version number"
+// @Param max_length query int false "Maximum content length in bytes"
+// @Success 200 {object} helper.Response{data=dto.DocumentVersionResponse} "Document version"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/versions/{version} [get]
+func (d *documentController) GetDocumentVersion(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	documentID := c.Param("document_id")
+	versionNumber, err := strconv.Atoi(c.Param("version"))
+	if documentID == "" || err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document ID and numeric version are required"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	maxLength, ok := parseMaxLength(c)
+	if !ok {
+		return
+	}
+
+	version, ok := d.findVersion(c, document.ID, versionNumber)
+	if !ok {
+		return
+	}
+
+	content, truncated, err := readContentPreview(func() (io.ReadCloser, error) {
+		return services.OpenVersionContent(c.Request.Context(), version)
+	}, version.Size, maxLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to read document content"))
+		return
+	}
+
+	// Как и в превью документа, бинарное содержимое в JSON-строку не кладем
+	binary := !utf8.Valid(content)
+	if binary {
+		content = nil
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(dto.DocumentVersionResponse{
+		DocumentID: document.ID,
+		Name:       document.Name,
+		Version:    version.Version,
+		Content:    string(content),
+		Size:       version.Size,
+		Truncated:  truncated,
+		Binary:     binary,
+		CreatedAt:  version.CreatedAt,
+	}))
+}
+
+// GetDocumentVersionsDiff godoc
+// @Summary Diff two document versions
+// @Description Term-level diff between TF-IDF profiles of two versions. IDF is calculated over the documents of the document's collections, or over the whole library if it is in none
+// @Tags Documents
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Param from query int false "Old version (default: previous, required to be given for a document with one version)"
+// @Param to query int false "New version (default: current)"
+// @Param limit query int false "Max number of terms (default: 50)"
+// @Success 200 {object} helper.Response{data=object} "Changed terms"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/versions/diff [get]
+func (d *documentController) GetDocumentVersionsDiff(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	documentID := c.Param("document_id")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document ID is required"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	// По умолчанию сравнивается с предыдущей версией, а у единственной версии ее нет
+	if c.Query("from") == "" && document.Version < 2 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("document has only one version"))
+		return
+	}
+
+	fromNumber, errFrom := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(document.Version-1)))
+	toNumber, errTo := strconv.Atoi(c.DefaultQuery("to", strconv.Itoa(document.Version)))
+	limit, errLimit := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if errFrom != nil || errTo != nil || errLimit != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("from, to and limit must be positive numbers"))
+		return
+	}
+
+	fromVersion, ok := d.findVersion(c, document.ID, fromNumber)
+	if !ok {
+		return
+	}
+	toVersion, ok := d.findVersion(c, document.ID, toNumber)
+	if !ok {
+		return
+	}
+
+	// IDF считается по тому же корпусу, что и статистика документа
+	corpusDocs, err := services.DocumentCorpus(document)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get corpus documents"))
+		return
+	}
+	corpus := services.CorpusWordCounts(c.Request.Context(), corpusDocs)
+	idf := services.CalculateIDF(corpus)
+
+	var profiles [2]map[string]float64
+	for i, version := range []models.DocumentVersion{fromVersion, toVersion} {
+		counts, err := services.CountVersionWords(c.Request.Context(), version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to read document content"))
+			return
+		}
+		profiles[i] = services.TFIDFProfile(counts, idf, len(corpus))
+	}
+
+	diff := services.DiffProfiles(profiles[0], profiles[1])
+	totalChanged := len(diff)
+	if len(diff) > limit {
+		diff = diff[:limit]
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(gin.H{
+		"terms": diff,
+		"meta": gin.H{
+			"from":            fromVersion.Version,
+			"to":              toVersion.Version,
+			"total_changed":   totalChanged,
+			"total_documents": len(corpus),
+		},
+	}))
+}
+
+// findVersion ищет версию документа и сам отвечает 404/500, если не получилось
+func (d *documentController) findVersion(c *gin.Context, documentID uint, number int) (models.DocumentVersion, bool) {
+	var version models.DocumentVersion
+	if err := d.DB.Where("document_id = ? AND version = ?", documentID, number).First(&version).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse(fmt.Sprintf("Version %d not found", number)))
+			return models.DocumentVersion{}, false
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document version"))
+		return models.DocumentVersion{}, false
+	}
+	return version, true
+}
+
+// GetDocumentHighlight godoc
+// @Summary Get document text with TF-IDF heat map
+// @Description Splits the document text into words and the text between them, each word with its TF-IDF weight. IDF is calculated over the documents of the given collection, or over all user's documents if collection_id is not set. The top terms get a highlight level from 1 to 5. With format=html returns a page where the top terms are wrapped in <mark class="tfidf-N">
+// @Tags Documents
+// @Produce json,html
+// @Param document_id path string true "Document ID"
+// @Param collection_id query int false "Collection to calculate IDF over"
+// @Param top query int false "How many top terms to highlight" default(20)
+// @Param format query string false "Response format" Enums(json, html) default(json)
+// @Success 200 {object} helper.Response{data=services.Highlight} "Document segments with weights"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/highlight [get]
+func (d *documentController) GetDocumentHighlight(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "20"))
+	if err != nil || top < 1 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("top must be a positive number"))
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("format must be json or html"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", c.Param("document_id"), userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	// Корпус для IDF: выбранная коллекция или вся библиотека пользователя
+	corpusDocs, ok := scopeDocuments(c, d.DB, userID)
+	if !ok {
+		return
+	}
+
+	content, err := services.ReadDocumentContent(c.Request.Context(), document)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to read document content"))
+		return
+	}
+	if !utf8.Valid(content) {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document is not a text"))
+		return
+	}
+
+	corpus := services.CorpusWordCounts(c.Request.Context(), corpusDocs)
+	idf := services.CalculateIDF(corpus)
+	profile := services.TFIDFProfile(services.CountContentWords(document.ContentHash, content), idf, len(corpus))
+	highlight := services.HighlightDocument(content, profile, top)
+
+	if format == "html" {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := services.RenderHighlightHTML(c.Writer, document.Name, highlight); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(highlight))
+}
+
+// Ограничения выдачи конкорданса
+const (
+	defaultConcordanceWindow = 5
+	maxConcordanceWindow     = 50
+	defaultConcordanceLimit  = 100
+	maxConcordanceLimit      = 1000
+)
+
+// parseConcordanceQuery разбирает term, window и limit, при ошибке сам отвечает 400
+func parseConcordanceQuery(c *gin.Context) (term string, window, limit int, ok bool) {
+	term, err := services.NormalizeTerm(c.Query("term"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("term must be a single word"))
+		return "", 0, 0, false
+	}
+
+	window, err = strconv.Atoi(c.DefaultQuery("window", strconv.Itoa(defaultConcordanceWindow)))
+	if err != nil || window < 0 || window > maxConcordanceWindow {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(fmt.Sprintf("window must be a number from 0 to %d", maxConcordanceWindow)))
+		return "", 0, 0, false
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultConcordanceLimit)))
+	if err != nil || limit < 1 || limit > maxConcordanceLimit {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(fmt.Sprintf("limit must be a number from 1 to %d", maxConcordanceLimit)))
+		return "", 0, 0, false
+	}
+
+	return term, window, limit, true
+}
+
+// GetDocumentConcordance godoc
+// @Summary Get keyword in context for a document
+// @Description Finds every occurrence of the word in the document using the positional index and returns it with window words of left and right context and byte offsets. Context is cut from the original text as is
+// @Tags Documents
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Param term query string true "Word to find"
+// @Param window query int false "Context words on each side, 0-50" default(5)
+// @Param limit query int false "Max number of occurrences, 1-1000" default(100)
+// @Success 200 {object} helper.Response{data=services.ConcordanceResult} "Occurrences with context"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/concordance [get]
+func (d *documentController) GetDocumentConcordance(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	term, window, limit, ok := parseConcordanceQuery(c)
+	if !ok {
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", c.Param("document_id"), userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	result, err := services.FindConcordance(c.Request.Context(), d.DB, []models.Document{document}, term, window, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to build concordance"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(result))
+}
+
+// SearchDocuments godoc
+// @Summary Search documents with a query language
+// @Description Finds user's documents (or documents of the collection) matching the query using the positional index. Supports words, "exact phrases", a NEAR/n b (at most n words between, any order), AND (or just a space), OR, NOT and parentheses. Operators are uppercase. Documents with more matches come first
+// @Tags Documents
+// @Produce json
+// @Param q query string true "Search query"
+// @Param collection_id query int false "Search only in this collection"
+// @Param limit query int false "Max number of documents, 1-100" default(20)
+// @Success 200 {object} helper.Response{data=services.SearchResult} "Matching documents"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/search [get]
+func (d *documentController) SearchDocuments(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	input := c.Query("q")
+	node, err := services.ParseQuery(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("limit must be a number from 1 to 100"))
+		return
+	}
+
+	documents, ok := scopeDocuments(c, d.DB, userID)
+	if !ok {
+		return
+	}
+
+	result, err := services.SearchDocuments(d.DB, documents, input, node, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to search documents"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(result))
+}
+
+// scopeDocuments - документы коллекции из collection_id, а без него - вся библиотека пользователя.
+// При ошибке сам отвечает клиенту.
+func scopeDocuments(c *gin.Context, db *gorm.DB, userID int) ([]models.Document, bool) {
+	var documents []models.Document
+
+	collectionID := c.Query("collection_id")
+	if collectionID == "" {
+		if err := db.Where("user_id = ?", userID).Order("id").Find(&documents).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get documents"))
+			return nil, false
+		}
+		return documents, true
+	}
+
+	var collection models.Collection
+	err := db.Preload("Documents", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Collection not found"))
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get collection"))
+		return nil, false
+	}
+
+	for _, doc := range collection.Documents {
+		documents = append(documents, *doc)
+	}
+	return documents, true
+}
+
+// GetDocumentSummary godoc
+// @Summary Get extractive summary of a document
+// @Description Splits the document into sentences, scores each by the sum of its words' TF-IDF weights (IDF over the given collection, or over all user's documents if collection_id is not set) and picks sentences with MMR: every step takes the sentence with the highest lambda * score - (1 - lambda) * similarity to already picked ones. The picked sentences are returned in the original order
+// @Tags Documents
+// @Produce json
+// @Param document_id path string true "Document ID"
+// @Param sentences query int false "Number of sentences, 1-50" default(5)
+// @Param lambda query number false "Relevance vs diversity, 0-1" default(0.7)
+// @Param collection_id query int false "Collection to calculate IDF over"
+// @Success 200 {object} helper.Response{data=services.ExtractiveSummary} "Summary"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /documents/{document_id}/summary [get]
+func (d *documentController) GetDocumentSummary(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("sentences", "5"))
+	if err != nil || count < 1 || count > 50 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("sentences must be a number from 1 to 50"))
+		return
+	}
+	lambda, err := strconv.ParseFloat(c.DefaultQuery("lambda", "0.7"), 64)
+	if err != nil || lambda < 0 || lambda > 1 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("lambda must be a number from 0 to 1"))
+		return
+	}
+
+	var document models.Document
+	if err := d.DB.Where("id = ? AND user_id = ?", c.Param("document_id"), userID).First(&document).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Document not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get document"))
+		return
+	}
+
+	corpusDocs, ok := scopeDocuments(c, d.DB, userID)
+	if !ok {
+		return
+	}
+
+	content, err := services.ReadDocumentContent(c.Request.Context(), document)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to read document content"))
+		return
+	}
+	if !utf8.Valid(content) {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Document is not a text"))
+		return
+	}
+
+	corpus := services.CorpusWordCounts(c.Request.Context(), corpusDocs)
+	idf := services.CalculateIDF(corpus)
+	profile := services.TFIDFProfile(services.CountContentWords(document.ContentHash, content), idf, len(corpus))
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(services.ExtractSummary(content, profile, count, lambda)))
+}
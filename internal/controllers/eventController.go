@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Пауза между keepalive-комментариями: прокси не должны закрыть молчащее соединение
+const streamKeepalive = 25 * time.Second
+
+type EventController interface {
+	StreamEvents(c *gin.Context)
+}
+
+type eventController struct{}
+
+func NewEventController() EventController {
+	return &eventController{}
+}
+
+// StreamEvents godoc
+// @Summary Stream processing events
+// @Description Server-Sent Events stream of the current user. Events: upload.progress (id, name, processed bytes, total and percent when the size is known, done, error) while an upload is tokenized, the id is the progress_id query parameter of the upload or a random one; statistics.ready when document or collection statistics are computed (not when the word counts come from the cache); metrics.updated with service metrics (without top words) after every processed file; document.created, document.deleted and collection.updated as for webhooks. The first event is "ready", a comment is sent every 25 seconds to keep the connection open. Events that happen while the client is disconnected are not replayed
+// @Tags Events
+// @Produce text/event-stream
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} helper.Response
+// @Router /events [get]
+func (e *eventController) StreamEvents(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	events, unsubscribe := services.SubscribeStream(userID)
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// nginx не должен буферизовать поток
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{"user_id": userID})
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(streamKeepalive)
+	defer keepalive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Event, event.Data)
+		case <-keepalive.C:
+			// Строка-комментарий SSE, EventSource ее игнорирует
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"tfidf-app/internal/helper"
 	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -26,7 +27,7 @@ func NewMetricsController(db *gorm.DB) MetricsController {
 
 // GetMetrics godoc
 // @Summary Get application metrics
-// @Description Retrieves aggregated metrics including processing time, file size, and top 10 most seen words
+// @Description Retrieves aggregated metrics including processing time, file size, bytes saved by compression at rest and deduplication, and top 10 most seen words
 // @Tags Metrics
 // @Produce json
 // @Success 200 {object} helper.Response{data=models.Metric} "Application metrics"
@@ -51,5 +52,12 @@ func (m *metricsController) GetMetrics(c *gin.Context) {
 		return
 	}
 
+	usage, err := services.GetTotalStorageUsage(m.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to retrieve storage usage"))
+		return
+	}
+	metric.StorageSavedMB = services.RoundFileSizeMB(usage.SavedBytes)
+
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(metric))
 }
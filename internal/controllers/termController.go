@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TermController interface {
+	GetTerm(c *gin.Context)
+}
+
+type termController struct {
+	DB *gorm.DB
+}
+
+func NewTermController(db *gorm.DB) TermController {
+	return &termController{DB: db}
+}
+
+// GetTerm godoc
+// @Summary Get a word's statistics across documents
+// @Description Returns in how many documents the word occurs (df), its IDF, total count and the list of documents containing it with per-document count, TF and TF-IDF, highest TF-IDF first. The corpus is the given collection or all user's documents. Served from the positional index, document files are not read
+// @Tags Vocabulary
+// @Produce json
+// @Param term path string true "Word"
+// @Param collection_id query int false "Collection to use as the corpus"
+// @Param limit query int false "Max number of documents in postings, 1-1000" default(100)
+// @Success 200 {object} helper.Response{data=services.TermLookup} "Word statistics"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /terms/{term} [get]
+func (t *termController) GetTerm(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	term, err := services.NormalizeTerm(c.Param("term"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("term must be a single word"))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("limit must be a number from 1 to 1000"))
+		return
+	}
+
+	documents, ok := scopeDocuments(c, t.DB, userID)
+	if !ok {
+		return
+	}
+
+	lookup, err := services.LookupTerm(t.DB, documents, term, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get term statistics"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(lookup))
+}
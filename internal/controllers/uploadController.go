@@ -1,32 +1,32 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 
-	"tfidf-app/internal/database"
+	"tfidf-app/internal/config"
 	"tfidf-app/internal/helper"
-	"tfidf-app/internal/models"
 	"tfidf-app/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 // HandleFileUpload godoc
 // @Summary Upload and process a document
-// @Description Uploads a file, processes it for TF and IDF, gives top 50 rare words, sets up metrics, and saves to database. Only in this case: IDF = log(total words / count)
+// @Description Uploads a file, processes it for TF and IDF, gives top 50 rare words, sets up metrics, and saves to database. Only in this case: IDF = log(total words / count). Identical content is stored once; if the user already has a document with the same content, its ID is returned in duplicate_of. Uploading a file with the name of an existing document creates its new version
 // @Tags Upload document
 // @Accept multipart/form-data
 // @Produce json
 // @Param file formData file true "Document file to upload"
+// @Param progress_id query string false "ID of upload.progress events in GET /events, random by default"
 // @Success 200 {object} helper.Response{data=[]services.WordStat} "TF-IDF statistics"
 // @Failure 400 {object} helper.Response
 // @Failure 401 {object} helper.Response
-// @Failure 409 {object} helper.Response
+// @Failure 409 {object} helper.Response "Name conflict with a concurrent request"
+// @Failure 413 {object} helper.Response "File is too large or storage quota exceeded"
 // @Failure 500 {object} helper.Response
 // @Router /upload [post]
 func HandleFileUpload(c *gin.Context) {
@@ -38,86 +38,112 @@ func HandleFileUpload(c *gin.Context) {
 		return
 	}
 
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("No file provided: "+err.Error()))
-		return
-	}
-
-	// Проверка: существует ли уже документ с таким именем у этого пользователя
-	var existingDoc models.Document
-	if err := database.DB.
-		Where("name = ? AND user_id = ?", file.Filename, userID).First(&existingDoc).Error; err == nil {
-		c.JSON(http.StatusConflict, helper.NewErrorResponse("Document with the same name already exists"))
+	// Файл пишется во временный файл прямо из тела запроса, попутно считаются хэш и слова
+	staged, filename, ok := stageMultipartFile(c, userID)
+	if !ok {
 		return
 	}
+	defer staged.Remove()
 
-	// Путь до папки пользователя
-	userDir := fmt.Sprintf("documents/user_%d", userID)
-	fullPath := filepath.Join("/app", userDir)
-
-	// Создаём папку, если не существует
-	if err := os.MkdirAll(fullPath, os.ModePerm); err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Cannot create user folder: "+err.Error()))
+	// Новый документ или новая версия документа с таким же именем
+	result, err := services.IngestDocument(c.Request.Context(), userID, filename, staged, startTime)
+	if err != nil {
+		respondIngestError(c, err)
 		return
 	}
 
-	// Сохраняем файл
-	filePath := filepath.Join(fullPath, file.Filename)
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Cannot save file: "+err.Error()))
-		return
-	}
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(uploadResponse(result)))
+}
 
-	// Обработка файла (вынесено до транзакции, так как это CPU-bound операция)
-	words, err := services.ProcessFile(filePath)
+// stageMultipartFile находит в multipart-теле поле "file" и потоково сохраняет его во временный файл.
+// Ход разбора публикуется в /events пользователя. Если что-то не так, ответ с ошибкой уже отправлен и ok = false.
+func stageMultipartFile(c *gin.Context, userID int) (staged *services.StagedContent, filename string, ok bool) {
+	reader, err := c.Request.MultipartReader()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Cannot process file: "+err.Error()))
-		return
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("No file provided: "+err.Error()))
+		return nil, "", false
 	}
 
-	// Начинаем транзакцию для всех операций с БД
-	err = database.DB.Transaction(func(tx *gorm.DB) error {
-		// Сохраняем метаинформацию в БД
-		document := models.Document{
-			Name:     file.Filename,
-			FilePath: filePath,
-			UserID:   userID,
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("No file provided"))
+			return nil, "", false
 		}
-		if err := tx.Create(&document).Error; err != nil {
-			return fmt.Errorf("failed to save document: %w", err)
-		}
-
-		// Вычисляем метрики
-		processingTime := services.CalculateProcessingTime(startTime)
-		fileSizeMB := services.RoundFileSizeMB(file.Size)
-
-		// Обновляем метрики
-		metric, err := services.UpdateMetrics(tx, processingTime, fileSizeMB)
 		if err != nil {
-			return fmt.Errorf("failed to update metrics: %w", err)
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Cannot read file: "+err.Error()))
+			return nil, "", false
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
 		}
 
-		// Сохраняем слова
-		if err := services.SaveWords(tx, words, metric.ID); err != nil {
-			return fmt.Errorf("failed to save words: %w", err)
+		// Размер тела запроса чуть больше файла (границы multipart) - для процентов этого достаточно
+		progress := newUploadProgress(c, userID, part.FileName(), c.Request.ContentLength)
+		staged, err = services.StageContent(part, config.Init.MaxUploadSize, progress)
+		part.Close()
+		if err != nil {
+			respondIngestError(c, err)
+			return nil, "", false
 		}
+		staged.Source = services.SourceUpload
+		return staged, part.FileName(), true
+	}
+}
 
-		return nil
-	})
+// newUploadProgress - прогресс разбора загрузки для /events, клиент может задать свой progress_id
+func newUploadProgress(c *gin.Context, userID int, name string, total int64) *services.UploadProgress {
+	return services.NewUploadProgress(userID, c.Query("progress_id"), name, total)
+}
 
-	if err != nil {
+// respondIngestError отвечает на ошибку сохранения содержимого: превышение лимитов - 413, ошибки загрузки по ссылке - 4xx/502
+func respondIngestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, helper.NewErrorResponse(fmt.Sprintf("File is too large, maximum size is %d bytes", config.Init.MaxUploadSize)))
+	case errors.Is(err, services.ErrQuotaExceeded):
+		c.JSON(http.StatusRequestEntityTooLarge, helper.NewErrorResponse("Storage quota exceeded"))
+	case errors.Is(err, services.ErrInvalidDocumentName):
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrDocumentNameTaken):
+		c.JSON(http.StatusConflict, helper.NewErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrFetchNotAllowed):
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrUnsupportedContent):
+		c.JSON(http.StatusUnsupportedMediaType, helper.NewErrorResponse(err.Error()))
+	case errors.Is(err, services.ErrFetchFailed):
+		c.JSON(http.StatusBadGateway, helper.NewErrorResponse(err.Error()))
+	default:
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Database error: "+err.Error()))
-		return
 	}
+}
 
+// Ответ на загрузку: топ-50 TF-IDF и куда легло содержимое
+func uploadResponse(result services.IngestResult) gin.H {
 	// TF-IDF (не требует транзакции, так как это вычисление)
-	stats := services.ComputeTFIDFForUpload(words)
+	stats := services.ComputeTFIDFForUpload(result.Counts)
 	if len(stats) > 50 {
 		stats = stats[:50]
 	}
 
-	c.JSON(http.StatusOK, helper.NewSuccessResponse(gin.H{
-		"words": stats,
-	}))
+	response := gin.H{
+		"words":       stats,
+		"document_id": result.Document.ID,
+		"version":     result.Document.Version,
+	}
+	if result.DuplicateOf != nil {
+		response["duplicate_of"] = *result.DuplicateOf
+	}
+	if result.Unchanged {
+		response["unchanged"] = true
+	}
+	if len(result.FiledInto) > 0 {
+		response["filed_into"] = result.FiledInto
+	}
+	if len(result.Alerts) > 0 {
+		response["watch_alerts"] = result.Alerts
+	}
+
+	return response
 }
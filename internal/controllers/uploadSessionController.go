@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Поддерживаемая версия протокола докачки tus
+const tusVersion = "1.0.0"
+
+type UploadSessionController interface {
+	CreateUpload(c *gin.Context)
+	UploadChunk(c *gin.Context)
+	GetUploadOffset(c *gin.Context)
+}
+
+// uploadSessionController implements UploadSessionController
+type uploadSessionController struct {
+	DB *gorm.DB
+}
+
+// NewUploadSessionController returns an instance of UploadSessionController
+func NewUploadSessionController(db *gorm.DB) UploadSessionController {
+	return &uploadSessionController{DB: db}
+}
+
+// CreateUpload godoc
+// @Summary Start a resumable upload
+// @Description Creates a resumable (tus 1.0.0) upload of a file of Upload-Length bytes. The file name is passed in Upload-Metadata as "filename <base64>" and must be 1-100 characters long, it is checked before any data is sent. The response Location header is the URL to send chunks to with PATCH
+// @Tags Upload document
+// @Produce json
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Length header int true "Total file size in bytes"
+// @Param Upload-Metadata header string true "filename <base64 file name>"
+// @Success 201 {object} helper.Response{data=models.UploadSession} "Upload created"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 412 {object} helper.Response "Unsupported protocol version"
+// @Failure 413 {object} helper.Response "File is too large or storage quota exceeded"
+// @Failure 500 {object} helper.Response
+// @Router /uploads [post]
+func (u *uploadSessionController) CreateUpload(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid Upload-Length header"))
+		return
+	}
+
+	filename := parseUploadMetadata(c.GetHeader("Upload-Metadata"))["filename"]
+	if filename == "" {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("File name is required in Upload-Metadata"))
+		return
+	}
+
+	session, err := services.CreateUploadSession(userID, filename, length)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+
+	c.Header("Location", "/uploads/"+session.ID)
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, helper.NewSuccessResponse(session))
+}
+
+// UploadChunk godoc
+// @Summary Upload a chunk of a resumable upload
+// @Description Appends the request body to the upload starting at Upload-Offset, which must equal the number of bytes already received (see HEAD). After an interrupted request, ask HEAD for the offset and continue from it. When the last byte is received the document is processed and its TF-IDF statistics are returned, otherwise the response is 204 with the new Upload-Offset
+// @Tags Upload document
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Param upload_id path string true "Upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Offset header int true "Offset of this chunk"
+// @Success 200 {object} helper.Response{data=object} "Upload completed, TF-IDF statistics"
+// @Success 204 "Chunk accepted"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 409 {object} helper.Response "Upload-Offset does not match"
+// @Failure 412 {object} helper.Response "Unsupported protocol version"
+// @Failure 413 {object} helper.Response "More data than Upload-Length or storage quota exceeded"
+// @Failure 415 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /uploads/{upload_id} [patch]
+func (u *uploadSessionController) UploadChunk(c *gin.Context) {
+	startTime := time.Now()
+
+	if !checkTusVersion(c) {
+		return
+	}
+
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, helper.NewErrorResponse("Content-Type must be application/offset+octet-stream"))
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid Upload-Offset header"))
+		return
+	}
+
+	session, ok := u.findSession(c)
+	if !ok {
+		return
+	}
+
+	err = services.AppendUploadChunk(&session, offset, c.Request.Body)
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	if errors.Is(err, services.ErrOffsetMismatch) {
+		c.JSON(http.StatusConflict, helper.NewErrorResponse("Upload-Offset does not match, expected "+strconv.FormatInt(session.Offset, 10)))
+		return
+	}
+	if errors.Is(err, services.ErrTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, helper.NewErrorResponse("More data than Upload-Length"))
+		return
+	}
+	if err != nil {
+		// Принятая часть сохранена, клиент продолжит с нового смещения
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to save chunk: "+err.Error()))
+		return
+	}
+
+	if session.Offset < session.Length {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	result, err := services.CompleteUpload(c.Request.Context(), session, startTime)
+	if err != nil {
+		respondIngestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(uploadResponse(result)))
+}
+
+// GetUploadOffset godoc
+// @Summary Get resumable upload offset
+// @Description Returns how many bytes of the upload were received in the Upload-Offset header and the total size in Upload-Length
+// @Tags Upload document
+// @Param upload_id path string true "Upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 200 "Upload-Offset and Upload-Length headers"
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 412 {object} helper.Response "Unsupported protocol version"
+// @Router /uploads/{upload_id} [head]
+func (u *uploadSessionController) GetUploadOffset(c *gin.Context) {
+	if !checkTusVersion(c) {
+		return
+	}
+
+	session, ok := u.findSession(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Length, 10))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// findSession находит загрузку текущего пользователя, иначе отвечает ошибкой
+func (u *uploadSessionController) findSession(c *gin.Context) (models.UploadSession, bool) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return models.UploadSession{}, false
+	}
+
+	var session models.UploadSession
+	if err := u.DB.Where("id = ? AND user_id = ?", c.Param("upload_id"), userID).First(&session).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Upload not found"))
+			return models.UploadSession{}, false
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get upload"))
+		return models.UploadSession{}, false
+	}
+
+	return session, true
+}
+
+// checkTusVersion проверяет заголовок Tus-Resumable и выставляет его в ответе
+func checkTusVersion(c *gin.Context) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.JSON(http.StatusPreconditionFailed, helper.NewErrorResponse("Unsupported Tus-Resumable version, expected "+tusVersion))
+		return false
+	}
+	return true
+}
+
+// parseUploadMetadata разбирает Upload-Metadata: пары "ключ base64-значение" через запятую
+func parseUploadMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
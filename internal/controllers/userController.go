@@ -1,14 +1,16 @@
 package controllers
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"tfidf-app/internal/dto"
 	"tfidf-app/internal/helper"
 	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
+	"tfidf-app/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -17,6 +19,7 @@ import (
 
 type UserController interface {
 	GetMe(c *gin.Context)
+	GetMyStorage(c *gin.Context)
 	Register(c *gin.Context)
 	Login(c *gin.Context)
 	Logout(c *gin.Context)
@@ -62,6 +65,31 @@ func (u *userController) GetMe(c *gin.Context) {
 	c.JSON(http.StatusOK, helper.NewSuccessResponse(me))
 }
 
+// GetMyStorage godoc
+// @Summary Get storage usage of the current user
+// @Description Returns original and stored size of the user's documents and how many bytes compression at rest saved
+// @Tags Users
+// @Produce json
+// @Success 200 {object} helper.Response{data=services.StorageUsage}
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /users/me/storage [get]
+func (u *userController) GetMyStorage(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	usage, err := services.GetStorageUsage(u.DB.Where("user_id = ?", userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get storage usage"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(usage))
+}
+
 // Register godoc
 // @Summary New user registration
 // @Tags Users
@@ -255,20 +283,60 @@ func (u *userController) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	// Удаление папки пользователя со всеми файлами
-	userDir := fmt.Sprintf("/app/documents/user_%d", id)
-	if err := os.RemoveAll(userDir); err != nil && !os.IsNotExist(err) {
-		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete user's folder: "+err.Error()))
+	var documents []models.Document
+	if err := u.DB.Where("user_id = ?", id).Find(&documents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get user's documents"))
 		return
 	}
 
-	// Удаление пользователя из базы данных
-	result := u.DB.Delete(&models.User{}, id)
-	if result.Error != nil {
+	// Незавершенные загрузки удалятся каскадом, их временные файлы - после
+	var uploads []models.UploadSession
+	if err := u.DB.Where("user_id = ?", id).Find(&uploads).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get user's uploads"))
+		return
+	}
+
+	// Удаление пользователя из базы данных вместе со ссылками на содержимое документов
+	var deleteContents []func(ctx context.Context) error
+	err = u.DB.Transaction(func(tx *gorm.DB) error {
+		for _, doc := range documents {
+			deleteContent, err := services.ReleaseDocumentContent(tx, doc)
+			if err != nil {
+				return err
+			}
+			deleteContents = append(deleteContents, deleteContent)
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete user"))
 		return
 	}
 
+	// Удаление файлов, на которые больше никто не ссылается
+	for _, deleteContent := range deleteContents {
+		if err := deleteContent(c.Request.Context()); err != nil {
+			log.Printf("Failed to delete file of user %d: %v", id, err)
+		}
+	}
+
+	services.RemoveUploadFiles(uploads)
+
+	// Подчищаем старые файлы под префиксом пользователя, если они не стали общими blob'ами
+	leftovers, err := storage.Files.List(c.Request.Context(), services.UserKeyPrefix(id))
+	if err != nil {
+		log.Printf("Failed to list files of user %d: %v", id, err)
+	}
+	for _, obj := range leftovers {
+		var shared int64
+		if err := u.DB.Model(&models.Blob{}).Where("storage_key = ?", obj.Key).Count(&shared).Error; err != nil || shared > 0 {
+			continue
+		}
+		if err := storage.Files.Delete(c.Request.Context(), obj.Key); err != nil {
+			log.Printf("Failed to delete file %s: %v", obj.Key, err)
+		}
+	}
+
 	c.SetCookie("auth_token", "", -1, "/", "", false, true)
 	c.JSON(http.StatusOK, helper.NewSuccessResponse("User deleted and logged out successfully"))
 }
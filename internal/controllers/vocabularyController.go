@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"tfidf-app/internal/dto"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type VocabularyController interface {
+	SuggestTerms(c *gin.Context)
+}
+
+type vocabularyController struct {
+	DB *gorm.DB
+}
+
+func NewVocabularyController(db *gorm.DB) VocabularyController {
+	return &vocabularyController{DB: db}
+}
+
+// SuggestTerms godoc
+// @Summary Suggest similar words from the user's vocabulary
+// @Description "Did you mean": finds words from all user's documents within max_distance Levenshtein edits of q. Closer words come first, equally close ones - by the number of documents they occur in (df). By default one typo is allowed for words up to 4 letters and two for longer ones
+// @Tags Vocabulary
+// @Produce json
+// @Param q query string true "Word to check"
+// @Param max_distance query int false "Max number of edits, 1-3"
+// @Param limit query int false "Max number of suggestions, 1-50" default(5)
+// @Success 200 {object} helper.Response{data=dto.SuggestResponse} "Suggestions"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /vocabulary/suggest [get]
+func (v *vocabularyController) SuggestTerms(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	term, err := services.NormalizeTerm(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("q must be a single word"))
+		return
+	}
+
+	maxDistance, err := strconv.Atoi(c.DefaultQuery("max_distance", strconv.Itoa(services.DefaultSuggestDistance(term))))
+	if err != nil || maxDistance < 1 || maxDistance > services.MaxSuggestDistance {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(fmt.Sprintf("max_distance must be a number from 1 to %d", services.MaxSuggestDistance)))
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > 50 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("limit must be a number from 1 to 50"))
+		return
+	}
+
+	vocabulary, err := services.UserVocabulary(v.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get vocabulary"))
+		return
+	}
+
+	df := vocabulary.DF(term)
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(dto.SuggestResponse{
+		Query:       term,
+		Found:       df > 0,
+		DF:          df,
+		Suggestions: vocabulary.Suggest(term, maxDistance, limit),
+	}))
+}
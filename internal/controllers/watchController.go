@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"tfidf-app/internal/dto"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WatchController interface {
+	CreateWatch(c *gin.Context)
+	GetWatches(c *gin.Context)
+	GetWatchByID(c *gin.Context)
+	DeleteWatch(c *gin.Context)
+	GetWatchAlerts(c *gin.Context)
+	GetWatchDeliveries(c *gin.Context)
+}
+
+type watchController struct {
+	DB *gorm.DB
+}
+
+func NewWatchController(db *gorm.DB) WatchController {
+	return &watchController{DB: db}
+}
+
+// CreateWatch godoc
+// @Summary Watch new uploads for a word or a query
+// @Description Creates a watch: a word that must be among the top_n (default 20, max 100) TF-IDF words of a newly uploaded document (IDF over all user's documents), or a search query in the /documents/search syntax. Every match is recorded as an alert; if webhook_url is set, a JSON payload is POSTed there, signed with HMAC-SHA256 in X-Webhook-Signature ("sha256=<hex>") using the secret returned only in this response. Failed deliveries are retried with exponential backoff
+// @Tags Watches
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateWatchReq true "Watch"
+// @Success 201 {object} helper.Response{data=dto.CreateWatchResponse} "Created watch with its signing secret"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /watches [post]
+func (w *watchController) CreateWatch(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.CreateWatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" || utf8.RuneCountInString(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("name must be from 1 to 100 characters"))
+		return
+	}
+
+	watch := models.Watch{Name: req.Name, UserID: userID, TopN: services.DefaultWatchTopN, WebhookURL: req.WebhookURL}
+	switch {
+	case (req.Term == "") == (req.Query == ""):
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(services.ErrInvalidWatch.Error()))
+		return
+	case req.Term != "":
+		if watch.Term, err = services.NormalizeTerm(req.Term); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("term must be a single word"))
+			return
+		}
+	default:
+		if _, err := services.ParseQuery(req.Query); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+		watch.Query = req.Query
+	}
+
+	if req.TopN != nil {
+		if *req.TopN < 1 || *req.TopN > services.MaxWatchTopN {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(fmt.Sprintf("top_n must be a number from 1 to %d", services.MaxWatchTopN)))
+			return
+		}
+		watch.TopN = *req.TopN
+	}
+
+	if watch.WebhookURL != "" {
+		if err := services.ValidateWebhookURL(watch.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+	}
+
+	if watch.Secret, err = services.NewWebhookSecret(); err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to create watch"))
+		return
+	}
+
+	if err := w.DB.Create(&watch).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to create watch"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.NewSuccessResponse(dto.CreateWatchResponse{Watch: watch, Secret: watch.Secret}))
+}
+
+// GetWatches godoc
+// @Summary Get all watches
+// @Description Gets all watches of the current user
+// @Tags Watches
+// @Produce json
+// @Success 200 {object} helper.Response{data=[]models.Watch} "List of watches"
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /watches [get]
+func (w *watchController) GetWatches(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	watches := []models.Watch{}
+	if err := w.DB.Where("user_id = ?", userID).Order("id").Find(&watches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get watches"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(watches))
+}
+
+// GetWatchByID godoc
+// @Summary Get watch by ID
+// @Description Gets a watch of the current user
+// @Tags Watches
+// @Produce json
+// @Param watch_id path string true "Watch ID"
+// @Success 200 {object} helper.Response{data=models.Watch} "Watch"
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /watches/{watch_id} [get]
+func (w *watchController) GetWatchByID(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	watch, ok := w.findWatch(c, userID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(watch))
+}
+
+// DeleteWatch godoc
+// @Summary Delete watch
+// @Description Deletes a watch with its alerts and webhook deliveries, including ones not delivered yet
+// @Tags Watches
+// @Produce json
+// @Param watch_id path string true "Watch ID"
+// @Success 200 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /watches/{watch_id} [delete]
+func (w *watchController) DeleteWatch(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	watch, ok := w.findWatch(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := w.DB.Delete(&watch).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete watch"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse("Watch deleted successfully"))
+}
+
+// GetWatchAlerts godoc
+// @Summary Get watch alerts
+// @Description Gets documents the watch matched, newest first: for a word its rank and TF-IDF in the document, for a query the number of hits
+// @Tags Watches
+// @Produce json
+// @Param watch_id path string true "Watch ID"
+// @Param limit query int false "Max number of alerts, 1-500" default(50)
+// @Success 200 {object} helper.Response{data=[]models.WatchAlert} "Alerts"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /watches/{watch_id}/alerts [get]
+func (w *watchController) GetWatchAlerts(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	limit, ok := parseLogLimit(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := w.findWatch(c, userID)
+	if !ok {
+		return
+	}
+
+	alerts := []models.WatchAlert{}
+	if err := w.DB.Where("watch_id = ?", watch.ID).Order("id DESC").Limit(limit).Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get alerts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(alerts))
+}
+
+// GetWatchDeliveries godoc
+// @Summary Get watch webhook deliveries
+// @Description Gets the delivery log of the watch webhook, newest first: payload, status (pending, delivered, failed), number of attempts, last response code and error, time of the next attempt
+// @Tags Watches
+// @Produce json
+// @Param watch_id path string true "Watch ID"
+// @Param limit query int false "Max number of deliveries, 1-500" default(50)
+// @Success 200 {object} helper.Response{data=[]models.WebhookDelivery} "Deliveries"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /watches/{watch_id}/deliveries [get]
+func (w *watchController) GetWatchDeliveries(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	limit, ok := parseLogLimit(c)
+	if !ok {
+		return
+	}
+
+	watch, ok := w.findWatch(c, userID)
+	if !ok {
+		return
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := w.DB.Where("watch_id = ?", watch.ID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(deliveries))
+}
+
+// findWatch находит отслеживание пользователя из пути. При ошибке сам отвечает клиенту.
+func (w *watchController) findWatch(c *gin.Context, userID int) (models.Watch, bool) {
+	var watch models.Watch
+	if err := w.DB.Where("id = ? AND user_id = ?", c.Param("watch_id"), userID).First(&watch).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Watch not found"))
+			return watch, false
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get watch"))
+		return watch, false
+	}
+	return watch, true
+}
+
+// parseLogLimit разбирает limit журналов (срабатывания, доставки). При ошибке сам отвечает клиенту.
+func parseLogLimit(c *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("limit must be a number from 1 to 500"))
+		return 0, false
+	}
+	return limit, true
+}
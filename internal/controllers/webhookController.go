@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"net/http"
+	"tfidf-app/internal/dto"
+	"tfidf-app/internal/helper"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/services"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type WebhookController interface {
+	CreateWebhook(c *gin.Context)
+	GetWebhooks(c *gin.Context)
+	GetWebhookByID(c *gin.Context)
+	UpdateWebhook(c *gin.Context)
+	DeleteWebhook(c *gin.Context)
+	GetWebhookDeliveries(c *gin.Context)
+}
+
+type webhookController struct {
+	DB *gorm.DB
+}
+
+func NewWebhookController(db *gorm.DB) WebhookController {
+	return &webhookController{DB: db}
+}
+
+// CreateWebhook godoc
+// @Summary Subscribe a webhook to events
+// @Description Subscribes a URL to lifecycle events: document.created (new document uploaded), document.deleted, collection.updated (renamed, document added or removed, including auto filing) and statistics.ready (document or collection statistics computed, not served from the cache). Each event is POSTed as JSON {event, created_at, data} with headers X-Webhook-Event, X-Webhook-Delivery and X-Webhook-Signature ("sha256=" + HMAC-SHA256 of the body with the secret returned only in this response). Failed deliveries are retried with exponential backoff
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateWebhookReq true "Webhook"
+// @Success 201 {object} helper.Response{data=dto.CreateWebhookResponse} "Created webhook with its signing secret"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /webhooks [post]
+func (wh *webhookController) CreateWebhook(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.CreateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	if err := services.ValidateWebhookURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		return
+	}
+	events, err := services.ValidateEvents(req.Events)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+		return
+	}
+	if utf8.RuneCountInString(req.Description) > 255 {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("description must be at most 255 characters"))
+		return
+	}
+
+	webhook := models.Webhook{UserID: userID, URL: req.URL, Events: events, Description: req.Description, Active: true}
+	if webhook.Secret, err = services.NewWebhookSecret(); err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to create webhook"))
+		return
+	}
+
+	if err := wh.DB.Create(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to create webhook"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, helper.NewSuccessResponse(dto.CreateWebhookResponse{Webhook: webhook, Secret: webhook.Secret}))
+}
+
+// GetWebhooks godoc
+// @Summary Get all webhooks
+// @Description Gets all webhook subscriptions of the current user
+// @Tags Webhooks
+// @Produce json
+// @Success 200 {object} helper.Response{data=[]models.Webhook} "List of webhooks"
+// @Failure 401 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /webhooks [get]
+func (wh *webhookController) GetWebhooks(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	webhooks := []models.Webhook{}
+	if err := wh.DB.Where("user_id = ?", userID).Order("id").Find(&webhooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get webhooks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(webhooks))
+}
+
+// GetWebhookByID godoc
+// @Summary Get webhook by ID
+// @Description Gets a webhook subscription of the current user
+// @Tags Webhooks
+// @Produce json
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} helper.Response{data=models.Webhook} "Webhook"
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /webhooks/{webhook_id} [get]
+func (wh *webhookController) GetWebhookByID(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	webhook, ok := wh.findWebhook(c, userID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(webhook))
+}
+
+// UpdateWebhook godoc
+// @Summary Update webhook
+// @Description Changes the URL, events, description of a webhook or pauses it (active=false, events are not queued while paused). Fields missing in the request are not changed, events replace the whole list
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param webhook_id path string true "Webhook ID"
+// @Param request body dto.UpdateWebhookReq true "Fields to change"
+// @Success 200 {object} helper.Response{data=models.Webhook} "Updated webhook"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /webhooks/{webhook_id} [patch]
+func (wh *webhookController) UpdateWebhook(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	var req dto.UpdateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, helper.NewErrorResponse("Invalid input"))
+		return
+	}
+
+	updates := map[string]any{}
+	if req.URL != nil {
+		if err := services.ValidateWebhookURL(*req.URL); err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+		updates["url"] = *req.URL
+	}
+	if req.Events != nil {
+		events, err := services.ValidateEvents(*req.Events)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse(err.Error()))
+			return
+		}
+		updates["events"] = events
+	}
+	if req.Description != nil {
+		if utf8.RuneCountInString(*req.Description) > 255 {
+			c.JSON(http.StatusBadRequest, helper.NewErrorResponse("description must be at most 255 characters"))
+			return
+		}
+		updates["description"] = *req.Description
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+
+	webhook, ok := wh.findWebhook(c, userID)
+	if !ok {
+		return
+	}
+
+	if len(updates) > 0 {
+		if err := wh.DB.Model(&webhook).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to update webhook"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(webhook))
+}
+
+// DeleteWebhook godoc
+// @Summary Delete webhook
+// @Description Deletes a webhook subscription with its delivery log, including events not delivered yet
+// @Tags Webhooks
+// @Produce json
+// @Param webhook_id path string true "Webhook ID"
+// @Success 200 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /webhooks/{webhook_id} [delete]
+func (wh *webhookController) DeleteWebhook(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	webhook, ok := wh.findWebhook(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := wh.DB.Delete(&webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to delete webhook"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse("Webhook deleted successfully"))
+}
+
+// GetWebhookDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Gets the delivery log of the webhook, newest first: event, payload, status (pending, delivered, failed), number of attempts, last response code and error, time of the next attempt
+// @Tags Webhooks
+// @Produce json
+// @Param webhook_id path string true "Webhook ID"
+// @Param limit query int false "Max number of deliveries, 1-500" default(50)
+// @Success 200 {object} helper.Response{data=[]models.WebhookDelivery} "Deliveries"
+// @Failure 400 {object} helper.Response
+// @Failure 401 {object} helper.Response
+// @Failure 404 {object} helper.Response
+// @Failure 500 {object} helper.Response
+// @Router /webhooks/{webhook_id}/deliveries [get]
+func (wh *webhookController) GetWebhookDeliveries(c *gin.Context) {
+	userID, err := helper.GetUserIDFromContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, helper.NewErrorResponse("You are not authorized"))
+		return
+	}
+
+	limit, ok := parseLogLimit(c)
+	if !ok {
+		return
+	}
+
+	webhook, ok := wh.findWebhook(c, userID)
+	if !ok {
+		return
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := wh.DB.Where("webhook_id = ?", webhook.ID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get deliveries"))
+		return
+	}
+
+	c.JSON(http.StatusOK, helper.NewSuccessResponse(deliveries))
+}
+
+// findWebhook находит вебхук пользователя из пути. При ошибке сам отвечает клиенту.
+func (wh *webhookController) findWebhook(c *gin.Context, userID int) (models.Webhook, bool) {
+	var webhook models.Webhook
+	if err := wh.DB.Where("id = ? AND user_id = ?", c.Param("webhook_id"), userID).First(&webhook).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, helper.NewErrorResponse("Webhook not found"))
+			return webhook, false
+		}
+		c.JSON(http.StatusInternalServerError, helper.NewErrorResponse("Failed to get webhook"))
+		return webhook, false
+	}
+	return webhook, true
+}
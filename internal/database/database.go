@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"strings"
 	"tfidf-app/internal/config"
 	"tfidf-app/internal/models"
 
@@ -16,7 +17,8 @@ func ConnectDatabase() {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s",
 		config.Init.DB_HOST, config.Init.DB_PORT, config.Init.DB_USER, config.Init.DB_NAME, config.Init.DB_PASSWORD)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError: нарушение уникального индекса приходит как gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -28,6 +30,24 @@ func ConnectDatabase() {
 }
 
 func migrate() {
+	if err := migrateFilePathToStorageKey(); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if err := migrateTermPostingsEncoding(); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if err := migrateDuplicateDocumentNames(); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
+	// Связь документов и тегов через DocumentTag, чтобы при удалении документа или тега она удалялась каскадом
+	if err := DB.SetupJoinTable(&models.Document{}, "Tags", &models.DocumentTag{}); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if err := DB.SetupJoinTable(&models.Tag{}, "Documents", &models.DocumentTag{}); err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+
 	err := DB.AutoMigrate(
 		&models.Metric{},
 		&models.Word{},
@@ -35,9 +55,86 @@ func migrate() {
 		&models.Document{},
 		&models.Collection{},
 		&models.CollectionDocument{},
+		&models.Blob{},
+		&models.DocumentVersion{},
+		&models.UploadSession{},
+		&models.Tag{},
+		&models.DocumentTag{},
+		&models.TermPosting{},
+		&models.Classifier{},
+		&models.ClassifierLabel{},
+		&models.Watch{},
+		&models.Webhook{},
+		&models.WatchAlert{},
+		&models.WebhookDelivery{},
 	)
 	if err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 	log.Println("INFO: Database migrated.")
 }
+
+// Раньше в documents.file_path лежал абсолютный путь на диске,
+// теперь это ключ в хранилище относительно его корня
+func migrateFilePathToStorageKey() error {
+	const legacyDocumentsDir = "/app/documents/"
+
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&models.Document{}) || !migrator.HasColumn(&models.Document{}, "file_path") {
+		return nil
+	}
+
+	// DDL в Postgres транзакционный: если UPDATE не пройдет, откатится и переименование,
+	// и при следующем старте миграция повторится целиком, а не оставит абсолютные пути
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().RenameColumn(&models.Document{}, "file_path", "storage_key"); err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"UPDATE documents SET storage_key = SUBSTRING(storage_key FROM ?) WHERE storage_key LIKE ?",
+			len(legacyDocumentsDir)+1, legacyDocumentsDir+"%",
+		).Error
+	})
+}
+
+// Имена документов у пользователя уникальны (индекс idx_user_document_name), но раньше это только
+// проверялось перед вставкой, и параллельные загрузки могли создать одноименные документы.
+// Перед созданием индекса старший документ сохраняет имя, остальные получают суффикс " (id)".
+func migrateDuplicateDocumentNames() error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&models.Document{}) || migrator.HasIndex(&models.Document{}, "idx_user_document_name") {
+		return nil
+	}
+
+	result := DB.Exec(`UPDATE documents d
+		SET name = LEFT(d.name, 100 - LENGTH(' (' || d.id || ')')) || ' (' || d.id || ')'
+		WHERE EXISTS (SELECT 1 FROM documents o WHERE o.user_id = d.user_id AND o.name = d.name AND o.id < d.id)`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("INFO: Renamed %d documents with duplicate names.", result.RowsAffected)
+	}
+	return nil
+}
+
+// Позиции в индексе сначала хранились в jsonb, теперь - в сжатом бинарном виде.
+// Старый индекс проще удалить: при старте он строится заново по содержимому.
+func migrateTermPostingsEncoding() error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(&models.TermPosting{}) {
+		return nil
+	}
+
+	columnTypes, err := migrator.ColumnTypes(&models.TermPosting{})
+	if err != nil {
+		return err
+	}
+	for _, column := range columnTypes {
+		if column.Name() == "positions" && strings.EqualFold(column.DatabaseTypeName(), "jsonb") {
+			return migrator.DropTable(&models.TermPosting{})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package dto
+
+type CreateClassifierReq struct {
+	Name          string   `json:"name" binding:"required"`
+	CollectionIDs []uint   `json:"collection_ids" binding:"required"` // метки классификатора, от 2 до 50 коллекций
+	AutoFile      bool     `json:"auto_file"`
+	MinConfidence *float64 `json:"min_confidence"` // по умолчанию 0.5
+}
+
+// Поля, которых нет в запросе, не меняются
+type UpdateClassifierReq struct {
+	Name          *string  `json:"name"`
+	AutoFile      *bool    `json:"auto_file"`
+	MinConfidence *float64 `json:"min_confidence"`
+}
+
+type PredictReq struct {
+	DocumentID uint `json:"document_id" binding:"required"`
+}
@@ -1,5 +1,10 @@
 package dto
 
+import (
+	"tfidf-app/internal/models"
+	"time"
+)
+
 type CreateCollectionReq struct {
 	Name string `json:"name" binding:"required"`
 }
@@ -12,3 +17,14 @@ type AddDocumentToCollectionsReq struct {
 	DocumentID    uint   `json:"document_id" binding:"required"`
 	CollectionIDs []uint `json:"collection_ids" binding:"required"`
 }
+
+// Коллекция в списке: число документов, а сами документы - только при include=documents
+type CollectionListItem struct {
+	ID            uint               `json:"id"`
+	Name          string             `json:"name"`
+	UserID        int                `json:"user_id"`
+	DocumentCount int64              `json:"document_count"`
+	Documents     []*models.Document `gorm:"-" json:"documents,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
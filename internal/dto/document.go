@@ -3,8 +3,50 @@ package dto
 import "time"
 
 type DocumentResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Content     string    `json:"content"`
-	UplodadedAt time.Time `json:"uploaded_at"`
+	ID           uint       `json:"id"`
+	Name         string     `json:"name"`
+	Content      string     `json:"content"`
+	Description  string     `json:"description"`
+	Source       string     `json:"source"`
+	MimeType     string     `json:"mime_type"`
+	Size         int64      `json:"size"`
+	TokenCount   int        `json:"token_count"`
+	UniqueTerms  int        `json:"unique_terms"`
+	ContentHash  string     `json:"content_hash"`
+	Version      int        `json:"version"`
+	Tags         []string   `json:"tags"`
+	DocumentDate *time.Time `json:"document_date"`
+	Truncated    bool       `json:"truncated"`        // content обрезан до max_length
+	Binary       bool       `json:"binary,omitempty"` // содержимое не текст, content пустой
+	UplodadedAt  time.Time  `json:"uploaded_at"`
+}
+
+type DocumentVersionResponse struct {
+	DocumentID uint      `json:"document_id"`
+	Name       string    `json:"name"`
+	Version    int       `json:"version"`
+	Content    string    `json:"content"`
+	Size       int64     `json:"size"`
+	Truncated  bool      `json:"truncated"`        // content обрезан до max_length
+	Binary     bool      `json:"binary,omitempty"` // содержимое не текст, content пустой
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateDocumentFromTextReq struct {
+	Name    string `json:"name" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+type CreateDocumentFromURLReq struct {
+	URL  string `json:"url" binding:"required"`
+	Name string `json:"name"` // по умолчанию - последний сегмент пути ссылки
+}
+
+// Поля, которых нет в запросе, не меняются. tags заменяет все теги документа
+type UpdateDocumentReq struct {
+	Name         *string   `json:"name"`
+	Description  *string   `json:"description"`
+	Source       *string   `json:"source"`
+	Tags         *[]string `json:"tags"`
+	DocumentDate *string   `json:"document_date"` // 2024-03-31, пустая строка убирает дату
 }
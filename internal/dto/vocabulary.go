@@ -0,0 +1,10 @@
+package dto
+
+import "tfidf-app/internal/services"
+
+type SuggestResponse struct {
+	Query       string                `json:"query"`
+	Found       bool                  `json:"found"` // слово есть в документах пользователя как есть
+	DF          int                   `json:"df"`
+	Suggestions []services.Suggestion `json:"suggestions"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "tfidf-app/internal/models"
+
+// Задается ровно одно из term и query
+type CreateWatchReq struct {
+	Name       string `json:"name" binding:"required"`
+	Term       string `json:"term"`        // слово, которое должно попасть в топ TF-IDF нового документа
+	Query      string `json:"query"`       // поисковый запрос, как в /documents/search
+	TopN       *int   `json:"top_n"`       // размер топа для term, 1-100, по умолчанию 20
+	WebhookURL string `json:"webhook_url"` // куда слать срабатывания, пусто - только записывать
+}
+
+// Ключ подписи вебхуков показывается один раз - при создании
+type CreateWatchResponse struct {
+	models.Watch
+	Secret string `json:"secret"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+import "tfidf-app/internal/models"
+
+type CreateWebhookReq struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events" binding:"required"` // document.created, document.deleted, collection.updated, statistics.ready
+	Description string   `json:"description"`
+}
+
+// Поля, которых нет в запросе, не меняются. events заменяет весь список
+type UpdateWebhookReq struct {
+	URL         *string   `json:"url"`
+	Events      *[]string `json:"events"`
+	Description *string   `json:"description"`
+	Active      *bool     `json:"active"`
+}
+
+// Ключ подписи показывается один раз - при создании
+type CreateWebhookResponse struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
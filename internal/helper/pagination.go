@@ -0,0 +1,204 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Ключи сортировки списков: sort=name|created_at|size
+const (
+	SortName      = "name"
+	SortCreatedAt = "created_at"
+	SortSize      = "size"
+)
+
+// Блок meta в ответе со списком
+type PageMeta struct {
+	Total      int64  `json:"total"`                 // сколько всего записей под фильтрами
+	Limit      int    `json:"limit"`                 // размер страницы
+	NextCursor string `json:"next_cursor,omitempty"` // передать в cursor, чтобы получить следующую страницу
+	Sort       string `json:"sort"`
+	Order      string `json:"order"`
+}
+
+// ListParams - параметры списка из query: limit, cursor, sort, order, name, created_from, created_to
+type ListParams struct {
+	Limit       int
+	Sort        string
+	Desc        bool
+	Name        string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time // не включительно
+
+	cursor *pageCursor
+}
+
+// Курсор - значение сортировки и id последней записи страницы
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d"`
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// ParseListParams читает параметры списка, по умолчанию - новые сверху по 20
+func ParseListParams(c *gin.Context) (ListParams, error) {
+	params := ListParams{
+		Limit: defaultPageLimit,
+		Sort:  c.DefaultQuery("sort", SortCreatedAt),
+		Name:  strings.TrimSpace(c.Query("name")),
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return ListParams{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		params.Limit = n
+	}
+
+	switch params.Sort {
+	case SortName, SortCreatedAt, SortSize:
+	default:
+		return ListParams{}, errors.New("sort must be one of name, created_at, size")
+	}
+
+	// Имена удобнее по алфавиту, остальное - от больших к меньшим
+	order := c.Query("order")
+	switch order {
+	case "":
+		params.Desc = params.Sort != SortName
+	case "asc":
+	case "desc":
+		params.Desc = true
+	default:
+		return ListParams{}, errors.New("order must be asc or desc")
+	}
+
+	var err error
+	if params.CreatedFrom, err = parseDateParam(c.Query("created_from"), false); err != nil {
+		return ListParams{}, fmt.Errorf("invalid created_from: %w", err)
+	}
+	if params.CreatedTo, err = parseDateParam(c.Query("created_to"), true); err != nil {
+		return ListParams{}, fmt.Errorf("invalid created_to: %w", err)
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return ListParams{}, errors.New("invalid cursor")
+		}
+		params.cursor = &pageCursor{}
+		if err := json.Unmarshal(raw, params.cursor); err != nil {
+			return ListParams{}, errors.New("invalid cursor")
+		}
+		if params.cursor.Sort != params.Sort || params.cursor.Desc != params.Desc {
+			return ListParams{}, errors.New("cursor was issued for another sort order")
+		}
+	}
+
+	return params, nil
+}
+
+// parseDateParam понимает RFC 3339 и просто дату. Дата как конец диапазона включает весь день.
+func parseDateParam(value string, end bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, errors.New("expected YYYY-MM-DD or RFC 3339")
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// Filter добавляет поиск по подстроке имени и диапазон дат создания
+func (p ListParams) Filter(query *gorm.DB, table string) *gorm.DB {
+	if p.Name != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(p.Name)
+		query = query.Where(table+".name ILIKE ?", "%"+escaped+"%")
+	}
+	if p.CreatedFrom != nil {
+		query = query.Where(table+".created_at >= ?", *p.CreatedFrom)
+	}
+	if p.CreatedTo != nil {
+		query = query.Where(table+".created_at < ?", *p.CreatedTo)
+	}
+	return query
+}
+
+// Page добавляет условие курсора, сортировку и лимит (на одну запись больше, чтобы понять, есть ли еще).
+// columns - SQL-выражения для ключей сортировки.
+func (p ListParams) Page(query *gorm.DB, table string, columns map[string]string) (*gorm.DB, error) {
+	column := columns[p.Sort]
+	op, dir := ">", "ASC"
+	if p.Desc {
+		op, dir = "<", "DESC"
+	}
+
+	if p.cursor != nil {
+		value, err := p.cursorValue()
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			fmt.Sprintf("((%s %s ?) OR (%s = ? AND %s.id %s ?))", column, op, column, table, op),
+			value, value, p.cursor.ID,
+		)
+	}
+
+	return query.Order(fmt.Sprintf("%s %s, %s.id %s", column, dir, table, dir)).Limit(p.Limit + 1), nil
+}
+
+func (p ListParams) cursorValue() (any, error) {
+	var err error
+	switch p.Sort {
+	case SortName:
+		var value string
+		err = json.Unmarshal(p.cursor.Value, &value)
+		return value, err
+	case SortCreatedAt:
+		var value time.Time
+		err = json.Unmarshal(p.cursor.Value, &value)
+		return value, err
+	default:
+		var value int64
+		err = json.Unmarshal(p.cursor.Value, &value)
+		return value, err
+	}
+}
+
+// Meta собирает блок meta. Если записей больше лимита, лишняя отбрасывается вызывающим,
+// а курсор строится по последней записи страницы: ее значению сортировки и id.
+func (p ListParams) Meta(total int64, hasMore bool, lastValue any, lastID uint) PageMeta {
+	meta := PageMeta{Total: total, Limit: p.Limit, Sort: p.Sort, Order: "asc"}
+	if p.Desc {
+		meta.Order = "desc"
+	}
+
+	if hasMore {
+		value, _ := json.Marshal(lastValue)
+		raw, _ := json.Marshal(pageCursor{Sort: p.Sort, Desc: p.Desc, Value: value, ID: lastID})
+		meta.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return meta
+}
@@ -3,6 +3,7 @@ package helper
 // @Description This is the standard response format for all API endpoints
 type Response struct {
 	Data      any    `json:"data,omitempty"`
+	Meta      any    `json:"meta,omitempty"` // пагинация для списков
 	Error     string `json:"error,omitempty"`
 	IsSuccess bool   `json:"is_success"`
 }
@@ -22,3 +23,12 @@ func NewSuccessResponse(data any) Response {
 		IsSuccess: true,
 	}
 }
+
+func NewPageResponse(data any, meta PageMeta) Response {
+	return Response{
+		Data:      data,
+		Meta:      meta,
+		Error:     "",
+		IsSuccess: true,
+	}
+}
@@ -16,8 +16,9 @@ func CorsMiddleware(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", origin)
 	}
 
-	c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, HEAD, OPTIONS")
+	c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Tus-Resumable, Upload-Length, Upload-Metadata, Upload-Offset")
+	c.Header("Access-Control-Expose-Headers", "Location, Tus-Resumable, Upload-Offset, Upload-Length")
 	c.Header("Access-Control-Allow-Credentials", "true")
 
 	if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Blob - файл в хранилище, адресуемый по SHA-256 содержимого.
+// Одинаковые документы ссылаются на один blob, RefCount - сколько их.
+type Blob struct {
+	Hash        string `gorm:"primaryKey;size:64"`
+	StorageKey  string `gorm:"not null"`
+	Compression string `gorm:"size:10"`
+	Size        int64
+	StoredSize  int64
+	RefCount    int  `gorm:"not null;default:0"`
+	Indexed     bool `gorm:"not null;default:false"` // позиционный индекс построен (у текста без слов он пустой)
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
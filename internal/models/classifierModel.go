@@ -0,0 +1,62 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Classifier - наивный байесовский классификатор, обученный на коллекциях пользователя:
+// каждая коллекция - метка класса, ее документы - обучающие примеры.
+type Classifier struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	Name   string `gorm:"size:100;not null" json:"name"`
+	UserID int    `gorm:"not null;index" json:"user_id"`
+	User   User   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	// Раскладывать ли новые загрузки в предсказанную коллекцию, если вероятность не ниже MinConfidence
+	AutoFile      bool              `gorm:"not null;default:false" json:"auto_file"`
+	MinConfidence float64           `gorm:"not null;default:0.5" json:"min_confidence"`
+	Vocabulary    int               `gorm:"not null" json:"vocabulary"` // число разных слов во всех метках
+	Labels        []ClassifierLabel `gorm:"constraint:OnDelete:CASCADE" json:"labels"`
+
+	TrainedAt time.Time `json:"trained_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ClassifierLabel - класс модели. Ссылка на коллекцию без внешнего ключа:
+// удаление коллекции не портит обученную модель, только автораскладку в нее.
+type ClassifierLabel struct {
+	ID           uint       `gorm:"primaryKey" json:"-"`
+	ClassifierID uint       `gorm:"not null;index" json:"-"`
+	CollectionID uint       `gorm:"not null" json:"collection_id"`
+	Name         string     `gorm:"size:100;not null" json:"name"`
+	Documents    int        `gorm:"not null" json:"documents"` // обучающих документов, из них априорная вероятность
+	Tokens       int        `gorm:"not null" json:"tokens"`
+	Terms        int        `gorm:"not null" json:"terms"`
+	Counts       TermCounts `gorm:"type:jsonb;not null" json:"-"`
+}
+
+// TermCounts - сколько раз каждое слово встретилось в документах метки
+type TermCounts map[string]int
+
+func (t TermCounts) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *TermCounts) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for TermCounts")
+	}
+	return json.Unmarshal(data, t)
+}
@@ -3,12 +3,24 @@ package models
 import "time"
 
 type Document struct {
-	ID          uint          `gorm:"primaryKey" json:"id"`
-	Name        string        `gorm:"size:100;not null" json:"name"` // имя файла или произвольное название
-	FilePath    string        `gorm:"not null" json:"-"`             // путь до файла на диске
-	UserID      int           `gorm:"not null" json:"user_id"`
-	User        User          `gorm:"constraint:OnDelete:CASCADE" json:"-"`
-	Collections []*Collection `gorm:"many2many:collection_documents;" json:"-"`
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	Name         string        `gorm:"size:100;not null;uniqueIndex:idx_user_document_name" json:"name"` // имя файла или произвольное название, у пользователя уникально
+	StorageKey   string        `gorm:"not null" json:"-"`                                                // ключ файла в хранилище (user_1/file.txt)
+	Compression  string        `gorm:"size:10" json:"-"`                                                 // как файл сжат на диске ("" - без сжатия)
+	Size         int64         `json:"size"`                                                             // исходный размер в байтах
+	StoredSize   int64         `json:"stored_size"`                                                      // размер на диске в байтах
+	ContentHash  string        `gorm:"size:64;index" json:"content_hash"`                                // SHA-256 содержимого, он же ключ blob'а
+	Version      int           `gorm:"not null;default:1" json:"version"`                                // номер текущей версии
+	Description  string        `gorm:"type:text" json:"description"`
+	Source       string        `gorm:"type:text" json:"source"`                // откуда взят: upload, text или ссылка
+	MimeType     string        `gorm:"size:100;index" json:"mime_type"`        // тип сохраненного содержимого
+	TokenCount   int           `gorm:"not null;default:0" json:"token_count"`  // сколько всего слов
+	UniqueTerms  int           `gorm:"not null;default:0" json:"unique_terms"` // сколько разных слов
+	DocumentDate *time.Time    `gorm:"type:date;index" json:"document_date"`   // дата самого текста (отчета, письма), задает пользователь
+	UserID       int           `gorm:"not null;uniqueIndex:idx_user_document_name" json:"user_id"`
+	User         User          `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Collections  []*Collection `gorm:"many2many:collection_documents;" json:"-"`
+	Tags         []*Tag        `gorm:"many2many:document_tags;" json:"tags"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
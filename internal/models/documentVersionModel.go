@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DocumentVersion - одна из версий содержимого документа.
+// Ссылки на blob'ы держат именно версии, документ хранит копию полей текущей.
+type DocumentVersion struct {
+	ID          uint     `gorm:"primaryKey" json:"-"`
+	DocumentID  uint     `gorm:"not null;uniqueIndex:idx_document_version" json:"document_id"`
+	Document    Document `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Version     int      `gorm:"not null;uniqueIndex:idx_document_version" json:"version"`
+	StorageKey  string   `gorm:"not null" json:"-"`
+	Compression string   `gorm:"size:10" json:"-"`
+	Size        int64    `json:"size"`
+	StoredSize  int64    `json:"stored_size"`
+	ContentHash string   `gorm:"size:64" json:"content_hash"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
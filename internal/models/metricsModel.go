@@ -11,6 +11,7 @@ type Metric struct {
 	MaxTimeProcessed             float64   `gorm:"type:decimal(10,3);default:0.0" json:"max_time_processed"`
 	TotalFileSizeMB              float64   `gorm:"type:decimal(10,3);default:0.0" json:"total_file_size_mb"`
 	AvgFileSizeMB                float64   `gorm:"type:decimal(10,3);default:0.0" json:"avg_file_size_mb"`
+	StorageSavedMB               float64   `gorm:"-" json:"storage_saved_mb"` // считается по документам при запросе
 	Words                        []Word    `gorm:"foreignKey:MetricID" json:"top_10_most_freq_words"`
 }
 
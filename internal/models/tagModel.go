@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Tag - метка документов, у каждого пользователя свой набор
+type Tag struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	Name      string      `gorm:"size:50;not null;uniqueIndex:idx_user_tag" json:"name"`
+	UserID    int         `gorm:"not null;uniqueIndex:idx_user_tag" json:"-"`
+	User      User        `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Documents []*Document `gorm:"many2many:document_tags;" json:"-"`
+
+	CreatedAt time.Time `json:"-"`
+}
+
+type DocumentTag struct {
+	DocumentID uint `gorm:"primaryKey"`
+	TagID      uint `gorm:"primaryKey"`
+
+	Document Document `gorm:"constraint:OnDelete:CASCADE;foreignKey:DocumentID"`
+	Tag      Tag      `gorm:"constraint:OnDelete:CASCADE;foreignKey:TagID"`
+}
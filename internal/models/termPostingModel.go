@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+)
+
+// TermPosting - запись позиционного индекса: где в содержимом встречается слово.
+// Индекс строится по содержимому (хэшу), поэтому одинаковые документы и версии делят его.
+type TermPosting struct {
+	ContentHash string        `gorm:"primaryKey;size:64" json:"content_hash"`
+	Term        string        `gorm:"primaryKey;index" json:"term"`
+	Count       int           `gorm:"not null" json:"count"`
+	Positions   TermPositions `gorm:"type:bytea;not null" json:"positions"`
+}
+
+// Вхождение слова: номер слова в тексте и его байтовые смещения
+type TermPosition struct {
+	Pos   int   `json:"pos"`
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// TermPositions хранится компактно: вхождения идут по порядку, поэтому для каждого
+// пишутся varint'ами разницы с предыдущим - номера слова, начала от конца прошлого вхождения - и длина слова.
+type TermPositions []TermPosition
+
+func (p TermPositions) Value() (driver.Value, error) {
+	return p.AppendEncoded(make([]byte, 0, len(p)*4), TermPosition{}), nil
+}
+
+// AppendEncoded дописывает вхождения в формате Value, считая разницы от prev - вхождения перед ними.
+// Так закодированные по очереди куски вхождений одного слова можно просто склеить.
+func (p TermPositions) AppendEncoded(data []byte, prev TermPosition) []byte {
+	prevPos, prevEnd := prev.Pos, prev.End
+	for _, position := range p {
+		data = binary.AppendUvarint(data, uint64(position.Pos-prevPos))
+		data = binary.AppendUvarint(data, uint64(position.Start-prevEnd))
+		data = binary.AppendUvarint(data, uint64(position.End-position.Start))
+		prevPos, prevEnd = position.Pos, position.End
+	}
+	return data
+}
+
+func (p *TermPositions) Scan(value any) error {
+	data, ok := value.([]byte)
+	if !ok {
+		return errors.New("unsupported type for TermPositions")
+	}
+
+	positions := TermPositions{}
+	prevPos, prevEnd := 0, int64(0)
+	for len(data) > 0 {
+		var fields [3]uint64
+		for i := range fields {
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("corrupted TermPositions")
+			}
+			fields[i] = v
+			data = data[n:]
+		}
+
+		position := TermPosition{Pos: prevPos + int(fields[0]), Start: prevEnd + int64(fields[1])}
+		position.End = position.Start + int64(fields[2])
+		positions = append(positions, position)
+		prevPos, prevEnd = position.Pos, position.End
+	}
+
+	*p = positions
+	return nil
+}
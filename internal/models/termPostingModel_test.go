@@ -0,0 +1,76 @@
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestTermPositionsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions TermPositions
+	}{
+		{"empty", TermPositions{}},
+		{"single", TermPositions{{Pos: 0, Start: 0, End: 5}}},
+		{"single far from start", TermPositions{{Pos: 7, Start: 40, End: 52}}},
+		{"several", TermPositions{{Pos: 0, Start: 0, End: 8}, {Pos: 2, Start: 16, End: 24}, {Pos: 3, Start: 25, End: 25}}},
+		{"large gaps", TermPositions{{Pos: 1, Start: 2, End: 3}, {Pos: 1_000_000, Start: 1 << 40, End: 1<<40 + 300}, {Pos: 1 << 31, Start: 1 << 50, End: 1<<50 + 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.positions.Value()
+			if err != nil {
+				t.Fatalf("Value: %v", err)
+			}
+			data, ok := value.([]byte)
+			if !ok {
+				t.Fatalf("Value returned %T, want []byte", value)
+			}
+
+			var got TermPositions
+			if err := got.Scan(data); err != nil {
+				t.Fatalf("Scan: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.positions) {
+				t.Errorf("Scan(Value()) = %v, want %v", got, tt.positions)
+			}
+		})
+	}
+}
+
+func TestTermPositionsAppendEncodedChunks(t *testing.T) {
+	positions := TermPositions{{Pos: 0, Start: 0, End: 3}, {Pos: 4, Start: 20, End: 23}, {Pos: 9, Start: 50, End: 53}, {Pos: 100_000, Start: 1 << 33, End: 1<<33 + 3}}
+	whole, _ := positions.Value()
+
+	for split := 0; split <= len(positions); split++ {
+		data := positions[:split].AppendEncoded(nil, TermPosition{})
+		prev := TermPosition{}
+		if split > 0 {
+			prev = positions[split-1]
+		}
+		data = positions[split:].AppendEncoded(data, prev)
+		if !bytes.Equal(data, whole.([]byte)) {
+			t.Errorf("split at %d: chunks encode to %x, whole to %x", split, data, whole)
+		}
+	}
+}
+
+func TestTermPositionsScanErrors(t *testing.T) {
+	valid, _ := TermPositions{{Pos: 3, Start: 10, End: 15}}.Value()
+	encoded := valid.([]byte)
+
+	for name, value := range map[string]any{
+		"string":            "positions",
+		"nil":               nil,
+		"truncated varint":  []byte{0x80},
+		"incomplete triple": encoded[:len(encoded)-1],
+		"trailing byte":     append(append([]byte{}, encoded...), 0x01),
+	} {
+		var positions TermPositions
+		if err := positions.Scan(value); err == nil {
+			t.Errorf("%s: Scan succeeded with %v", name, positions)
+		}
+	}
+}
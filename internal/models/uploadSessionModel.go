@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// UploadSession - незавершенная докачиваемая загрузка (протокол tus).
+// Уже принятые байты лежат во временном файле в UPLOADS_DIR.
+type UploadSession struct {
+	ID       string `gorm:"primaryKey;size:32" json:"id"`
+	UserID   int    `gorm:"not null;index" json:"-"`
+	User     User   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Filename string `gorm:"not null" json:"filename"`
+	Length   int64  `gorm:"not null" json:"length"`
+	Offset   int64  `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
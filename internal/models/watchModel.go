@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Watch - отслеживание новых документов пользователя: слово (Term) в топе TF-IDF документа
+// или поисковый запрос (Query). Задано ровно одно из двух.
+// Если указан WebhookURL, о каждом срабатывании туда уходит подписанный Secret'ом POST.
+type Watch struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	Name       string `gorm:"size:100;not null" json:"name"`
+	UserID     int    `gorm:"not null;index" json:"user_id"`
+	User       User   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Term       string `gorm:"size:100" json:"term,omitempty"`
+	Query      string `gorm:"type:text" json:"query,omitempty"`
+	TopN       int    `gorm:"not null;default:20" json:"top_n"` // для слова: в скольких самых весомых словах документа искать
+	WebhookURL string `gorm:"type:text" json:"webhook_url,omitempty"`
+	Secret     string `gorm:"size:64;not null" json:"-"` // ключ HMAC-SHA256, показывается только при создании
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WatchAlert - срабатывание отслеживания на загруженном документе.
+// Ссылка на документ без внешнего ключа: история остается и после его удаления.
+type WatchAlert struct {
+	ID           uint    `gorm:"primaryKey" json:"id"`
+	WatchID      uint    `gorm:"not null;index" json:"watch_id"`
+	Watch        Watch   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	DocumentID   uint    `gorm:"not null" json:"document_id"`
+	DocumentName string  `gorm:"size:100;not null" json:"document_name"`
+	Version      int     `gorm:"not null" json:"version"`
+	Rank         int     `json:"rank,omitempty"`  // для слова: место в топе TF-IDF документа, с 1
+	Score        float64 `json:"score,omitempty"` // для слова: его TF-IDF в документе
+	Hits         int     `json:"hits,omitempty"`  // для запроса: число совпадений
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Состояния доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // попытки кончились
+)
+
+// WebhookDelivery - одно событие, отправляемое на вебхук, и история попыток его доставить.
+// Владелец - отслеживание (WatchID) или подписка на события (WebhookID), его ключом
+// Payload подписывается в момент отправки.
+type WebhookDelivery struct {
+	ID        uint     `gorm:"primaryKey" json:"id"`
+	UserID    int      `gorm:"not null;index" json:"-"`
+	User      User     `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	WatchID   *uint    `gorm:"index" json:"watch_id,omitempty"`
+	Watch     *Watch   `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	WebhookID *uint    `gorm:"index" json:"webhook_id,omitempty"`
+	Webhook   *Webhook `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Event     string   `gorm:"size:50;not null" json:"event"`
+	URL       string   `gorm:"type:text;not null" json:"url"`
+	Payload   string   `gorm:"type:text;not null" json:"payload"`
+
+	Status         string     `gorm:"size:20;not null;index" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"` // HTTP-код последней попытки
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
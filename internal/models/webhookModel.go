@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Webhook - подписка пользователя на события документов и коллекций.
+// Каждое событие из Events уходит POST-запросом на URL с подписью ключом Secret.
+type Webhook struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      int        `gorm:"not null;index" json:"user_id"`
+	User        User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	URL         string     `gorm:"type:text;not null" json:"url"`
+	Events      EventTypes `gorm:"type:jsonb;not null" json:"events"`
+	Description string     `gorm:"size:255" json:"description"`
+	Active      bool       `gorm:"not null;default:true" json:"active"`
+	Secret      string     `gorm:"size:64;not null" json:"-"` // ключ HMAC-SHA256, показывается только при создании
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// EventTypes - типы событий подписки ("document.created", ...)
+type EventTypes []string
+
+func (e EventTypes) Value() (driver.Value, error) {
+	if e == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(e)
+}
+
+func (e *EventTypes) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for EventTypes")
+	}
+	return json.Unmarshal(data, e)
+}
+
+// Has проверяет, подписан ли вебхук на событие
+func (e EventTypes) Has(event string) bool {
+	for _, subscribed := range e {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package query
+
+import "sort"
+
+// Index отдает позиции (номера слов) слова в документе по возрастанию
+type Index interface {
+	Positions(term string) []int
+}
+
+// span - совпадение в тексте: номера первого и последнего слова
+type span struct {
+	start, end int
+}
+
+// Match проверяет документ по запросу. hits - сколько найдено совпадений слов, фраз и NEAR
+// в выполненных условиях, по нему документы можно ранжировать.
+func Match(node Node, index Index) (matched bool, hits int) {
+	switch n := node.(type) {
+	case And:
+		leftMatched, leftHits := Match(n.Left, index)
+		if !leftMatched {
+			return false, 0
+		}
+		rightMatched, rightHits := Match(n.Right, index)
+		if !rightMatched {
+			return false, 0
+		}
+		return true, leftHits + rightHits
+	case Or:
+		leftMatched, leftHits := Match(n.Left, index)
+		rightMatched, rightHits := Match(n.Right, index)
+		return leftMatched || rightMatched, leftHits + rightHits
+	case Not:
+		matched, _ := Match(n.Node, index)
+		return !matched, 0
+	default:
+		found := spans(node, index)
+		return len(found) > 0, len(found)
+	}
+}
+
+// spans находит все места в тексте, где совпадает позиционный узел, по возрастанию начала
+func spans(node Node, index Index) []span {
+	switch n := node.(type) {
+	case Term:
+		positions := index.Positions(n.Word)
+		found := make([]span, len(positions))
+		for i, pos := range positions {
+			found[i] = span{pos, pos}
+		}
+		return found
+	case Phrase:
+		return phraseSpans(n.Words, index)
+	case Near:
+		return nearSpans(spans(n.Left, index), spans(n.Right, index), n.Distance)
+	}
+	return nil
+}
+
+// phraseSpans оставляет позиции первого слова, за которыми остальные слова идут подряд
+func phraseSpans(words []string, index Index) []span {
+	var found []span
+	first := index.Positions(words[0])
+	following := make([][]int, len(words)-1)
+	for i, word := range words[1:] {
+		following[i] = index.Positions(word)
+	}
+
+	for _, start := range first {
+		ok := true
+		for i, positions := range following {
+			if !contains(positions, start+i+1) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			found = append(found, span{start, start + len(words) - 1})
+		}
+	}
+	return found
+}
+
+func contains(sorted []int, value int) bool {
+	i := sort.SearchInts(sorted, value)
+	return i < len(sorted) && sorted[i] == value
+}
+
+// nearSpans находит пары совпадений, между которыми не больше distance слов.
+// Пересекающиеся совпадения (одно и то же слово с обеих сторон) парой не считаются.
+func nearSpans(left, right []span, distance int) []span {
+	if len(left) == 0 || len(right) == 0 {
+		return nil
+	}
+
+	// Самое длинное совпадение справа - насколько раньше левого может начинаться подходящее
+	longest := 0
+	for _, r := range right {
+		longest = max(longest, r.end-r.start)
+	}
+
+	var found []span
+	for _, l := range left {
+		from := sort.Search(len(right), func(i int) bool {
+			return right[i].start >= l.start-distance-1-longest
+		})
+		for _, r := range right[from:] {
+			if r.start > l.end+distance+1 {
+				break
+			}
+
+			var gap int
+			switch {
+			case l.end < r.start:
+				gap = r.start - l.end - 1
+			case r.end < l.start:
+				gap = l.start - r.end - 1
+			default:
+				continue
+			}
+			if gap <= distance {
+				found = append(found, span{min(l.start, r.start), max(l.end, r.end)})
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+	return found
+}
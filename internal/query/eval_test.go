@@ -0,0 +1,74 @@
+package query
+
+import "testing"
+
+// textIndex - индекс по тексту в памяти: позиции слов по порядку
+type textIndex map[string][]int
+
+func newTextIndex(text string) textIndex {
+	index := make(textIndex)
+	for pos, word := range analyze(text) {
+		index[word] = append(index[word], pos)
+	}
+	return index
+}
+
+func (index textIndex) Positions(term string) []int {
+	return index[term]
+}
+
+func TestMatch(t *testing.T) {
+	index := newTextIndex("the quick brown fox jumps over the lazy dog")
+
+	tests := []struct {
+		query   string
+		matched bool
+		hits    int
+	}{
+		{`fox`, true, 1},
+		{`the`, true, 2},
+		{`cat`, false, 0},
+		{`fox dog`, true, 2},
+		{`fox cat`, false, 0},
+		{`cat OR the`, true, 2},
+		{`cat OR mouse`, false, 0},
+		{`NOT cat`, true, 0},
+		{`NOT fox`, false, 0},
+		{`fox AND NOT dog`, false, 0},
+		{`fox AND NOT cat`, true, 1},
+		{`(cat OR fox) lazy`, true, 2},
+
+		// Фразы - слова подряд и в том же порядке
+		{`"quick brown"`, true, 1},
+		{`"brown quick"`, false, 0},
+		{`"quick fox"`, false, 0},
+		{`"the lazy dog"`, true, 1},
+
+		// NEAR/k - не больше k слов между, порядок неважен
+		{`quick NEAR/1 fox`, true, 1},
+		{`fox NEAR/1 quick`, true, 1},
+		{`quick NEAR/0 fox`, false, 0},
+		{`quick NEAR/0 brown`, true, 1},
+		{`brown NEAR/0 quick`, true, 1},
+		{`"lazy dog" NEAR/2 jumps`, true, 1},
+		{`jumps NEAR/2 "lazy dog"`, true, 1},
+		{`"lazy dog" NEAR/1 jumps`, false, 0},
+		{`the NEAR/2 fox`, true, 2},
+		{`the NEAR/1 fox`, false, 0},
+		{`the NEAR/10 the`, true, 2},
+		{`fox NEAR/0 fox`, false, 0},
+		{`(quick NEAR/0 brown) NEAR/0 fox`, true, 1},
+	}
+
+	for _, tt := range tests {
+		node, err := Parse(tt.query, analyze)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		matched, hits := Match(node, index)
+		if matched != tt.matched || hits != tt.hits {
+			t.Errorf("Match(%q) = %v, %d; want %v, %d", tt.query, matched, hits, tt.matched, tt.hits)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+// Package query разбирает язык поисковых запросов и проверяет запрос по позициям слов документа.
+//
+// Синтаксис:
+//
+//	слово               документ содержит слово
+//	"точная фраза"      слова идут подряд
+//	a NEAR/3 b          между a и b не больше 3 слов, в любом порядке (a и b - слова, фразы или NEAR)
+//	a b, a AND b        оба условия
+//	a OR b              хотя бы одно
+//	NOT a               условие не выполняется
+//	( ... )             группировка
+//
+// Приоритет от сильного к слабому: NEAR, NOT, AND, OR. Операторы пишутся заглавными буквами,
+// "and" строчными - обычное слово.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrSyntax = errors.New("invalid query")
+
+// Сколько слов может быть в одном запросе
+const MaxTerms = 32
+
+// Node - узел разобранного запроса
+type Node interface {
+	// positional - узел совпадает в конкретных местах текста, его можно ставить в NEAR
+	positional() bool
+}
+
+type Term struct {
+	Word string
+}
+
+type Phrase struct {
+	Words []string
+}
+
+type Near struct {
+	Left, Right Node
+	Distance    int // сколько слов может быть между операндами
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	Node Node
+}
+
+func (Term) positional() bool   { return true }
+func (Phrase) positional() bool { return true }
+func (Near) positional() bool   { return true }
+func (And) positional() bool    { return false }
+func (Or) positional() bool     { return false }
+func (Not) positional() bool    { return false }
+
+// Parse разбирает запрос. analyze разбивает текст на слова так же, как при индексации,
+// иначе слова запроса не совпадут со словами индекса.
+func Parse(input string, analyze func(string) []string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, analyze: analyze}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %s", ErrSyntax, tok)
+	}
+	if len(Terms(node)) > MaxTerms {
+		return nil, fmt.Errorf("%w: too many words, max %d", ErrSyntax, MaxTerms)
+	}
+	return node, nil
+}
+
+// Terms возвращает все разные слова запроса, в том числе под NOT
+func Terms(node Node) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(word string) {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+
+	var walk func(Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case Term:
+			add(n.Word)
+		case Phrase:
+			for _, word := range n.Words {
+				add(word)
+			}
+		case Near:
+			walk(n.Left)
+			walk(n.Right)
+		case And:
+			walk(n.Left)
+			walk(n.Right)
+		case Or:
+			walk(n.Left)
+			walk(n.Right)
+		case Not:
+			walk(n.Node)
+		}
+	}
+	walk(node)
+	return terms
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenPhrase
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenNear
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	distance int // для NEAR/n
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")"})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrSyntax)
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, text: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"`, runes[end]) {
+				end++
+			}
+			tok, err := keyword(string(runes[i:end]))
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = end
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// keyword отличает операторы от обычных слов
+func keyword(text string) (token, error) {
+	switch text {
+	case "AND":
+		return token{kind: tokenAnd, text: text}, nil
+	case "OR":
+		return token{kind: tokenOr, text: text}, nil
+	case "NOT":
+		return token{kind: tokenNot, text: text}, nil
+	case "NEAR":
+		return token{}, fmt.Errorf("%w: NEAR needs a distance, e.g. NEAR/3", ErrSyntax)
+	}
+
+	if rest, ok := strings.CutPrefix(text, "NEAR/"); ok {
+		distance, err := strconv.Atoi(rest)
+		if err != nil || distance < 0 {
+			return token{}, fmt.Errorf("%w: invalid distance in %s", ErrSyntax, text)
+		}
+		return token{kind: tokenNear, text: text, distance: distance}, nil
+	}
+
+	return token{kind: tokenWord, text: text}, nil
+}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	analyze func(string) []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// or := and ("OR" and)*
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// and := unary (["AND"] unary)*
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenWord, tokenPhrase, tokenLParen, tokenNot:
+			// Слова через пробел - тоже AND
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+// unary := "NOT" unary | near
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	}
+	return p.parseNear()
+}
+
+// near := primary ("NEAR/n" primary)*
+func (p *parser) parseNear() (Node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenNear {
+		distance := p.next().distance
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if !left.positional() || !right.positional() {
+			return nil, fmt.Errorf("%w: NEAR works only with words and phrases", ErrSyntax)
+		}
+		left = Near{Left: left, Right: right, Distance: distance}
+	}
+	return left, nil
+}
+
+// primary := "(" or ")" | phrase | word
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("%w: expected ) but got %s", ErrSyntax, closing)
+		}
+		return node, nil
+	case tokenWord, tokenPhrase:
+		return p.words(tok.text)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %s", ErrSyntax, tok)
+	}
+}
+
+// words превращает текст слова или фразы в слова индекса.
+// Одно слово может распасться на несколько ("e-mail") - тогда это фраза.
+func (p *parser) words(text string) (Node, error) {
+	words := p.analyze(text)
+	switch len(words) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s has no searchable words", ErrSyntax, strconv.Quote(text))
+	case 1:
+		return Term{Word: words[0]}, nil
+	default:
+		return Phrase{Words: words}, nil
+	}
+}
@@ -0,0 +1,178 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// analyze - упрощенный токенизатор: слова из букв и цифр в нижнем регистре
+func analyze(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// format записывает дерево запроса со скобками вокруг каждого оператора
+func format(node Node) string {
+	switch n := node.(type) {
+	case Term:
+		return n.Word
+	case Phrase:
+		return `"` + strings.Join(n.Words, " ") + `"`
+	case Near:
+		return fmt.Sprintf("(%s NEAR/%d %s)", format(n.Left), n.Distance, format(n.Right))
+	case And:
+		return fmt.Sprintf("(%s AND %s)", format(n.Left), format(n.Right))
+	case Or:
+		return fmt.Sprintf("(%s OR %s)", format(n.Left), format(n.Right))
+	case Not:
+		return fmt.Sprintf("(NOT %s)", format(n.Node))
+	}
+	return fmt.Sprintf("%#v", node)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`word`, `word`},
+		{`Word`, `word`},
+		{`a b`, `(a AND b)`},
+		{`a AND b AND c`, `((a AND b) AND c)`},
+		{`a OR b OR c`, `((a OR b) OR c)`},
+
+		// Приоритет: NEAR, NOT, AND, OR
+		{`a b OR c`, `((a AND b) OR c)`},
+		{`a OR b c`, `(a OR (b AND c))`},
+		{`a OR b AND c`, `(a OR (b AND c))`},
+		{`NOT a b`, `((NOT a) AND b)`},
+		{`a AND NOT b OR c`, `((a AND (NOT b)) OR c)`},
+		{`NOT NOT a`, `(NOT (NOT a))`},
+		{`a NEAR/2 b c`, `((a NEAR/2 b) AND c)`},
+		{`NOT a NEAR/1 b`, `(NOT (a NEAR/1 b))`},
+		{`a NEAR/1 b NEAR/2 c`, `((a NEAR/1 b) NEAR/2 c)`},
+		{`a OR b NEAR/3 c`, `(a OR (b NEAR/3 c))`},
+
+		// Скобки
+		{`(a OR b) c`, `((a OR b) AND c)`},
+		{`a (b OR c)`, `(a AND (b OR c))`},
+		{`NOT (a OR b)`, `(NOT (a OR b))`},
+		{`((a))`, `a`},
+		{`(a (b OR (c d)))`, `(a AND (b OR (c AND d)))`},
+
+		// Фразы
+		{`"New York"`, `"new york"`},
+		{`"new york" city`, `("new york" AND city)`},
+		{`"new york"OR"los angeles"`, `("new york" OR "los angeles")`},
+		{`"single"`, `single`},
+		{`e-mail`, `"e mail"`},
+		{`"new york" NEAR/3 "los angeles"`, `("new york" NEAR/3 "los angeles")`},
+
+		// Операторы - только заглавными
+		{`a and b`, `((a AND and) AND b)`},
+		{`a or b`, `((a AND or) AND b)`},
+		{`not a`, `(not AND a)`},
+		{`near/3`, `"near 3"`},
+		{`a NEAR/0 b`, `(a NEAR/0 b)`},
+		{"  a\tOR\nb  ", `(a OR b)`},
+	}
+
+	for _, tt := range tests {
+		node, err := Parse(tt.input, analyze)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if got := format(node); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`   `,
+		`"unterminated`,
+		`a "b c`,
+		`(a OR b`,
+		`((a)`,
+		`a OR b)`,
+		`)`,
+		`()`,
+		`a OR`,
+		`a AND`,
+		`OR a`,
+		`AND a`,
+		`NOT`,
+		`a NOT`,
+		`a NEAR/3`,
+		`NEAR/3 a`,
+		`a NEAR b`,
+		`a NEAR/ b`,
+		`a NEAR/x b`,
+		`a NEAR/-1 b`,
+		`(a OR b) NEAR/2 c`,
+		`a NEAR/2 (b c)`,
+		`NOT a NEAR/2 b NEAR/1 (NOT c)`,
+		`""`,
+		`!!!`,
+		`a ... b`,
+		manyWords(MaxTerms + 1),
+	}
+
+	for _, input := range tests {
+		node, err := Parse(input, analyze)
+		if err == nil {
+			t.Errorf("Parse(%q) = %s, want error", input, format(node))
+			continue
+		}
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) error %v is not ErrSyntax", input, err)
+		}
+	}
+}
+
+func TestParseMaxTerms(t *testing.T) {
+	if _, err := Parse(manyWords(MaxTerms), analyze); err != nil {
+		t.Errorf("%d words: %v", MaxTerms, err)
+	}
+	// Повторы одного слова считаются один раз
+	if _, err := Parse(strings.Repeat("same ", MaxTerms*2), analyze); err != nil {
+		t.Errorf("repeated word: %v", err)
+	}
+}
+
+// Любой обрывок запроса разбирается без паники: либо дерево, либо ErrSyntax
+func TestParsePrefixesDoNotPanic(t *testing.T) {
+	full := []rune(`NOT ("new york" OR city) NEAR/2 dog AND (e-mail OR "a b" NEAR/1 c) OR x`)
+	for i := 0; i <= len(full); i++ {
+		for _, input := range []string{string(full[:i]), string(full[i:])} {
+			if _, err := Parse(input, analyze); err != nil && !errors.Is(err, ErrSyntax) {
+				t.Errorf("Parse(%q) error %v is not ErrSyntax", input, err)
+			}
+		}
+	}
+}
+
+func TestTerms(t *testing.T) {
+	node, err := Parse(`a "b c" OR NOT (d NEAR/2 a) b`, analyze)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(Terms(node), ","); got != "a,b,c,d" {
+		t.Errorf("Terms = %s, want a,b,c,d", got)
+	}
+}
+
+func manyWords(n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("w%d", i)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ClassifierRoute(r *gin.Engine) {
+	classifierController := controllers.NewClassifierController(database.DB)
+
+	protected := r.Group("/classifiers")
+	protected.Use(middleware.AuthMiddleware)
+	{
+		protected.POST("/", classifierController.CreateClassifier)
+		protected.GET("/", classifierController.GetClassifiers)
+		protected.GET("/:classifier_id", classifierController.GetClassifierByID)
+		protected.PATCH("/:classifier_id", classifierController.UpdateClassifier)
+		protected.DELETE("/:classifier_id", classifierController.DeleteClassifier)
+		protected.POST("/:classifier_id/train", classifierController.TrainClassifier)
+		protected.POST("/:classifier_id/predict", classifierController.Predict)
+	}
+}
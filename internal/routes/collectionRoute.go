@@ -23,5 +23,8 @@ func CollectionRoute(r *gin.Engine) {
 		protected.DELETE("/:collection_id/:document_id", collectionController.DeleteDocumentFromCollection)
 		protected.POST("/add-many", collectionController.AddDocumentToCollections)
 		protected.GET("/:collection_id/statistics", collectionController.GetCollectionStatistics)
+		protected.GET("/:collection_id/concordance", collectionController.GetCollectionConcordance)
+		protected.GET("/:collection_id/vocabulary", collectionController.GetCollectionVocabulary)
+		protected.GET("/:collection_id/trends", collectionController.GetCollectionTrends)
 	}
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CompareRoute(r *gin.Engine) {
+	compareController := controllers.NewCompareController(database.DB)
+
+	r.GET("/compare", middleware.AuthMiddleware, compareController.Compare)
+}
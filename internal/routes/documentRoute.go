@@ -15,9 +15,22 @@ func DocumentRoute(r *gin.Engine) {
 	protected.Use(middleware.AuthMiddleware)
 	{
 		protected.GET("/", documentController.GetDocuments)
+		protected.GET("/search", documentController.SearchDocuments)
+		protected.POST("/from-text", documentController.CreateDocumentFromText)
+		protected.POST("/from-url", documentController.CreateDocumentFromURL)
 		protected.GET("/:document_id", documentController.GetDocumentByID)
+		protected.PATCH("/:document_id", documentController.UpdateDocument)
 		protected.DELETE("/:document_id", documentController.DeleteDocument)
+		protected.GET("/:document_id/download", documentController.DownloadDocument)
+		protected.HEAD("/:document_id/download", documentController.DownloadDocument)
 		protected.GET("/:document_id/statistics", documentController.GetDocumentStatistics)
 		protected.GET("/:document_id/huffman", documentController.GetDocumentHuffman)
+		protected.GET("/:document_id/highlight", documentController.GetDocumentHighlight)
+		protected.GET("/:document_id/summary", documentController.GetDocumentSummary)
+		protected.GET("/:document_id/concordance", documentController.GetDocumentConcordance)
+		protected.PUT("/:document_id/content", documentController.ReplaceDocumentContent)
+		protected.GET("/:document_id/versions", documentController.GetDocumentVersions)
+		protected.GET("/:document_id/versions/diff", documentController.GetDocumentVersionsDiff)
+		protected.GET("/:document_id/versions/:version", documentController.GetDocumentVersion)
 	}
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func EventRoute(r *gin.Engine) {
+	eventController := controllers.NewEventController()
+
+	r.GET("/events", middleware.AuthMiddleware, eventController.StreamEvents)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TermRoute(r *gin.Engine) {
+	termController := controllers.NewTermController(database.DB)
+
+	protected := r.Group("/terms")
+	protected.Use(middleware.AuthMiddleware)
+	{
+		protected.GET("/:term", termController.GetTerm)
+	}
+}
@@ -2,15 +2,23 @@ package routes
 
 import (
 	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
 	"tfidf-app/internal/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
 func UploadRoute(r *gin.Engine) {
+	uploadSessionController := controllers.NewUploadSessionController(database.DB)
+
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware)
 	{
 		protected.POST("/upload", controllers.HandleFileUpload)
+
+		// Докачиваемые загрузки по протоколу tus
+		protected.POST("/uploads", uploadSessionController.CreateUpload)
+		protected.PATCH("/uploads/:upload_id", uploadSessionController.UploadChunk)
+		protected.HEAD("/uploads/:upload_id", uploadSessionController.GetUploadOffset)
 	}
 }
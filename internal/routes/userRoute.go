@@ -20,6 +20,7 @@ func UserRoutes(r *gin.Engine) {
 	userGroup.Use(middleware.AuthMiddleware)
 	{
 		userGroup.GET("/me", userController.GetMe)
+		userGroup.GET("/me/storage", userController.GetMyStorage)
 		userGroup.PATCH("/:user_id", userController.UpdateUser)
 		userGroup.DELETE("/:user_id", userController.DeleteUser)
 	}
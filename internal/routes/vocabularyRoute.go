@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func VocabularyRoute(r *gin.Engine) {
+	vocabularyController := controllers.NewVocabularyController(database.DB)
+
+	protected := r.Group("/vocabulary")
+	protected.Use(middleware.AuthMiddleware)
+	{
+		protected.GET("/suggest", vocabularyController.SuggestTerms)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WatchRoute(r *gin.Engine) {
+	watchController := controllers.NewWatchController(database.DB)
+
+	protected := r.Group("/watches")
+	protected.Use(middleware.AuthMiddleware)
+	{
+		protected.POST("/", watchController.CreateWatch)
+		protected.GET("/", watchController.GetWatches)
+		protected.GET("/:watch_id", watchController.GetWatchByID)
+		protected.DELETE("/:watch_id", watchController.DeleteWatch)
+		protected.GET("/:watch_id/alerts", watchController.GetWatchAlerts)
+		protected.GET("/:watch_id/deliveries", watchController.GetWatchDeliveries)
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"tfidf-app/internal/controllers"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WebhookRoute(r *gin.Engine) {
+	webhookController := controllers.NewWebhookController(database.DB)
+
+	protected := r.Group("/webhooks")
+	protected.Use(middleware.AuthMiddleware)
+	{
+		protected.POST("/", webhookController.CreateWebhook)
+		protected.GET("/", webhookController.GetWebhooks)
+		protected.GET("/:webhook_id", webhookController.GetWebhookByID)
+		protected.PATCH("/:webhook_id", webhookController.UpdateWebhook)
+		protected.DELETE("/:webhook_id", webhookController.DeleteWebhook)
+		protected.GET("/:webhook_id/deliveries", webhookController.GetWebhookDeliveries)
+	}
+}
@@ -1,11 +1,10 @@
 package services
 
 import (
+	"context"
+	"log"
 	"math"
-	"os"
-	"regexp"
 	"sort"
-	"strings"
 	"tfidf-app/internal/database"
 	"tfidf-app/internal/models"
 )
@@ -17,10 +16,10 @@ type WordStat struct {
 	IDF   float64
 }
 
-func ComputeTFIDFForUpload(words []string) []WordStat {
-	wordCount := CountWords(words)
+func ComputeTFIDFForUpload(counts WordCounts) []WordStat {
+	wordCount := counts.Counts
 
-	totalWords := len(words)
+	totalWords := counts.Total
 
 	stats := make([]WordStat, 0, len(wordCount))
 	for w, count := range wordCount {
@@ -43,19 +42,55 @@ func ComputeTFIDFForUpload(words []string) []WordStat {
 	return stats
 }
 
-func ProcessFile(filePath string) ([]string, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-	text := string(data)
-	text = strings.ToLower(text)
+// Подсчет слов одного документа, в таком виде он и кэшируется
+type WordCounts struct {
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+func ExtractWords(data []byte) []string {
+	var words []string
+	tokenizer := NewTokenizer(func(token Token) {
+		words = append(words, token.Word)
+	})
+	tokenizer.Write(data)
+	tokenizer.Close()
+	return words
+}
+
+// CountDocumentWords отдает подсчет слов документа из кэша по хэшу содержимого, файл читается только при промахе
+func CountDocumentWords(ctx context.Context, document models.Document) (WordCounts, error) {
+	return GetOrCompute(DocumentCache, document.ContentHash, CacheKindWordCounts, func() (WordCounts, error) {
+		content, err := ReadDocumentContent(ctx, document)
+		if err != nil {
+			return WordCounts{}, err
+		}
+		return countContentWords(content), nil
+	})
+}
+
+// CountVersionWords - то же для версии документа
+func CountVersionWords(ctx context.Context, version models.DocumentVersion) (WordCounts, error) {
+	return GetOrCompute(DocumentCache, version.ContentHash, CacheKindWordCounts, func() (WordCounts, error) {
+		content, err := ReadVersionContent(ctx, version)
+		if err != nil {
+			return WordCounts{}, err
+		}
+		return countContentWords(content), nil
+	})
+}
 
-	reg := regexp.MustCompile(`[^a-zA-Zа-яА-Я]+`)
-	cleaned := reg.ReplaceAllString(text, " ")
+// CountContentWords отдает подсчет слов уже прочитанного содержимого с хэшем hash через кэш
+func CountContentWords(hash string, content []byte) WordCounts {
+	counts, _ := GetOrCompute(DocumentCache, hash, CacheKindWordCounts, func() (WordCounts, error) {
+		return countContentWords(content), nil
+	})
+	return counts
+}
 
-	words := strings.Fields(cleaned)
-	return words, nil
+func countContentWords(content []byte) WordCounts {
+	words := ExtractWords(content)
+	return WordCounts{Counts: CountWords(words), Total: len(words)}
 }
 
 func GetAllCollectionDocuments(collections []*models.Collection) ([]models.Document, error) {
@@ -138,3 +173,93 @@ func GetRarestWords(wordCount map[string]int, limit int) []WordStat {
 	}
 	return stats
 }
+
+// CorpusWordCounts считает слова каждого документа корпуса (через кэш).
+// Нечитаемые документы пропускаются, чтобы один битый файл не ломал статистику.
+func CorpusWordCounts(ctx context.Context, documents []models.Document) []map[string]int {
+	corpus := make([]map[string]int, 0, len(documents))
+	for _, doc := range documents {
+		counts, err := CountDocumentWords(ctx, doc)
+		if err != nil {
+			log.Printf("Failed to process file %s: %v", doc.StorageKey, err)
+			continue
+		}
+		corpus = append(corpus, counts.Counts)
+	}
+	return corpus
+}
+
+// DocumentCorpus - документы, относительно которых считается IDF документа:
+// все документы его коллекций, а если он ни в одной - вся библиотека пользователя
+func DocumentCorpus(document models.Document) ([]models.Document, error) {
+	var collections []*models.Collection
+	if err := database.DB.Model(&document).Association("Collections").Find(&collections); err != nil {
+		return nil, err
+	}
+	if len(collections) > 0 {
+		return GetAllCollectionDocuments(collections)
+	}
+
+	var documents []models.Document
+	if err := database.DB.Where("user_id = ?", document.UserID).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// IDFValue - IDF слова, а для слова, которого нет в корпусе, как будто оно встретилось в одном новом документе
+func IDFValue(idf map[string]float64, word string, corpusSize int) float64 {
+	if value, exists := idf[word]; exists {
+		return value
+	}
+	return math.Log(float64(corpusSize + 1))
+}
+
+// TFIDFProfile - вес TF-IDF каждого слова документа
+func TFIDFProfile(counts WordCounts, idf map[string]float64, corpusSize int) map[string]float64 {
+	profile := make(map[string]float64, len(counts.Counts))
+	for word, tf := range CalculateTF(counts.Counts, counts.Total) {
+		profile[word] = tf * IDFValue(idf, word, corpusSize)
+	}
+	return profile
+}
+
+// Изменение веса слова между двумя профилями TF-IDF
+type TermDiff struct {
+	Word   string  `json:"word"`
+	From   float64 `json:"from"`
+	To     float64 `json:"to"`
+	Delta  float64 `json:"delta"`
+	Status string  `json:"status"` // added, removed, changed
+}
+
+// DiffProfiles сравнивает профили по словам, самые сильные изменения идут первыми
+func DiffProfiles(from, to map[string]float64) []TermDiff {
+	diffs := make([]TermDiff, 0)
+
+	for word, toValue := range to {
+		fromValue, exists := from[word]
+		status := "changed"
+		if !exists {
+			status = "added"
+		}
+		if exists && fromValue == toValue {
+			continue
+		}
+		diffs = append(diffs, TermDiff{Word: word, From: fromValue, To: toValue, Delta: toValue - fromValue, Status: status})
+	}
+	for word, fromValue := range from {
+		if _, exists := to[word]; !exists {
+			diffs = append(diffs, TermDiff{Word: word, From: fromValue, Delta: -fromValue, Status: "removed"})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		di, dj := math.Abs(diffs[i].Delta), math.Abs(diffs[j].Delta)
+		if di == dj {
+			return diffs[i].Word < diffs[j].Word
+		}
+		return di > dj
+	})
+	return diffs
+}
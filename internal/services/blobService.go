@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewBlobKey - новый ключ blob'а в хранилище, раскладываем по подпапкам из первых двух символов хэша.
+// Случайный суффикс делает ключ своим у каждой попытки записи: параллельная загрузка того же
+// содержимого не перезапишет наш файл, а откат нашей транзакции не удалит файл, на который
+// ссылается чужой закоммиченный blob.
+func NewBlobKey(hash string) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("blobs/%s/%s-%s", hash[:2], hash, hex.EncodeToString(suffix)), nil
+}
+
+// AcquireBlob берет ссылку на blob с таким содержимым, а если его еще нет - сохраняет.
+// created = true значит файл был записан сейчас, и при откате транзакции его надо удалить.
+func AcquireBlob(ctx context.Context, tx *gorm.DB, staged *StagedContent) (blob models.Blob, created bool, err error) {
+	hash := staged.Hash
+
+	blob, err = retainBlob(tx, hash)
+	if err == nil {
+		return blob, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Blob{}, false, err
+	}
+
+	key, err := NewBlobKey(hash)
+	if err != nil {
+		return models.Blob{}, false, err
+	}
+	compression, storedSize, err := SaveStagedContent(ctx, key, staged)
+	if err != nil {
+		return models.Blob{}, false, err
+	}
+
+	blob = models.Blob{
+		Hash:        hash,
+		StorageKey:  key,
+		Compression: compression,
+		Size:        staged.Size,
+		StoredSize:  storedSize,
+		RefCount:    1,
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blob)
+	if result.Error != nil {
+		return models.Blob{}, true, result.Error
+	}
+
+	// Параллельная загрузка того же содержимого успела создать запись раньше нас.
+	// Берем ее blob, а наш файл под своим ключом никому не нужен.
+	if result.RowsAffected == 0 {
+		if delErr := storage.Files.Delete(ctx, key); delErr != nil {
+			log.Printf("Failed to delete duplicate blob file %s: %v", key, delErr)
+		}
+		blob, err = retainBlob(tx, hash)
+		return blob, false, err
+	}
+
+	return blob, true, nil
+}
+
+// retainBlob увеличивает счетчик ссылок существующего blob'а
+func retainBlob(tx *gorm.DB, hash string) (models.Blob, error) {
+	var blob models.Blob
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return models.Blob{}, err
+	}
+
+	blob.RefCount++
+	if err := tx.Model(&blob).Update("ref_count", blob.RefCount).Error; err != nil {
+		return models.Blob{}, err
+	}
+	return blob, nil
+}
+
+// ReleaseBlob отпускает ссылку на blob. last = true значит ссылок не осталось,
+// запись удалена и после коммита нужно вызвать DeleteBlobContent.
+func ReleaseBlob(tx *gorm.DB, hash string) (blob models.Blob, last bool, err error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return models.Blob{}, false, err
+	}
+
+	if blob.RefCount > 1 {
+		blob.RefCount--
+		return blob, false, tx.Model(&blob).Update("ref_count", blob.RefCount).Error
+	}
+
+	if err := DeleteContentIndex(tx, hash); err != nil {
+		return models.Blob{}, false, err
+	}
+	return blob, true, tx.Delete(&blob).Error
+}
+
+// DeleteBlobContent удаляет файл blob'а из хранилища и кэш по его содержимому
+func DeleteBlobContent(ctx context.Context, blob models.Blob) error {
+	DocumentCache.Invalidate(blob.Hash)
+	return storage.Files.Delete(ctx, blob.StorageKey)
+}
+
+// ReleaseDocumentContent отпускает содержимое всех версий удаляемого документа внутри транзакции.
+// Возвращает функцию, которую нужно вызвать после коммита, чтобы удалить ненужные файлы.
+func ReleaseDocumentContent(tx *gorm.DB, document models.Document) (func(ctx context.Context) error, error) {
+	// Документ без хэша еще не переведен на blob'ы, файл принадлежит только ему
+	if document.ContentHash == "" {
+		return func(ctx context.Context) error {
+			return DeleteDocumentContent(ctx, document)
+		}, nil
+	}
+
+	var versions []models.DocumentVersion
+	if err := tx.Where("document_id = ?", document.ID).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+
+	// Ссылки держат версии, а до их появления - сам документ
+	hashes := []string{document.ContentHash}
+	if len(versions) > 0 {
+		hashes = hashes[:0]
+		for _, version := range versions {
+			hashes = append(hashes, version.ContentHash)
+		}
+	}
+
+	var unused []models.Blob
+	for _, hash := range hashes {
+		blob, last, err := ReleaseBlob(tx, hash)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			unused = append(unused, blob)
+		}
+	}
+
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, blob := range unused {
+			errs = append(errs, DeleteBlobContent(ctx, blob))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// BackfillContentHashes переводит документы, загруженные до дедупликации, на blob'ы:
+// считает хэш, и если такое содержимое уже есть - удаляет собственную копию файла.
+func BackfillContentHashes(ctx context.Context) {
+	var documents []models.Document
+	if err := database.DB.Where("content_hash = '' OR content_hash IS NULL").Find(&documents).Error; err != nil {
+		log.Printf("Failed to get documents without content hash: %v", err)
+		return
+	}
+
+	for _, document := range documents {
+		content, err := ReadDocumentContent(ctx, document)
+		if err != nil {
+			log.Printf("Failed to read document %d for hashing: %v", document.ID, err)
+			continue
+		}
+		hash := HashContent(content)
+
+		var reused bool
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			blob, err := retainBlob(tx, hash)
+			switch {
+			case err == nil:
+				reused = true
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// Существующий файл документа становится blob'ом как есть
+				storedSize := document.StoredSize
+				if storedSize == 0 && document.Compression == CompressionNone {
+					storedSize = int64(len(content))
+				}
+				blob = models.Blob{
+					Hash:        hash,
+					StorageKey:  document.StorageKey,
+					Compression: document.Compression,
+					Size:        int64(len(content)),
+					StoredSize:  storedSize,
+					RefCount:    1,
+				}
+				if err := tx.Create(&blob).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+
+			return tx.Model(&document).Updates(map[string]any{
+				"content_hash": hash,
+				"storage_key":  blob.StorageKey,
+				"compression":  blob.Compression,
+				"size":         blob.Size,
+				"stored_size":  blob.StoredSize,
+			}).Error
+		})
+		if err != nil {
+			log.Printf("Failed to backfill content hash for document %d: %v", document.ID, err)
+			continue
+		}
+
+		if reused {
+			if err := storage.Files.Delete(ctx, document.StorageKey); err != nil {
+				log.Printf("Failed to delete duplicate file %s: %v", document.StorageKey, err)
+			}
+		}
+	}
+
+	if len(documents) > 0 {
+		log.Printf("INFO: Content hashes backfilled for %d documents.", len(documents))
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewBlobKeyIsUniquePerAttempt(t *testing.T) {
+	hash := HashContent([]byte("одно и то же содержимое"))
+
+	first, err := NewBlobKey(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewBlobKey(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Errorf("two attempts got the same key %s", first)
+	}
+	for _, key := range []string{first, second} {
+		if !strings.HasPrefix(key, "blobs/"+hash[:2]+"/"+hash+"-") {
+			t.Errorf("key %s is not under blobs/%s/%s", key, hash[:2], hash)
+		}
+	}
+}
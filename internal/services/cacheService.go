@@ -0,0 +1,299 @@
+package services
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"tfidf-app/internal/config"
+	"time"
+)
+
+// Виды закэшированных результатов для одного и того же содержимого
+const (
+	CacheKindHuffman    = "huffman"
+	CacheKindWordCounts = "wordcounts"
+)
+
+// ContentCache - кэш, адресуемый по SHA-256 содержимого документа.
+// Спереди LRU в памяти, сзади JSON-файлы на диске, поэтому после рестарта
+// результаты не пересчитываются. Память ограничена и числом записей, и байтами,
+// диск - байтами: при переполнении удаляются давно не читанные файлы.
+type ContentCache struct {
+	mu       sync.Mutex
+	dir      string
+	capacity int   // записей в памяти
+	maxBytes int64 // байт в памяти, размер записи - длина ее JSON
+	bytes    int64
+	order    *list.List // фронт - самые свежие записи
+	items    map[string]*list.Element
+
+	diskMu       sync.Mutex
+	diskMaxBytes int64 // 0 - без ограничения
+	diskBytes    int64 // -1 - еще не подсчитано
+}
+
+type cacheEntry struct {
+	key   string
+	value any
+	size  int64
+}
+
+var DocumentCache = NewContentCache(config.Init.CacheDir, config.Init.CacheSize, config.Init.CacheMemoryBytes, config.Init.CacheDiskBytes)
+
+func NewContentCache(dir string, capacity int, maxBytes, diskMaxBytes int64) *ContentCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &ContentCache{
+		dir:          dir,
+		capacity:     capacity,
+		maxBytes:     maxBytes,
+		order:        list.New(),
+		items:        make(map[string]*list.Element),
+		diskMaxBytes: diskMaxBytes,
+		diskBytes:    -1,
+	}
+}
+
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetOrCompute отдает значение из памяти, затем с диска, и только потом считает его заново.
+// Возвращаемое значение общее для всех вызывающих, менять его нельзя.
+// hash берется из базы (ContentHash документа или версии), поэтому при попадании файл не читается вовсе.
+func GetOrCompute[T any](c *ContentCache, hash, kind string, compute func() (T, error)) (T, error) {
+	// Хэш еще не посчитан (до BackfillContentHashes) - кэшировать не под чем
+	if hash == "" {
+		return compute()
+	}
+	key := hash + "." + kind
+
+	if value, ok := c.getMemory(key); ok {
+		if typed, ok := value.(T); ok {
+			return typed, nil
+		}
+	}
+
+	var value T
+	if data, ok := c.readDisk(hash, key); ok && json.Unmarshal(data, &value) == nil {
+		c.setMemory(key, value, int64(len(data)))
+		return value, nil
+	}
+
+	value, err := compute()
+	if err != nil {
+		return value, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode cache entry %s: %v", key, err)
+		return value, nil
+	}
+	c.setMemory(key, value, int64(len(data)))
+	c.writeDisk(hash, key, data)
+	return value, nil
+}
+
+// Invalidate удаляет все результаты для содержимого с данным хэшем
+func (c *ContentCache) Invalidate(hash string) {
+	c.mu.Lock()
+	for key, el := range c.items {
+		if strings.HasPrefix(key, hash+".") {
+			c.removeElement(el)
+		}
+	}
+	c.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(c.shardDir(hash), hash+".*"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		info, statErr := os.Stat(f)
+		if err := os.Remove(f); err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("Failed to remove cache file %s: %v", f, err)
+			}
+			continue
+		}
+		if statErr == nil {
+			c.addDiskBytes(-info.Size())
+		}
+	}
+}
+
+func (c *ContentCache) getMemory(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).value, true
+}
+
+// setMemory кладет значение размером size байт. Запись больше всего лимита в памяти не держим
+func (c *ContentCache) setMemory(key string, value any, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value, size: size})
+	c.bytes += size
+
+	for c.order.Len() > c.capacity || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+	}
+}
+
+func (c *ContentCache) removeElement(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	c.order.Remove(el)
+	delete(c.items, entry.key)
+	c.bytes -= entry.size
+}
+
+// Файлы раскладываются по подпапкам из первых двух символов хэша
+func (c *ContentCache) shardDir(hash string) string {
+	return filepath.Join(c.dir, hash[:2])
+}
+
+func (c *ContentCache) readDisk(hash, key string) ([]byte, bool) {
+	path := filepath.Join(c.shardDir(hash), key+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// Время изменения - время последнего чтения: при переполнении диска файлы удаляются от самых старых
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+func (c *ContentCache) writeDisk(hash, key string, data []byte) {
+	if c.diskMaxBytes > 0 && int64(len(data)) > c.diskMaxBytes {
+		return
+	}
+
+	dir := c.shardDir(hash)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		log.Printf("Failed to create cache folder: %v", err)
+		return
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы не читать недописанный json
+	tmp, err := os.CreateTemp(dir, key+".*.tmp")
+	if err != nil {
+		log.Printf("Failed to create cache file: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.Printf("Failed to write cache file: %v", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.Printf("Failed to write cache file: %v", err)
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, key+".json")); err != nil {
+		log.Printf("Failed to save cache file: %v", err)
+		return
+	}
+	c.addDiskBytes(int64(len(data)))
+}
+
+// addDiskBytes учитывает записанные (или удаленные) байты и чистит диск, если кэш вырос больше лимита
+func (c *ContentCache) addDiskBytes(delta int64) {
+	if c.diskMaxBytes <= 0 {
+		return
+	}
+
+	c.diskMu.Lock()
+	defer c.diskMu.Unlock()
+
+	// Сколько уже занято с прошлых запусков, узнаем при первой записи
+	if c.diskBytes < 0 {
+		files, _ := c.diskFiles()
+		c.diskBytes = 0
+		for _, f := range files {
+			c.diskBytes += f.size
+		}
+	} else {
+		c.diskBytes += delta
+	}
+
+	if c.diskBytes > c.diskMaxBytes {
+		c.evictDisk()
+	}
+}
+
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+func (c *ContentCache) diskFiles() ([]cacheFile, error) {
+	var files []cacheFile
+	err := filepath.WalkDir(c.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// evictDisk удаляет давно не читанные файлы, пока кэш не займет 90% лимита:
+// запас, чтобы не обходить папку на каждой следующей записи. Заодно пересчитывает занятое место -
+// счетчик мог разойтись с диском, например при перезаписи уже существующего файла.
+func (c *ContentCache) evictDisk() {
+	files, err := c.diskFiles()
+	if err != nil {
+		log.Printf("Failed to list cache files: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	c.diskBytes = 0
+	for _, f := range files {
+		c.diskBytes += f.size
+	}
+
+	target := c.diskMaxBytes / 10 * 9
+	for _, f := range files {
+		if c.diskBytes <= target {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove cache file %s: %v", f.path, err)
+			continue
+		}
+		c.diskBytes -= f.size
+	}
+}
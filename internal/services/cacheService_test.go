@@ -0,0 +1,129 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hashOf - правдоподобный хэш для ключа кэша
+func hashOf(s string) string {
+	return HashContent([]byte(s))
+}
+
+func TestContentCacheMemoryBytesLimit(t *testing.T) {
+	cache := NewContentCache(t.TempDir(), 100, 1000, 0)
+
+	compute := func(value string) func() (string, error) {
+		return func() (string, error) { return value, nil }
+	}
+	// JSON строки из 300 символов - 302 байта, в 1000 байт помещаются три
+	for _, name := range []string{"a", "b", "c", "d"} {
+		GetOrCompute(cache, hashOf(name), "test", compute(strings.Repeat(name, 300)))
+	}
+
+	if cache.order.Len() != 3 || cache.bytes != 3*302 {
+		t.Errorf("%d entries, %d bytes in memory; want 3 and %d", cache.order.Len(), cache.bytes, 3*302)
+	}
+	if _, ok := cache.getMemory(hashOf("a") + ".test"); ok {
+		t.Error("the oldest entry was not evicted")
+	}
+
+	// Запись больше всего лимита в память не попадает, но считается и лежит на диске
+	big := strings.Repeat("x", 2000)
+	if got, _ := GetOrCompute(cache, hashOf("big"), "test", compute(big)); got != big {
+		t.Error("big value was not returned")
+	}
+	if _, ok := cache.getMemory(hashOf("big") + ".test"); ok {
+		t.Error("value over the memory limit was kept in memory")
+	}
+	if got, _ := GetOrCompute(cache, hashOf("big"), "test", compute("recomputed")); got != big {
+		t.Errorf("big value was not read from disk: %q", got[:min(len(got), 20)])
+	}
+	if cache.bytes > 1000 {
+		t.Errorf("%d bytes in memory, limit 1000", cache.bytes)
+	}
+}
+
+func TestContentCacheDiskBytesLimit(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewContentCache(dir, 1, 0, 1000)
+	value := strings.Repeat("v", 198) // 200 байт JSON
+
+	names := []string{"a", "b", "c", "d", "e"}
+	for i, name := range names {
+		GetOrCompute(cache, hashOf(name), "test", func() (string, error) { return value, nil })
+		// Разное время записи, чтобы порядок вытеснения не зависел от точности часов ФС
+		path := filepath.Join(cache.shardDir(hashOf(name)), hashOf(name)+".test.json")
+		stamp := time.Now().Add(time.Duration(i-len(names)) * time.Minute)
+		os.Chtimes(path, stamp, stamp)
+	}
+
+	// Чтение с диска освежает файл "a"
+	GetOrCompute(cache, hashOf("a"), "test", func() (string, error) { return "", nil })
+
+	// Шестая запись переполняет 1000 байт: удаляются самые давно читанные, пока не останется 900
+	GetOrCompute(cache, hashOf("f"), "test", func() (string, error) { return value, nil })
+
+	files, err := cache.diskFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	kept := make(map[string]bool)
+	for _, f := range files {
+		total += f.size
+		kept[strings.SplitN(filepath.Base(f.path), ".", 2)[0]] = true
+	}
+	if total > 900 || cache.diskBytes != total {
+		t.Errorf("%d bytes on disk, counter %d; want at most 900", total, cache.diskBytes)
+	}
+	for name, want := range map[string]bool{"a": true, "b": false, "c": false, "f": true} {
+		if kept[hashOf(name)] != want {
+			t.Errorf("%s kept = %v, want %v", name, !want, want)
+		}
+	}
+}
+
+func TestContentCacheInvalidate(t *testing.T) {
+	cache := NewContentCache(t.TempDir(), 10, 0, 1<<20)
+	hash := hashOf("content")
+	GetOrCompute(cache, hash, CacheKindWordCounts, func() (int, error) { return 1, nil })
+	GetOrCompute(cache, hash, CacheKindSentences, func() (int, error) { return 2, nil })
+
+	cache.Invalidate(hash)
+	if cache.order.Len() != 0 || cache.bytes != 0 || cache.diskBytes != 0 {
+		t.Errorf("after Invalidate: %d entries, %d bytes in memory, %d on disk", cache.order.Len(), cache.bytes, cache.diskBytes)
+	}
+	if got, _ := GetOrCompute(cache, hash, CacheKindWordCounts, func() (int, error) { return 3, nil }); got != 3 {
+		t.Errorf("value was not recomputed: %d", got)
+	}
+}
+
+func TestContentCacheWithoutHash(t *testing.T) {
+	cache := NewContentCache(t.TempDir(), 10, 0, 0)
+	calls := 0
+	for range 2 {
+		GetOrCompute(cache, "", "test", func() (int, error) { calls++; return calls, nil })
+	}
+	if calls != 2 || cache.order.Len() != 0 {
+		t.Errorf("content without hash: %d computations, %d entries", calls, cache.order.Len())
+	}
+}
+
+func TestPackHuffmanBits(t *testing.T) {
+	for _, bits := range []string{"", "0", "1", "10110010", "101100101", strings.Repeat("01", 37)} {
+		code := PackHuffmanBits(bits)
+		if len(code.Data) != (len(bits)+7)/8 {
+			t.Errorf("%q: packed into %d bytes", bits, len(code.Data))
+		}
+		if got := code.String(); got != bits {
+			t.Errorf("PackHuffmanBits(%q).String() = %q", bits, got)
+		}
+	}
+	if code := PackHuffmanBits("10110010"); code.Data[0] != 0xb2 {
+		t.Errorf("first bit must be the high one: %#x", code.Data[0])
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"tfidf-app/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Меньше двух коллекций - не из чего выбирать, больше - модель становится слишком тяжелой
+	MinClassifierLabels = 2
+	MaxClassifierLabels = 50
+	// Сглаживание Лапласа: слово, не встречавшееся в метке, не обнуляет ее вероятность
+	laplaceAlpha = 1.0
+)
+
+var (
+	ErrTooFewLabels  = errors.New("classifier needs at least two collections")
+	ErrTooManyLabels = errors.New("too many collections for a classifier")
+	ErrEmptyLabel    = errors.New("collection has no indexed documents")
+)
+
+// LabelProbability - вероятность того, что документ относится к коллекции
+type LabelProbability struct {
+	CollectionID uint    `json:"collection_id"`
+	Name         string  `json:"name"`
+	Probability  float64 `json:"probability"`
+	LogScore     float64 `json:"log_score"` // ненормированный логарифм P(метка) * P(слова | метка)
+}
+
+// ClassifierPrediction - результат классификации, метки по убыванию вероятности
+type ClassifierPrediction struct {
+	ClassifierID uint               `json:"classifier_id"`
+	Label        LabelProbability   `json:"label"`        // самая вероятная метка
+	Tokens       int                `json:"tokens"`       // слов в документе
+	KnownTokens  int                `json:"known_tokens"` // из них встречались при обучении, остальные не учитываются
+	Labels       []LabelProbability `json:"labels"`
+}
+
+// TrainClassifier обучает мультиномиальный наивный байесовский классификатор на коллекциях
+// и сохраняет его. У уже сохраненного классификатора метки заменяются новыми.
+// Частоты слов берутся из позиционного индекса, файлы документов не читаются.
+func TrainClassifier(db *gorm.DB, classifier *models.Classifier, collections []models.Collection) error {
+	if len(collections) < MinClassifierLabels {
+		return ErrTooFewLabels
+	}
+	if len(collections) > MaxClassifierLabels {
+		return ErrTooManyLabels
+	}
+
+	ids := make([]uint, 0, len(collections))
+	labels := make(map[uint]*models.ClassifierLabel, len(collections))
+	for _, collection := range collections {
+		ids = append(ids, collection.ID)
+		labels[collection.ID] = &models.ClassifierLabel{
+			CollectionID: collection.ID,
+			Name:         collection.Name,
+			Counts:       models.TermCounts{},
+		}
+	}
+
+	// Число документов в коллекциях - для априорных вероятностей
+	var sizes []struct {
+		CollectionID uint
+		Documents    int
+	}
+	if err := db.Table("collection_documents").
+		Select("collection_id, COUNT(*) AS documents").
+		Where("collection_id IN ?", ids).
+		Group("collection_id").Scan(&sizes).Error; err != nil {
+		return err
+	}
+	for _, size := range sizes {
+		labels[size.CollectionID].Documents = size.Documents
+	}
+
+	// Частоты слов по коллекциям; строк может быть много, поэтому читаем построчно
+	rows, err := db.Table("collection_documents").
+		Select("collection_documents.collection_id, term_postings.term, SUM(term_postings.count) AS count").
+		Joins("JOIN documents ON documents.id = collection_documents.document_id").
+		Joins("JOIN term_postings ON term_postings.content_hash = documents.content_hash").
+		Where("collection_documents.collection_id IN ?", ids).
+		Group("collection_documents.collection_id, term_postings.term").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			collectionID uint
+			term         string
+			count        int
+		)
+		if err := rows.Scan(&collectionID, &term, &count); err != nil {
+			return err
+		}
+		label := labels[collectionID]
+		label.Counts[term] = count
+		label.Tokens += count
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := setClassifierLabels(classifier, ids, labels); err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if classifier.ID != 0 {
+			if err := tx.Where("classifier_id = ?", classifier.ID).Delete(&models.ClassifierLabel{}).Error; err != nil {
+				return err
+			}
+		}
+		// Новые метки (с нулевым ID) создаются вместе с классификатором
+		return tx.Save(classifier).Error
+	})
+}
+
+// setClassifierLabels кладет в классификатор посчитанные метки в порядке ids
+// и считает словарь - число разных слов во всех метках
+func setClassifierLabels(classifier *models.Classifier, ids []uint, labels map[uint]*models.ClassifierLabel) error {
+	vocabulary := make(map[string]struct{})
+	classifier.Labels = make([]models.ClassifierLabel, 0, len(ids))
+	for _, id := range ids {
+		label := labels[id]
+		if label.Tokens == 0 {
+			return fmt.Errorf("%w: %s", ErrEmptyLabel, label.Name)
+		}
+		for term := range label.Counts {
+			vocabulary[term] = struct{}{}
+		}
+		label.Terms = len(label.Counts)
+		classifier.Labels = append(classifier.Labels, *label)
+	}
+	classifier.Vocabulary = len(vocabulary)
+	classifier.TrainedAt = time.Now()
+	return nil
+}
+
+// Classify считает вероятности меток для документа с частотами слов counts:
+// log P(метка) + сумма count(w) * log P(w | метка), затем softmax.
+// Слова, которых не было в обучающих коллекциях, пропускаются.
+func Classify(classifier models.Classifier, counts map[string]int) ClassifierPrediction {
+	prediction := ClassifierPrediction{ClassifierID: classifier.ID, Labels: []LabelProbability{}}
+
+	totalDocuments := 0
+	for _, label := range classifier.Labels {
+		totalDocuments += label.Documents
+	}
+	if len(classifier.Labels) == 0 || totalDocuments == 0 {
+		return prediction
+	}
+
+	vocabulary := float64(classifier.Vocabulary)
+	scores := make([]float64, len(classifier.Labels))
+	for i, label := range classifier.Labels {
+		scores[i] = math.Log(float64(label.Documents) / float64(totalDocuments))
+	}
+
+	for term, count := range counts {
+		prediction.Tokens += count
+
+		known := false
+		for _, label := range classifier.Labels {
+			if label.Counts[term] > 0 {
+				known = true
+				break
+			}
+		}
+		if !known {
+			continue
+		}
+		prediction.KnownTokens += count
+
+		for i, label := range classifier.Labels {
+			p := (float64(label.Counts[term]) + laplaceAlpha) / (float64(label.Tokens) + laplaceAlpha*vocabulary)
+			scores[i] += float64(count) * math.Log(p)
+		}
+	}
+
+	// Softmax со сдвигом на максимум: сами логарифмы на длинных текстах - тысячи по модулю
+	maxScore := math.Inf(-1)
+	for _, score := range scores {
+		maxScore = math.Max(maxScore, score)
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - maxScore)
+	}
+
+	for i, label := range classifier.Labels {
+		prediction.Labels = append(prediction.Labels, LabelProbability{
+			CollectionID: label.CollectionID,
+			Name:         label.Name,
+			Probability:  math.Exp(scores[i]-maxScore) / sum,
+			LogScore:     scores[i],
+		})
+	}
+	sort.SliceStable(prediction.Labels, func(i, j int) bool {
+		return prediction.Labels[i].Probability > prediction.Labels[j].Probability
+	})
+	prediction.Label = prediction.Labels[0]
+
+	return prediction
+}
+
+// DocumentTermCounts возвращает частоты слов содержимого из позиционного индекса
+func DocumentTermCounts(db *gorm.DB, contentHash string) (map[string]int, error) {
+	var postings []models.TermPosting
+	if err := db.Select("term", "count").Where("content_hash = ?", contentHash).Find(&postings).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(postings))
+	for _, posting := range postings {
+		counts[posting.Term] = posting.Count
+	}
+	return counts, nil
+}
+
+// AutoFileDocument раскладывает новый документ по коллекциям: для каждого классификатора
+// пользователя с auto_file документ добавляется в предсказанную коллекцию,
+// если ее вероятность не ниже min_confidence. Возвращает ID коллекций, куда документ попал.
+// Ошибки только логируются - документ уже сохранен, раскладка не должна ломать загрузку.
+func AutoFileDocument(db *gorm.DB, document models.Document, counts map[string]int) []uint {
+	var classifiers []models.Classifier
+	if err := db.Preload("Labels").
+		Where("user_id = ? AND auto_file = ?", document.UserID, true).
+		Order("id").Find(&classifiers).Error; err != nil {
+		log.Printf("Failed to get classifiers for auto filing: %v", err)
+		return nil
+	}
+
+	var filed []uint
+	seen := make(map[uint]bool)
+	for _, classifier := range classifiers {
+		prediction := Classify(classifier, counts)
+		if prediction.KnownTokens == 0 || prediction.Label.Probability < classifier.MinConfidence {
+			continue
+		}
+
+		collectionID := prediction.Label.CollectionID
+		if seen[collectionID] {
+			continue
+		}
+		seen[collectionID] = true
+
+		// Коллекцию могли удалить после обучения
+		var collection models.Collection
+		if err := db.Where("id = ? AND user_id = ?", collectionID, document.UserID).First(&collection).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Failed to get collection %d for auto filing: %v", collectionID, err)
+			}
+			continue
+		}
+
+		if err := db.Model(&collection).Association("Documents").Append(&document); err != nil {
+			log.Printf("Failed to file document %d into collection %d: %v", document.ID, collectionID, err)
+			continue
+		}
+		filed = append(filed, collectionID)
+		EmitEvent(db, document.UserID, EventCollectionUpdated, CollectionEvent{
+			CollectionID: collection.ID, Name: collection.Name, Change: CollectionDocumentAdded, DocumentID: document.ID,
+		})
+	}
+
+	return filed
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"tfidf-app/internal/models"
+)
+
+// testLabels - метки "кошки" (id 1) и "рыбалка" (id 2) после разбора частот из индекса
+func testLabels(catDocuments, fishDocuments int) map[uint]*models.ClassifierLabel {
+	return map[uint]*models.ClassifierLabel{
+		1: {CollectionID: 1, Name: "кошки", Documents: catDocuments, Tokens: 3, Counts: models.TermCounts{"кот": 2, "мышь": 1}},
+		2: {CollectionID: 2, Name: "рыбалка", Documents: fishDocuments, Tokens: 4, Counts: models.TermCounts{"рыба": 3, "кот": 1}},
+	}
+}
+
+func TestTrainClassifierLabelLimits(t *testing.T) {
+	tests := []struct {
+		labels int
+		want   error
+	}{
+		{0, ErrTooFewLabels},
+		{1, ErrTooFewLabels},
+		{MaxClassifierLabels + 1, ErrTooManyLabels},
+	}
+	for _, tt := range tests {
+		// До базы дело не доходит
+		err := TrainClassifier(nil, &models.Classifier{}, make([]models.Collection, tt.labels))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%d labels: %v, want %v", tt.labels, err, tt.want)
+		}
+	}
+}
+
+func TestSetClassifierLabels(t *testing.T) {
+	var classifier models.Classifier
+	if err := setClassifierLabels(&classifier, []uint{2, 1}, testLabels(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Метки в порядке коллекций запроса, словарь - объединение слов без повторов
+	if len(classifier.Labels) != 2 || classifier.Labels[0].CollectionID != 2 || classifier.Labels[1].CollectionID != 1 {
+		t.Fatalf("labels = %+v", classifier.Labels)
+	}
+	if classifier.Labels[0].Terms != 2 || classifier.Labels[1].Terms != 2 {
+		t.Errorf("terms = %d, %d, want 2, 2", classifier.Labels[0].Terms, classifier.Labels[1].Terms)
+	}
+	if classifier.Vocabulary != 3 {
+		t.Errorf("vocabulary = %d, want 3", classifier.Vocabulary)
+	}
+	if classifier.TrainedAt.IsZero() {
+		t.Error("trained_at is not set")
+	}
+
+	// Коллекция без проиндексированных слов - ошибка с ее именем
+	labels := testLabels(1, 1)
+	labels[3] = &models.ClassifierLabel{CollectionID: 3, Name: "пустая", Counts: models.TermCounts{}}
+	err := setClassifierLabels(&classifier, []uint{1, 2, 3}, labels)
+	if !errors.Is(err, ErrEmptyLabel) || err.Error() != ErrEmptyLabel.Error()+": пустая" {
+		t.Errorf("empty label: %v", err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name                string
+		catDocs, fishDocs   int
+		counts              map[string]int
+		label               uint
+		probability         float64
+		tokens, knownTokens int
+	}{
+		// P(кот | кошки) = (2+1) / (3+3) = 1/2, P(кот | рыбалка) = (1+1) / (4+3) = 2/7
+		// При равных априорных: 1/2 / (1/2 + 2/7) = 7/11
+		{"one known word", 1, 1, map[string]int{"кот": 1}, 1, 7.0 / 11, 1, 1},
+		// P(рыба | кошки) = 1/6, P(рыба | рыбалка) = 4/7: 4/7 / (4/7 + 1/6) = 24/31
+		{"word of the other label", 1, 1, map[string]int{"рыба": 1}, 2, 24.0 / 31, 1, 1},
+		// Незнакомые слова не учитываются, остаются априорные вероятности 3:1
+		{"only unknown words", 3, 1, map[string]int{"слон": 5}, 1, 0.75, 5, 0},
+		// Логарифмы порядка -10^5: без сдвига на максимум exp дал бы 0/0
+		{"long text", 1, 1, map[string]int{"мышь": 100000, "слон": 1}, 1, 1, 100001, 100000},
+	}
+
+	for _, tt := range tests {
+		labels := testLabels(tt.catDocs, tt.fishDocs)
+		classifier := models.Classifier{ID: 7, Vocabulary: 3, Labels: []models.ClassifierLabel{*labels[1], *labels[2]}}
+		prediction := Classify(classifier, tt.counts)
+
+		if prediction.ClassifierID != 7 || len(prediction.Labels) != 2 {
+			t.Fatalf("%s: prediction = %+v", tt.name, prediction)
+		}
+		if prediction.Label.CollectionID != tt.label {
+			t.Errorf("%s: label = %d, want %d", tt.name, prediction.Label.CollectionID, tt.label)
+		}
+		if math.Abs(prediction.Label.Probability-tt.probability) > 1e-9 {
+			t.Errorf("%s: probability = %v, want %v", tt.name, prediction.Label.Probability, tt.probability)
+		}
+		if prediction.Tokens != tt.tokens || prediction.KnownTokens != tt.knownTokens {
+			t.Errorf("%s: tokens %d/%d, want %d/%d", tt.name, prediction.KnownTokens, prediction.Tokens, tt.knownTokens, tt.tokens)
+		}
+
+		// Вероятности - распределение, метки по убыванию
+		sum := prediction.Labels[0].Probability + prediction.Labels[1].Probability
+		if math.Abs(sum-1) > 1e-9 || prediction.Labels[0].Probability < prediction.Labels[1].Probability {
+			t.Errorf("%s: labels = %+v", tt.name, prediction.Labels)
+		}
+	}
+}
+
+func TestClassifyWithoutTraining(t *testing.T) {
+	prediction := Classify(models.Classifier{}, map[string]int{"кот": 1})
+	if len(prediction.Labels) != 0 || prediction.Label.CollectionID != 0 {
+		t.Errorf("prediction = %+v", prediction)
+	}
+}
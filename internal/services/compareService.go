@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"tfidf-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Критическое значение log-likelihood для p < 0.05 при одной степени свободы.
+// Слова с меньшим значением отличаются случайно и в отличительные не попадают.
+const KeynessThreshold = 3.84
+
+// CompareSide - одна сторона сравнения: документ или коллекция
+type CompareSide struct {
+	Kind      string `json:"kind"` // document или collection
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Documents int    `json:"documents"`
+	Tokens    int    `json:"tokens"`
+	Types     int    `json:"types"`
+
+	documents []models.Document
+	counts    WordCounts
+}
+
+func NewCompareSide(kind string, id uint, name string, documents []models.Document) *CompareSide {
+	return &CompareSide{Kind: kind, ID: id, Name: name, Documents: len(documents), documents: documents}
+}
+
+// KeyTerm - слово, которое на одной стороне встречается заметно чаще, чем на другой
+type KeyTerm struct {
+	Word           string  `json:"word"`
+	LeftCount      int     `json:"left_count"`
+	RightCount     int     `json:"right_count"`
+	LeftFrequency  float64 `json:"left_frequency"` // доля от всех слов стороны
+	RightFrequency float64 `json:"right_frequency"`
+	LogLikelihood  float64 `json:"log_likelihood"`
+	ChiSquare      float64 `json:"chi_square"`
+}
+
+// SharedTerm - слово, которое есть на обеих сторонах
+type SharedTerm struct {
+	Word           string  `json:"word"`
+	LeftCount      int     `json:"left_count"`
+	RightCount     int     `json:"right_count"`
+	LeftFrequency  float64 `json:"left_frequency"`
+	RightFrequency float64 `json:"right_frequency"`
+	Ratio          float64 `json:"ratio"` // left_frequency / right_frequency
+}
+
+type CompareResult struct {
+	Left             *CompareSide `json:"left"`
+	Right            *CompareSide `json:"right"`
+	CosineSimilarity float64      `json:"cosine_similarity"` // по векторам TF-IDF, IDF по всей библиотеке пользователя
+	CosineTF         float64      `json:"cosine_tf"`         // по векторам TF, имеет смысл и когда документ всего один
+	LeftDistinctive  []KeyTerm    `json:"left_distinctive"`  // по убыванию log-likelihood
+	RightDistinctive []KeyTerm    `json:"right_distinctive"`
+	Shared           []SharedTerm `json:"shared"` // самые частые на обеих сторонах вместе
+}
+
+// count считает слова всех документов стороны вместе
+func (s *CompareSide) count(ctx context.Context) error {
+	s.counts = WordCounts{Counts: make(map[string]int)}
+	for _, document := range s.documents {
+		counts, err := CountDocumentWords(ctx, document)
+		if err != nil {
+			return err
+		}
+		for word, count := range counts.Counts {
+			s.counts.Counts[word] += count
+		}
+		s.counts.Total += counts.Total
+	}
+
+	s.Tokens = s.counts.Total
+	s.Types = len(s.counts.Counts)
+	return nil
+}
+
+// IDFFunc возвращает IDF слова
+type IDFFunc func(word string) float64
+
+// LibraryIDF - IDF по всей библиотеке пользователя: ln(N / df), df - из позиционного индекса.
+// Только по сравниваемым документам считать нельзя: у двух документов любое общее слово получило бы IDF 0.
+func LibraryIDF(db *gorm.DB, userID int) (IDFFunc, error) {
+	vocabulary, err := UserVocabulary(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents int64
+	if err := db.Model(&models.Document{}).Where("user_id = ?", userID).Count(&documents).Error; err != nil {
+		return nil, err
+	}
+
+	return func(word string) float64 {
+		// Слова нет в словаре, только если индекс еще не догнал документ - считаем его единственным
+		return math.Log(float64(max(documents, 1)) / float64(max(vocabulary.DF(word), 1)))
+	}, nil
+}
+
+// Compare сравнивает две стороны по словам. limit - сколько слов в каждом списке.
+func Compare(ctx context.Context, left, right *CompareSide, idf IDFFunc, limit int) (CompareResult, error) {
+	if err := left.count(ctx); err != nil {
+		return CompareResult{Left: left, Right: right}, err
+	}
+	if err := right.count(ctx); err != nil {
+		return CompareResult{Left: left, Right: right}, err
+	}
+	return compareCounts(left, right, idf, limit), nil
+}
+
+// compareCounts сравнивает стороны с уже посчитанными словами
+func compareCounts(left, right *CompareSide, idf IDFFunc, limit int) CompareResult {
+	result := CompareResult{
+		Left:             left,
+		Right:            right,
+		LeftDistinctive:  []KeyTerm{},
+		RightDistinctive: []KeyTerm{},
+		Shared:           []SharedTerm{},
+	}
+
+	leftTF := CalculateTF(left.counts.Counts, left.counts.Total)
+	rightTF := CalculateTF(right.counts.Counts, right.counts.Total)
+	result.CosineTF = cosine(leftTF, rightTF, nil)
+	result.CosineSimilarity = cosine(leftTF, rightTF, idf)
+
+	words := make(map[string]bool, len(left.counts.Counts)+len(right.counts.Counts))
+	for word := range left.counts.Counts {
+		words[word] = true
+	}
+	for word := range right.counts.Counts {
+		words[word] = true
+	}
+
+	for word := range words {
+		a, b := left.counts.Counts[word], right.counts.Counts[word]
+		if a > 0 && b > 0 {
+			result.Shared = append(result.Shared, SharedTerm{
+				Word:           word,
+				LeftCount:      a,
+				RightCount:     b,
+				LeftFrequency:  leftTF[word],
+				RightFrequency: rightTF[word],
+				Ratio:          leftTF[word] / rightTF[word],
+			})
+		}
+
+		ll := logLikelihood(a, b, left.counts.Total, right.counts.Total)
+		if ll < KeynessThreshold {
+			continue
+		}
+		term := KeyTerm{
+			Word:           word,
+			LeftCount:      a,
+			RightCount:     b,
+			LeftFrequency:  leftTF[word],
+			RightFrequency: rightTF[word],
+			LogLikelihood:  ll,
+			ChiSquare:      chiSquare(a, b, left.counts.Total, right.counts.Total),
+		}
+		if term.LeftFrequency > term.RightFrequency {
+			result.LeftDistinctive = append(result.LeftDistinctive, term)
+		} else {
+			result.RightDistinctive = append(result.RightDistinctive, term)
+		}
+	}
+
+	byKeyness := func(terms []KeyTerm) {
+		sort.Slice(terms, func(i, j int) bool {
+			if terms[i].LogLikelihood != terms[j].LogLikelihood {
+				return terms[i].LogLikelihood > terms[j].LogLikelihood
+			}
+			return terms[i].Word < terms[j].Word
+		})
+	}
+	byKeyness(result.LeftDistinctive)
+	byKeyness(result.RightDistinctive)
+	sort.Slice(result.Shared, func(i, j int) bool {
+		a, b := result.Shared[i], result.Shared[j]
+		if a.LeftCount+a.RightCount != b.LeftCount+b.RightCount {
+			return a.LeftCount+a.RightCount > b.LeftCount+b.RightCount
+		}
+		return a.Word < b.Word
+	})
+
+	result.LeftDistinctive = result.LeftDistinctive[:min(limit, len(result.LeftDistinctive))]
+	result.RightDistinctive = result.RightDistinctive[:min(limit, len(result.RightDistinctive))]
+	result.Shared = result.Shared[:min(limit, len(result.Shared))]
+	return result
+}
+
+// logLikelihood - G² Даннинга: насколько частоты слова a из total1 и b из total2 отличаются от ожидаемых
+func logLikelihood(a, b, total1, total2 int) float64 {
+	if total1 == 0 || total2 == 0 {
+		return 0
+	}
+	expected1 := float64(total1) * float64(a+b) / float64(total1+total2)
+	expected2 := float64(total2) * float64(a+b) / float64(total1+total2)
+
+	var ll float64
+	if a > 0 {
+		ll += float64(a) * math.Log(float64(a)/expected1)
+	}
+	if b > 0 {
+		ll += float64(b) * math.Log(float64(b)/expected2)
+	}
+	return 2 * ll
+}
+
+// chiSquare - критерий хи-квадрат Пирсона для таблицы 2x2 (слово / остальные слова на каждой стороне)
+func chiSquare(a, b, total1, total2 int) float64 {
+	n := float64(total1 + total2)
+	c, d := float64(total1-a), float64(total2-b)
+	denominator := float64(a+b) * (c + d) * float64(total1) * float64(total2)
+	if denominator == 0 {
+		return 0
+	}
+	diff := float64(a)*d - float64(b)*c
+	return n * diff * diff / denominator
+}
+
+// cosine - косинусное сходство векторов TF, умноженных на IDF (если idf не nil).
+// Нулевой вектор ни на что не похож, сходство 0.
+func cosine(left, right map[string]float64, idf IDFFunc) float64 {
+	weight := func(word string, tf float64) float64 {
+		if idf == nil {
+			return tf
+		}
+		return tf * idf(word)
+	}
+
+	var dot, leftNorm, rightNorm float64
+	for word, tf := range left {
+		w := weight(word, tf)
+		leftNorm += w * w
+		if other, ok := right[word]; ok {
+			dot += w * weight(word, other)
+		}
+	}
+	for word, tf := range right {
+		w := weight(word, tf)
+		rightNorm += w * w
+	}
+
+	if leftNorm == 0 || rightNorm == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(leftNorm*rightNorm)
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+// countedSide - сторона сравнения с уже посчитанными словами текста
+func countedSide(name, text string) *CompareSide {
+	side := NewCompareSide("document", 0, name, nil)
+	side.counts = WordCounts{Counts: make(map[string]int)}
+	for _, token := range Tokenize([]byte(text)) {
+		side.counts.Counts[token.Word]++
+		side.counts.Total++
+	}
+	return side
+}
+
+// libraryIDF - IDF по библиотеке из текстов, как LibraryIDF по индексу
+func libraryIDF(texts ...string) IDFFunc {
+	df := make(map[string]int)
+	for _, text := range texts {
+		seen := make(map[string]bool)
+		for _, token := range Tokenize([]byte(text)) {
+			if !seen[token.Word] {
+				seen[token.Word] = true
+				df[token.Word]++
+			}
+		}
+	}
+	return func(word string) float64 {
+		return math.Log(float64(len(texts)) / float64(max(df[word], 1)))
+	}
+}
+
+func TestCompareCosine(t *testing.T) {
+	cats := "кошка ловит мышь, кошка спит на окне"
+	dogs := "собака грызет кость и лает во дворе"
+	mixed := "кошка и собака спят во дворе"
+	idf := libraryIDF(cats, dogs, mixed, "сказка о рыбаке и рыбке")
+
+	tests := []struct {
+		name        string
+		left, right string
+		tfidf, tf   float64
+	}{
+		{"same document", cats, cats, 1, 1},
+		{"disjoint documents", cats, "рыбак тянет невод", 0, 0},
+		{"no words", cats, "", 0, 0},
+	}
+	for _, tt := range tests {
+		result := compareCounts(countedSide("left", tt.left), countedSide("right", tt.right), idf, 10)
+		if math.Abs(result.CosineSimilarity-tt.tfidf) > 1e-9 {
+			t.Errorf("%s: cosine_similarity = %v, want %v", tt.name, result.CosineSimilarity, tt.tfidf)
+		}
+		if math.Abs(result.CosineTF-tt.tf) > 1e-9 {
+			t.Errorf("%s: cosine_tf = %v, want %v", tt.name, result.CosineTF, tt.tf)
+		}
+	}
+
+	// Общие слова дают сходство строго между 0 и 1
+	result := compareCounts(countedSide("left", cats), countedSide("right", mixed), idf, 10)
+	if result.CosineSimilarity <= 0 || result.CosineSimilarity >= 1 {
+		t.Errorf("overlapping documents: cosine_similarity = %v", result.CosineSimilarity)
+	}
+}
+
+func TestCompareDistinctiveTerms(t *testing.T) {
+	left := countedSide("left", "кошка кошка кошка кошка кошка кошка кошка кошка дом дом")
+	right := countedSide("right", "собака собака собака собака собака собака собака собака дом дом")
+	result := compareCounts(left, right, libraryIDF("кошка дом", "собака дом", "сад"), 10)
+
+	if len(result.LeftDistinctive) != 1 || result.LeftDistinctive[0].Word != "кошка" {
+		t.Errorf("left distinctive = %+v", result.LeftDistinctive)
+	}
+	if len(result.RightDistinctive) != 1 || result.RightDistinctive[0].Word != "собака" {
+		t.Errorf("right distinctive = %+v", result.RightDistinctive)
+	}
+	// "дом" одинаково част на обеих сторонах: общий, но не отличительный
+	if len(result.Shared) != 1 || result.Shared[0].Word != "дом" || result.Shared[0].Ratio != 1 {
+		t.Errorf("shared = %+v", result.Shared)
+	}
+}
+
+func TestLogLikelihood(t *testing.T) {
+	tests := []struct {
+		a, b, total1, total2 int
+		want                 float64
+	}{
+		{10, 10, 100, 100, 0},
+		{0, 0, 100, 100, 0},
+		{5, 0, 0, 100, 0},
+		// 2 * 10 * ln(10 / 5)
+		{10, 0, 100, 100, 20 * math.Ln2},
+	}
+	for _, tt := range tests {
+		if got := logLikelihood(tt.a, tt.b, tt.total1, tt.total2); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("logLikelihood(%d, %d, %d, %d) = %v, want %v", tt.a, tt.b, tt.total1, tt.total2, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"tfidf-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Режимы хранения документов на диске
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
+// Сколько места занимают документы и сколько сэкономило сжатие
+type StorageUsage struct {
+	Documents     int64 `json:"documents"`
+	OriginalBytes int64 `json:"original_bytes"`
+	StoredBytes   int64 `json:"stored_bytes"`
+	SavedBytes    int64 `json:"saved_bytes"`
+}
+
+// NewCompressWriter оборачивает w сжатием, Close дописывает хвост сжатого потока
+func NewCompressWriter(w io.Writer, compression string) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown compression %q", compression)
+	}
+}
+
+// NewDecompressReader распаковывает поток; Close закрывает и распаковщик, и исходный поток
+func NewDecompressReader(rc io.ReadCloser, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return rc, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(rc)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		return &decompressReader{Reader: zr, closers: []io.Closer{zr, rc}}, nil
+	default:
+		rc.Close()
+		return nil, fmt.Errorf("unknown compression %q", compression)
+	}
+}
+
+type decompressReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decompressReader) Close() error {
+	var errs []error
+	for _, c := range d.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func DecompressContent(stored []byte, compression string) ([]byte, error) {
+	switch compression {
+	case CompressionNone:
+		return stored, nil
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(stored))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+	default:
+		return nil, fmt.Errorf("unknown compression %q", compression)
+	}
+}
+
+// GetStorageUsage считает место по документам из переданного запроса (можно сузить через Where)
+func GetStorageUsage(tx *gorm.DB) (StorageUsage, error) {
+	var usage StorageUsage
+	err := tx.Model(&models.Document{}).
+		Select("COUNT(*) AS documents, COALESCE(SUM(size), 0) AS original_bytes, COALESCE(SUM(stored_size), 0) AS stored_bytes").
+		Scan(&usage).Error
+	if err != nil {
+		return StorageUsage{}, err
+	}
+
+	usage.SavedBytes = usage.OriginalBytes - usage.StoredBytes
+	return usage, nil
+}
+
+// GetTotalStorageUsage считает место по всем документам с учетом дедупликации:
+// одинаковое содержимое хранится одним blob'ом и учитывается один раз
+func GetTotalStorageUsage(db *gorm.DB) (StorageUsage, error) {
+	usage, err := GetStorageUsage(db.Where("content_hash = '' OR content_hash IS NULL"))
+	if err != nil {
+		return StorageUsage{}, err
+	}
+
+	var deduplicated StorageUsage
+	if err := db.Model(&models.Document{}).
+		Where("content_hash <> ''").
+		Select("COUNT(*) AS documents, COALESCE(SUM(size), 0) AS original_bytes").
+		Scan(&deduplicated).Error; err != nil {
+		return StorageUsage{}, err
+	}
+	if err := db.Model(&models.Blob{}).
+		Select("COALESCE(SUM(stored_size), 0)").
+		Scan(&deduplicated.StoredBytes).Error; err != nil {
+		return StorageUsage{}, err
+	}
+
+	usage.Documents += deduplicated.Documents
+	usage.OriginalBytes += deduplicated.OriginalBytes
+	usage.StoredBytes += deduplicated.StoredBytes
+	usage.SavedBytes = usage.OriginalBytes - usage.StoredBytes
+	return usage, nil
+}
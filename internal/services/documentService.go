@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/storage"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrQuotaExceeded       = errors.New("storage quota exceeded")
+	ErrDocumentNameTaken   = errors.New("document with this name already exists")
+	ErrInvalidDocumentName = errors.New("document name must be 1-100 characters long")
+	ErrInvalidDocumentDate = errors.New("document_date must be a date like 2024-03-31")
+)
+
+// Откуда взято содержимое документа (models.Document.Source), для ссылок - сам адрес
+const (
+	SourceUpload = "upload"
+	SourceText   = "text"
+)
+
+// Результат сохранения нового содержимого документа
+type IngestResult struct {
+	Document    models.Document
+	Counts      WordCounts
+	DuplicateOf *uint               // другой документ пользователя с тем же содержимым
+	Unchanged   bool                // содержимое совпало с текущей версией, новая версия не создавалась
+	FiledInto   []uint              // коллекции, в которые новый документ разложили классификаторы
+	Alerts      []models.WatchAlert // сработавшие на новом содержимом отслеживания
+}
+
+// IngestDocument сохраняет загруженное содержимое под именем name.
+// Если у пользователя уже есть документ с таким именем, создается его новая версия.
+func IngestDocument(ctx context.Context, userID int, name string, staged *StagedContent, startTime time.Time) (IngestResult, error) {
+	if err := ValidateDocumentName(name); err != nil {
+		return IngestResult{}, err
+	}
+
+	findDocument := func(tx *gorm.DB, document *models.Document) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ? AND user_id = ?", name, userID).First(document).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			*document = models.Document{Name: name, UserID: userID}
+			return nil
+		}
+		return err
+	}
+
+	// Несуществующую строку блокировка не держит: параллельная загрузка с тем же именем может
+	// успеть создать документ раньше, и тогда вставку отклонит уникальный индекс (user_id, name).
+	// Повторная попытка уже найдет этот документ и создаст его новую версию.
+	result, err := ingest(ctx, userID, staged, startTime, findDocument)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		result, err = ingest(ctx, userID, staged, startTime, findDocument)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return result, ErrDocumentNameTaken
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// Автораскладка - только для новых документов, новая версия остается в своих коллекциях
+	if !result.Unchanged && result.Document.Version == 1 {
+		result.FiledInto = AutoFileDocument(database.DB, result.Document, staged.Counts.Counts)
+		EmitEvent(database.DB, userID, EventDocumentCreated, DocumentEvent{
+			DocumentID: result.Document.ID, Name: result.Document.Name, Version: 1, FiledInto: result.FiledInto,
+		})
+	}
+	if !result.Unchanged {
+		result.Alerts = MatchWatches(database.DB, result.Document, staged.Counts)
+	}
+	return result, nil
+}
+
+// ReplaceDocumentContent создает новую версию существующего документа
+func ReplaceDocumentContent(ctx context.Context, document models.Document, staged *StagedContent, startTime time.Time) (IngestResult, error) {
+	return ingest(ctx, document.UserID, staged, startTime, func(tx *gorm.DB, locked *models.Document) error {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", document.ID, document.UserID).First(locked).Error
+	})
+}
+
+// ingest - общий конвейер: подсчет слов, blob, документ и версия, метрики - в одной транзакции.
+// findDocument находит (и блокирует) документ, либо заполняет новый с нулевым ID.
+func ingest(ctx context.Context, userID int, staged *StagedContent, startTime time.Time, findDocument func(tx *gorm.DB, document *models.Document) error) (IngestResult, error) {
+	// Хэш и слова посчитаны еще при записи во временный файл
+	result := IngestResult{Counts: staged.Counts}
+	hash := staged.Hash
+
+	var createdKey string
+	var metric models.Metric
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var document models.Document
+		if err := findDocument(tx, &document); err != nil {
+			return err
+		}
+
+		// Тот же текст уже загружен под другим именем - сообщим его id
+		var duplicate models.Document
+		if err := tx.Where("content_hash = ? AND user_id = ? AND id <> ?", hash, userID, document.ID).
+			First(&duplicate).Error; err == nil {
+			result.DuplicateOf = &duplicate.ID
+		}
+
+		if document.ID != 0 && document.ContentHash == hash {
+			result.Document = document
+			result.Unchanged = true
+			return nil
+		}
+
+		if err := CheckUserQuota(tx, userID, staged.Size); err != nil {
+			return err
+		}
+
+		// Сохраняем файл в хранилище, если такого содержимого еще нет (сжатым, если включено STORAGE_COMPRESSION)
+		blob, created, err := AcquireBlob(ctx, tx, staged)
+		if created {
+			createdKey = blob.StorageKey
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save file: %w", err)
+		}
+
+		// Позиционный индекс нужен один на содержимое, у уже известного blob'а он есть
+		if err := SaveContentIndex(tx, hash, staged.Postings); err != nil {
+			return fmt.Errorf("failed to save index: %w", err)
+		}
+
+		document.MimeType = staged.MimeType
+		document.Source = staged.Source
+		document.TokenCount = staged.Counts.Total
+		document.UniqueTerms = len(staged.Counts.Counts)
+
+		if err := addVersion(tx, &document, blob); err != nil {
+			return err
+		}
+		result.Document = document
+
+		// Вычисляем и обновляем метрики
+		processingTime := CalculateProcessingTime(startTime)
+		metric, err = UpdateMetrics(tx, processingTime, RoundFileSizeMB(staged.Size))
+		if err != nil {
+			return fmt.Errorf("failed to update metrics: %w", err)
+		}
+
+		// Сохраняем слова
+		if err := SaveWords(tx, result.Counts.Counts, metric.ID); err != nil {
+			return fmt.Errorf("failed to save words: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil && createdKey != "" {
+		// Документ не сохранился в БД, только что записанный файл никому не нужен.
+		// Ключ у каждой попытки свой, так что чужой blob на этот файл сослаться не может.
+		if delErr := storage.Files.Delete(ctx, createdKey); delErr != nil {
+			log.Printf("Failed to delete orphaned file %s: %v", createdKey, delErr)
+		}
+	}
+
+	if err == nil && metric.ID != 0 {
+		// Метрики общие для сервиса, как и /metrics. Слова не шлем: их список в метрике полный, а не топ-10
+		metric.Words = nil
+		BroadcastStream(EventMetricsUpdated, metric)
+	}
+
+	return result, err
+}
+
+// ParseDocumentDate разбирает дату документа: 2024-03-31 или RFC 3339 (время отбрасывается).
+// Пустая строка - даты нет.
+func ParseDocumentDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		parsed, rfcErr := time.Parse(time.RFC3339, value)
+		if rfcErr != nil {
+			return nil, ErrInvalidDocumentDate
+		}
+		date = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return &date, nil
+}
+
+// ValidateDocumentName проверяет длину имени (колонка documents.name - 100 символов)
+func ValidateDocumentName(name string) error {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > 100 {
+		return ErrInvalidDocumentName
+	}
+	return nil
+}
+
+// RenameDocument переименовывает документ внутри транзакции.
+// Имена документов у пользователя уникальны: по имени загрузка находит документ для новой версии.
+func RenameDocument(tx *gorm.DB, document *models.Document, name string) error {
+	if name == document.Name {
+		return nil
+	}
+	if err := ValidateDocumentName(name); err != nil {
+		return err
+	}
+
+	// Занятость имени проверяет уникальный индекс (user_id, name): проверка перед обновлением
+	// не спасла бы от параллельного переименования в то же имя
+	err := tx.Model(document).Update("name", name).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDocumentNameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	document.Name = name
+	return nil
+}
+
+// CheckUserQuota проверяет, поместится ли еще incoming байт в лимит MAX_USER_STORAGE.
+// Считаются все версии документов пользователя.
+func CheckUserQuota(tx *gorm.DB, userID int, incoming int64) error {
+	if config.Init.MaxUserStorage <= 0 {
+		return nil
+	}
+
+	var used int64
+	err := tx.Model(&models.DocumentVersion{}).
+		Joins("JOIN documents ON documents.id = document_versions.document_id").
+		Where("documents.user_id = ?", userID).
+		Select("COALESCE(SUM(document_versions.size), 0)").
+		Scan(&used).Error
+	if err != nil {
+		return err
+	}
+
+	if used+incoming > config.Init.MaxUserStorage {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// addVersion делает blob текущим содержимым документа и записывает версию
+func addVersion(tx *gorm.DB, document *models.Document, blob models.Blob) error {
+	document.StorageKey = blob.StorageKey
+	document.Compression = blob.Compression
+	document.Size = blob.Size
+	document.StoredSize = blob.StoredSize
+	document.ContentHash = blob.Hash
+	document.Version++
+
+	if document.ID == 0 {
+		if err := tx.Create(document).Error; err != nil {
+			return fmt.Errorf("failed to save document: %w", err)
+		}
+	} else if err := tx.Save(document).Error; err != nil {
+		return fmt.Errorf("failed to update document: %w", err)
+	}
+
+	version := models.DocumentVersion{
+		DocumentID:  document.ID,
+		Version:     document.Version,
+		StorageKey:  blob.StorageKey,
+		Compression: blob.Compression,
+		Size:        blob.Size,
+		StoredSize:  blob.StoredSize,
+		ContentHash: blob.Hash,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("failed to save document version: %w", err)
+	}
+
+	return nil
+}
+
+// ReadVersionContent читает содержимое конкретной версии документа
+func ReadVersionContent(ctx context.Context, version models.DocumentVersion) ([]byte, error) {
+	return readStoredContent(ctx, version.StorageKey, version.Compression)
+}
+
+// BackfillDocumentVersions создает первую версию документам, загруженным до версионирования.
+// Ссылку на blob, которую держал документ, дальше держит эта версия.
+func BackfillDocumentVersions() {
+	var documents []models.Document
+	err := database.DB.
+		Where("NOT EXISTS (SELECT 1 FROM document_versions v WHERE v.document_id = documents.id)").
+		Find(&documents).Error
+	if err != nil {
+		log.Printf("Failed to get documents without versions: %v", err)
+		return
+	}
+
+	for _, document := range documents {
+		version := models.DocumentVersion{
+			DocumentID:  document.ID,
+			Version:     document.Version,
+			StorageKey:  document.StorageKey,
+			Compression: document.Compression,
+			Size:        document.Size,
+			StoredSize:  document.StoredSize,
+			ContentHash: document.ContentHash,
+			CreatedAt:   document.CreatedAt,
+		}
+		if err := database.DB.Create(&version).Error; err != nil {
+			log.Printf("Failed to create first version of document %d: %v", document.ID, err)
+		}
+	}
+
+	if len(documents) > 0 {
+		log.Printf("INFO: First versions created for %d documents.", len(documents))
+	}
+}
+
+// BackfillDocumentMetadata заполняет тип, источник и число слов документам, загруженным до их появления
+func BackfillDocumentMetadata(ctx context.Context) {
+	var documents []models.Document
+	if err := database.DB.Where("mime_type = '' OR mime_type IS NULL").Find(&documents).Error; err != nil {
+		log.Printf("Failed to get documents without metadata: %v", err)
+		return
+	}
+
+	for _, document := range documents {
+		content, err := ReadDocumentContent(ctx, document)
+		if err != nil {
+			log.Printf("Failed to read document %d for metadata: %v", document.ID, err)
+			continue
+		}
+		counts := CountContentWords(document.ContentHash, content)
+
+		source := document.Source
+		if source == "" {
+			source = SourceUpload
+		}
+
+		err = database.DB.Model(&document).Updates(map[string]any{
+			"mime_type":    MediaType(http.DetectContentType(content)),
+			"source":       source,
+			"token_count":  counts.Total,
+			"unique_terms": len(counts.Counts),
+		}).Error
+		if err != nil {
+			log.Printf("Failed to backfill metadata for document %d: %v", document.ID, err)
+		}
+	}
+
+	if len(documents) > 0 {
+		log.Printf("INFO: Metadata backfilled for %d documents.", len(documents))
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/storage"
+)
+
+// Ключ-префикс файлов пользователя (документы, загруженные до дедупликации)
+func UserKeyPrefix(userID int) string {
+	return fmt.Sprintf("user_%d/", userID)
+}
+
+// SaveStagedContent сохраняет содержимое в хранилище с текущим режимом сжатия из конфига.
+// Возвращает использованный режим и размер в хранилище.
+func SaveStagedContent(ctx context.Context, key string, staged *StagedContent) (string, int64, error) {
+	compression := config.Init.StorageCompression
+	path, size := staged.Path, staged.Size
+
+	if compression != CompressionNone {
+		compressed, compressedSize, err := compressFile(staged.Path, compression)
+		if err != nil {
+			return "", 0, err
+		}
+		defer os.Remove(compressed)
+
+		// Если сжатие ничего не дало, храним как есть
+		if compressedSize < staged.Size {
+			path, size = compressed, compressedSize
+		} else {
+			compression = CompressionNone
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	if err := storage.Files.Put(ctx, key, f, size); err != nil {
+		return "", 0, err
+	}
+	return compression, size, nil
+}
+
+// compressFile потоково сжимает файл во временный рядом с ним
+func compressFile(path, compression string) (string, int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(filepath.Dir(path), "compressed-*")
+	if err != nil {
+		return "", 0, err
+	}
+
+	zw, err := NewCompressWriter(dst, compression)
+	if err == nil {
+		_, err = io.Copy(zw, src)
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", 0, err
+	}
+
+	info, err := os.Stat(dst.Name())
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", 0, err
+	}
+	return dst.Name(), info.Size(), nil
+}
+
+// ReadDocumentContent читает файл документа из хранилища и прозрачно распаковывает его
+func ReadDocumentContent(ctx context.Context, document models.Document) ([]byte, error) {
+	return readStoredContent(ctx, document.StorageKey, document.Compression)
+}
+
+func readStoredContent(ctx context.Context, key, compression string) ([]byte, error) {
+	rc, err := storage.Files.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	stored, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return DecompressContent(stored, compression)
+}
+
+// OpenDocumentContent открывает распакованное содержимое документа потоком, не читая его в память
+func OpenDocumentContent(ctx context.Context, document models.Document) (io.ReadCloser, error) {
+	rc, err := storage.Files.Get(ctx, document.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecompressReader(rc, document.Compression)
+}
+
+// OpenVersionContent открывает распакованное содержимое версии потоком, не читая его в память
+func OpenVersionContent(ctx context.Context, version models.DocumentVersion) (io.ReadCloser, error) {
+	rc, err := storage.Files.Get(ctx, version.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecompressReader(rc, version.Compression)
+}
+
+// ContentSeeker - содержимое документа как io.ReadSeeker для http.ServeContent (Range-запросы).
+// Файл открывается лениво при первом чтении. Если поток сам не умеет Seek, несжатый файл
+// открывается заново с нужного байта, а сжатый вперед проматывается чтением, назад - открывается заново.
+type ContentSeeker struct {
+	ctx      context.Context
+	document models.Document
+
+	rc     io.ReadCloser
+	pos    int64 // куда просили встать
+	rcPos  int64 // где на самом деле стоит rc
+	closed bool
+}
+
+func NewContentSeeker(ctx context.Context, document models.Document) *ContentSeeker {
+	return &ContentSeeker{ctx: ctx, document: document}
+}
+
+func (s *ContentSeeker) Seek(offset int64, whence int) (int64, error) {
+	var pos int64
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos = s.pos + offset
+	case io.SeekEnd:
+		pos = s.document.Size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("negative position %d", pos)
+	}
+	s.pos = pos
+	return pos, nil
+}
+
+func (s *ContentSeeker) Read(p []byte) (int, error) {
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	if s.pos >= s.document.Size {
+		return 0, io.EOF
+	}
+	if err := s.sync(); err != nil {
+		return 0, err
+	}
+
+	n, err := s.rc.Read(p)
+	s.pos += int64(n)
+	s.rcPos += int64(n)
+	return n, err
+}
+
+// sync ставит открытый поток на s.pos
+func (s *ContentSeeker) sync() error {
+	if s.rc != nil && s.rcPos == s.pos {
+		return nil
+	}
+
+	if seeker, ok := s.rc.(io.Seeker); ok {
+		if _, err := seeker.Seek(s.pos, io.SeekStart); err != nil {
+			return err
+		}
+		s.rcPos = s.pos
+		return nil
+	}
+
+	// Несжатый файл хранилище само отдает с нужного байта (S3 - запросом с Range),
+	// проматывать его чтением - значит зря качать все до начала диапазона
+	if s.document.Compression == CompressionNone {
+		return s.reopen(s.pos)
+	}
+
+	// Сжатый поток читается только подряд: назад - открываем заново, вперед - проматываем
+	if s.rc == nil || s.rcPos > s.pos {
+		if err := s.reopen(0); err != nil {
+			return err
+		}
+	}
+	skipped, err := io.CopyN(io.Discard, s.rc, s.pos-s.rcPos)
+	s.rcPos += skipped
+	return err
+}
+
+// reopen открывает содержимое заново с байта offset (больше нуля - только для несжатого)
+func (s *ContentSeeker) reopen(offset int64) error {
+	if s.rc != nil {
+		s.rc.Close()
+		s.rc = nil
+	}
+
+	var rc io.ReadCloser
+	var err error
+	if offset > 0 {
+		rc, err = storage.Files.GetRange(s.ctx, s.document.StorageKey, offset)
+	} else {
+		rc, err = OpenDocumentContent(s.ctx, s.document)
+	}
+	if err != nil {
+		return err
+	}
+	s.rc, s.rcPos = rc, offset
+
+	if seeker, ok := rc.(io.Seeker); ok && s.pos != offset {
+		if _, err := seeker.Seek(s.pos, io.SeekStart); err != nil {
+			return err
+		}
+		s.rcPos = s.pos
+	}
+	return nil
+}
+
+func (s *ContentSeeker) Close() error {
+	s.closed = true
+	if s.rc == nil {
+		return nil
+	}
+	return s.rc.Close()
+}
+
+// DeleteDocumentContent сбрасывает кэш по хэшу содержимого и удаляет файл из хранилища.
+// Файл ради хэша не читается: без content_hash в кэш ничего не попадало, сбрасывать нечего.
+func DeleteDocumentContent(ctx context.Context, document models.Document) error {
+	if document.ContentHash != "" {
+		DocumentCache.Invalidate(document.ContentHash)
+	}
+	return storage.Files.Delete(ctx, document.StorageKey)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/storage"
+)
+
+// streamStorage ведет себя как S3: поток без Seek, а каждое открытие записывается
+type streamStorage struct {
+	storage.Storage
+	opens []int64 // смещения открытий, 0 - Get
+}
+
+func (s *streamStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.opens = append(s.opens, 0)
+	rc, err := s.Storage.Get(ctx, key)
+	return struct{ io.ReadCloser }{rc}, err
+}
+
+func (s *streamStorage) GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	s.opens = append(s.opens, offset)
+	rc, err := s.Storage.GetRange(ctx, key, offset)
+	return struct{ io.ReadCloser }{rc}, err
+}
+
+// withStreamStorage подменяет storage.Files на локальное хранилище без Seek
+func withStreamStorage(t *testing.T) *streamStorage {
+	t.Helper()
+	saved := storage.Files
+	t.Cleanup(func() { storage.Files = saved })
+
+	store := &streamStorage{Storage: storage.NewLocalStorage(t.TempDir())}
+	storage.Files = store
+	return store
+}
+
+// readAt читает length байт с позиции offset, как http.ServeContent для Range
+func readAt(t *testing.T, seeker *ContentSeeker, offset, length int64) string {
+	t.Helper()
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		t.Fatalf("Seek(%d): %v", offset, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(seeker, length))
+	if err != nil {
+		t.Fatalf("read at %d: %v", offset, err)
+	}
+	return string(data)
+}
+
+func TestContentSeekerUncompressedUsesRanges(t *testing.T) {
+	store := withStreamStorage(t)
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 100)
+	if err := store.Put(ctx, "user_1/a.txt", strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+
+	seeker := NewContentSeeker(ctx, models.Document{StorageKey: "user_1/a.txt", Size: int64(len(content))})
+	defer seeker.Close()
+
+	ranges := []struct{ offset, length int64 }{{500, 10}, {900, 5}, {100, 3}, {103, 4}}
+	for _, r := range ranges {
+		if got, want := readAt(t, seeker, r.offset, r.length), content[r.offset:r.offset+r.length]; got != want {
+			t.Errorf("read at %d = %q, want %q", r.offset, got, want)
+		}
+	}
+
+	// Чтение подряд (103 после 100..102) не открывает файл заново
+	want := []int64{500, 900, 100}
+	if len(store.opens) != len(want) {
+		t.Fatalf("opens = %v, want %v", store.opens, want)
+	}
+	for i := range want {
+		if store.opens[i] != want[i] {
+			t.Fatalf("opens = %v, want %v", store.opens, want)
+		}
+	}
+}
+
+func TestContentSeekerGzipSkipsForward(t *testing.T) {
+	store := withStreamStorage(t)
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 100)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(content))
+	zw.Close()
+	if err := store.Put(ctx, "user_1/a.txt.gz", &compressed, int64(compressed.Len())); err != nil {
+		t.Fatal(err)
+	}
+
+	document := models.Document{StorageKey: "user_1/a.txt.gz", Size: int64(len(content)), Compression: CompressionGzip}
+	seeker := NewContentSeeker(ctx, document)
+	defer seeker.Close()
+
+	for _, offset := range []int64{500, 900, 100} {
+		if got, want := readAt(t, seeker, offset, 10), content[offset:offset+10]; got != want {
+			t.Errorf("read at %d = %q, want %q", offset, got, want)
+		}
+	}
+
+	// Сжатый поток диапазоном не открыть: вперед проматываем, назад - заново с начала
+	if len(store.opens) != 2 || store.opens[0] != 0 || store.opens[1] != 0 {
+		t.Errorf("opens = %v, want [0 0]", store.opens)
+	}
+}
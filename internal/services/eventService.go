@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"tfidf-app/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// События жизненного цикла, на которые можно подписать вебхук
+const (
+	EventDocumentCreated   = "document.created"
+	EventDocumentDeleted   = "document.deleted"
+	EventCollectionUpdated = "collection.updated"
+	EventStatisticsReady   = "statistics.ready"
+)
+
+// Что изменилось в коллекции (поле change события collection.updated)
+const (
+	CollectionRenamed         = "renamed"
+	CollectionDocumentAdded   = "document_added"
+	CollectionDocumentRemoved = "document_removed"
+)
+
+var WebhookEvents = []string{EventDocumentCreated, EventDocumentDeleted, EventCollectionUpdated, EventStatisticsReady}
+
+var ErrInvalidEvents = errors.New("events must be a non-empty list of document.created, document.deleted, collection.updated, statistics.ready")
+
+// Event - тело вебхука о событии
+type Event struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Данные событий document.created и document.deleted
+type DocumentEvent struct {
+	DocumentID uint   `json:"document_id"`
+	Name       string `json:"name"`
+	Version    int    `json:"version,omitempty"`
+	FiledInto  []uint `json:"filed_into,omitempty"` // коллекции, куда документ разложили классификаторы
+}
+
+// Данные события collection.updated
+type CollectionEvent struct {
+	CollectionID uint   `json:"collection_id"`
+	Name         string `json:"name"`
+	Change       string `json:"change"`
+	DocumentID   uint   `json:"document_id,omitempty"`
+}
+
+// Данные события statistics.ready: посчитана статистика документа или коллекции
+type StatisticsEvent struct {
+	Kind    string            `json:"kind"` // document или collection
+	ID      uint              `json:"id"`
+	Name    string            `json:"name"`
+	Summary LinguisticSummary `json:"summary"`
+}
+
+// ValidateEvents проверяет список событий подписки и убирает повторы
+func ValidateEvents(events []string) (models.EventTypes, error) {
+	if len(events) == 0 {
+		return nil, ErrInvalidEvents
+	}
+
+	valid := models.EventTypes{}
+	for _, event := range events {
+		if !models.EventTypes(WebhookEvents).Has(event) {
+			return nil, ErrInvalidEvents
+		}
+		if !valid.Has(event) {
+			valid = append(valid, event)
+		}
+	}
+	return valid, nil
+}
+
+// EmitEvent отправляет событие в поток /events пользователя и ставит его в очередь доставки
+// всем активным вебхукам пользователя, подписанным на него.
+// Ошибки только логируются: событие сопровождает уже выполненное действие и не должно его ломать.
+func EmitEvent(db *gorm.DB, userID int, event string, data any) {
+	PublishStream(userID, event, data)
+
+	var webhooks []models.Webhook
+	if err := db.Where("user_id = ? AND active = ?", userID, true).Find(&webhooks).Error; err != nil {
+		log.Printf("Failed to get webhooks for %s: %v", event, err)
+		return
+	}
+
+	payload := Event{Event: event, CreatedAt: time.Now(), Data: data}
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Events.Has(event) {
+			continue
+		}
+
+		delivery, err := NewWebhookDelivery(userID, webhook.URL, event, payload)
+		if err != nil {
+			log.Printf("Failed to encode %s event: %v", event, err)
+			return
+		}
+		delivery.WebhookID = &webhook.ID
+		deliveries = append(deliveries, delivery)
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := db.Create(&deliveries).Error; err != nil {
+		log.Printf("Failed to queue %s event: %v", event, err)
+		return
+	}
+	WakeWebhookWorker()
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"tfidf-app/internal/config"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrFetchNotAllowed    = errors.New("url is not allowed")
+	ErrFetchFailed        = errors.New("failed to fetch url")
+	ErrUnsupportedContent = errors.New("unsupported content type")
+)
+
+// Сколько редиректов проходим при загрузке по ссылке
+const maxFetchRedirects = 5
+
+// StageURL скачивает страницу по ссылке и сохраняет ее текст так же, как загруженный файл.
+// Из HTML остается только читаемый текст, кодировка приводится к UTF-8.
+// Возвращает содержимое и имя документа по умолчанию (последний сегмент пути или хост).
+// progress (может быть nil) получает ход разбора скачанного текста.
+func StageURL(ctx context.Context, rawURL string, limit int64, progress *UploadProgress) (*StagedContent, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrFetchNotAllowed, err)
+	}
+	if err := checkFetchURL(u); err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrFetchNotAllowed, err)
+	}
+	req.Header.Set("User-Agent", "tfidf-app")
+	req.Header.Set("Accept", "text/html, text/plain;q=0.9, */*;q=0.1")
+
+	resp, err := newFetchClient().Do(req)
+	if err != nil {
+		if errors.Is(err, ErrFetchNotAllowed) {
+			return nil, "", err
+		}
+		return nil, "", fmt.Errorf("%w: %v", ErrFetchFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("%w: status %d", ErrFetchFailed, resp.StatusCode)
+	}
+	if limit > 0 && resp.ContentLength > limit {
+		return nil, "", ErrTooLarge
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType := MediaType(contentType)
+	if !isTextMediaType(mediaType) {
+		return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedContent, mediaType)
+	}
+
+	// Сервер может не прислать Content-Length, поэтому ограничиваем и само тело
+	var body io.Reader = resp.Body
+	if limit > 0 {
+		body = &capReader{r: body, n: limit}
+	}
+	body, err = charset.NewReader(body, contentType)
+	if errors.Is(err, ErrTooLarge) {
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedContent, err)
+	}
+
+	var staged *StagedContent
+	if isHTMLMediaType(mediaType) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(ExtractHTMLText(pw, body))
+		}()
+		// Размер извлеченного текста заранее неизвестен, прогресс будет в байтах
+		staged, err = StageContent(pr, limit, progress)
+		pr.Close()
+	} else {
+		if progress != nil && resp.ContentLength > 0 {
+			progress.Total = resp.ContentLength
+		}
+		staged, err = StageContent(body, limit, progress)
+	}
+	if err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return nil, "", ErrTooLarge
+		}
+		return nil, "", fmt.Errorf("%w: %v", ErrFetchFailed, err)
+	}
+
+	// Из HTML сохранен только текст, его тип определен по содержимому.
+	// Остальное сохранено как есть, и сервер лучше знает его тип.
+	if mediaType != "" && !isHTMLMediaType(mediaType) {
+		staged.MimeType = mediaType
+	}
+	staged.Source = u.String()
+
+	// Имя по адресу, на который привели редиректы
+	return staged, nameFromURL(resp.Request.URL), nil
+}
+
+// ExtractHTMLText пишет в w текст страницы без разметки, скриптов и стилей.
+// Блочные элементы отделяются переводами строк, чтобы слова соседних блоков не склеивались.
+func ExtractHTMLText(w io.Writer, r io.Reader) error {
+	z := html.NewTokenizer(r)
+	skip := 0
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			if _, err := w.Write(z.Text()); err != nil {
+				return err
+			}
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if hiddenHTMLTags[tag] {
+				skip++
+			} else if blockHTMLTags[tag] && skip == 0 {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			if hiddenHTMLTags[tag] && skip > 0 {
+				skip--
+			} else if blockHTMLTags[tag] && skip == 0 {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+		case html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if blockHTMLTags[string(name)] && skip == 0 {
+				if _, err := io.WriteString(w, "\n"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Элементы, текст которых пользователь не видит
+var hiddenHTMLTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true, "iframe": true,
+}
+
+// Элементы, после которых текст начинается с новой строки
+var blockHTMLTags = map[string]bool{
+	"title": true, "p": true, "div": true, "br": true, "hr": true, "li": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"table": true, "tr": true, "td": true, "th": true, "blockquote": true, "pre": true,
+	"section": true, "article": true, "header": true, "footer": true, "nav": true, "aside": true, "main": true,
+}
+
+func isHTMLMediaType(mediaType string) bool {
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case mediaType == "", strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/xhtml+xml", mediaType == "application/json", mediaType == "application/xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// checkFetchURL пропускает только http(s) и хосты из FETCH_ALLOWLIST, если он задан
+func checkFetchURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https are supported", ErrFetchNotAllowed)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%w: host is required", ErrFetchNotAllowed)
+	}
+	if len(config.Init.FetchAllowlist) > 0 && !isAllowlistedHost(u.Hostname()) {
+		return fmt.Errorf("%w: host %s is not in the allowlist", ErrFetchNotAllowed, u.Hostname())
+	}
+	return nil
+}
+
+// isAllowlistedHost: хост есть в FETCH_ALLOWLIST
+func isAllowlistedHost(host string) bool {
+	return hostInList(host, config.Init.FetchAllowlist)
+}
+
+// hostInList: точное совпадение или поддомен для записей вида "*.example.com"
+func hostInList(host string, list []string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range list {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// newFetchClient - клиент с таймаутом и проверкой каждого редиректа.
+// Во внутреннюю сеть (localhost, частные адреса) ходим, только если хост явно есть в FETCH_ALLOWLIST.
+func newFetchClient() *http.Client {
+	transport := &http.Transport{
+		DialContext:           publicDialContext(config.Init.FetchTimeout, config.Init.FetchAllowlist),
+		TLSHandshakeTimeout:   config.Init.FetchTimeout,
+		ResponseHeaderTimeout: config.Init.FetchTimeout,
+	}
+
+	return &http.Client{
+		Timeout:   config.Init.FetchTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("%w: too many redirects", ErrFetchFailed)
+			}
+			return checkFetchURL(req.URL)
+		},
+	}
+}
+
+// publicDialContext соединяется только с публичными адресами, хостам из allowlist можно и во внутреннюю сеть.
+// Адрес проверяется после резолвинга, поэтому DNS-имя, указывающее на localhost, не пройдет.
+func publicDialContext(timeout time.Duration, allowlist []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if hostInList(host, allowlist) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if !isPublicIP(ip.IP) {
+				return nil, fmt.Errorf("%w: %s resolves to a private address", ErrFetchNotAllowed, host)
+			}
+		}
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// nameFromURL - последний сегмент пути, а для корня сайта - хост
+func nameFromURL(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "/" || name == "." || name == "" {
+		return u.Hostname()
+	}
+	return name
+}
+
+// capReader отдает не больше n байт, а на лишних возвращает ErrTooLarge
+type capReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	if c.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > c.n+1 {
+		p = p[:c.n+1]
+	}
+	n, err := c.r.Read(p)
+	c.n -= int64(n)
+	if c.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
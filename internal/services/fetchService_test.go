@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"tfidf-app/internal/config"
+	"time"
+)
+
+// withFetchConfig задает FETCH_ALLOWLIST и временную папку загрузок на время теста
+func withFetchConfig(t *testing.T, allowlist ...string) {
+	t.Helper()
+
+	saved := config.Init
+	t.Cleanup(func() { config.Init = saved })
+
+	config.Init.UploadsDir = t.TempDir()
+	config.Init.FetchAllowlist = allowlist
+	config.Init.FetchTimeout = 5 * time.Second
+}
+
+// newPageServer - локальная замена внешнего сайта, считает пришедшие запросы
+func newPageServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func readStaged(t *testing.T, staged *StagedContent) string {
+	t.Helper()
+
+	data, err := os.ReadFile(staged.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStageURLExtractsHTMLText(t *testing.T) {
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		// "Привет" в windows-1251
+		w.Write([]byte("<html><head><title>Page</title><script>var hidden = 1;</script></head><body><p>\xcf\xf0\xe8\xe2\xe5\xf2</p><p>world</p></body></html>"))
+	})
+	withFetchConfig(t, "127.0.0.1")
+
+	staged, name, err := StageURL(context.Background(), srv.URL+"/articles/report.html", 0, nil)
+	if err != nil {
+		t.Fatalf("StageURL: %v", err)
+	}
+	defer staged.Remove()
+
+	text := readStaged(t, staged)
+	if strings.Contains(text, "hidden") {
+		t.Errorf("script text was kept: %q", text)
+	}
+	for _, word := range []string{"Page", "Привет", "world"} {
+		if !strings.Contains(text, word) {
+			t.Errorf("text %q has no %q", text, word)
+		}
+	}
+	if name != "report.html" {
+		t.Errorf("name = %q, want report.html", name)
+	}
+	if staged.Source != srv.URL+"/articles/report.html" {
+		t.Errorf("source = %q", staged.Source)
+	}
+	if staged.Counts.Counts["привет"] != 1 {
+		t.Errorf("counts = %v", staged.Counts.Counts)
+	}
+}
+
+func TestStageURLRejectsHostNotInAllowlist(t *testing.T) {
+	srv, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	})
+	withFetchConfig(t, "example.com", "*.example.org")
+
+	_, _, err := StageURL(context.Background(), srv.URL, 0, nil)
+	if !errors.Is(err, ErrFetchNotAllowed) {
+		t.Fatalf("err = %v, want ErrFetchNotAllowed", err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("server got %d requests", hits.Load())
+	}
+}
+
+func TestStageURLRejectsLoopbackWithoutAllowlist(t *testing.T) {
+	srv, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	})
+	withFetchConfig(t)
+
+	for _, target := range []string{srv.URL, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)} {
+		_, _, err := StageURL(context.Background(), target, 0, nil)
+		if !errors.Is(err, ErrFetchNotAllowed) {
+			t.Errorf("%s: err = %v, want ErrFetchNotAllowed", target, err)
+		}
+	}
+	if hits.Load() != 0 {
+		t.Errorf("server got %d requests", hits.Load())
+	}
+}
+
+func TestStageURLRejectsRedirectToDisallowedHost(t *testing.T) {
+	internal, internalHits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	})
+	// Тот же адрес, но под именем, которого нет в списке
+	target := strings.Replace(internal.URL, "127.0.0.1", "localhost", 1) + "/admin"
+
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+	withFetchConfig(t, "127.0.0.1")
+
+	_, _, err := StageURL(context.Background(), srv.URL, 0, nil)
+	if !errors.Is(err, ErrFetchNotAllowed) {
+		t.Fatalf("err = %v, want ErrFetchNotAllowed", err)
+	}
+	if internalHits.Load() != 0 {
+		t.Errorf("redirect target got %d requests", internalHits.Load())
+	}
+}
+
+func TestStageURLBodyOverLimitWithoutContentLength(t *testing.T) {
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// И текст, и HTML (его тело идет через извлечение текста)
+		chunk := strings.Repeat("word ", 20)
+		if r.URL.Path == "/page" {
+			w.Header().Set("Content-Type", "text/html")
+			chunk = "<p>" + chunk + "</p>"
+		} else {
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		// Flush до конца ответа - тело уходит chunked, без Content-Length
+		for i := 0; i < 100; i++ {
+			w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+	})
+	withFetchConfig(t, "127.0.0.1")
+
+	for _, path := range []string{"/plain", "/page"} {
+		staged, _, err := StageURL(context.Background(), srv.URL+path, 1000, nil)
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: err = %v, want ErrTooLarge", path, err)
+		}
+		if staged != nil {
+			staged.Remove()
+		}
+	}
+
+	entries, err := os.ReadDir(config.Init.UploadsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("temporary files left: %d", len(entries))
+	}
+}
+
+func TestStageURLBodyOverLimitWithContentLength(t *testing.T) {
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", 2000)))
+	})
+	withFetchConfig(t, "127.0.0.1")
+
+	if _, _, err := StageURL(context.Background(), srv.URL, 1000, nil); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("err = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestStageURLRejectsNonTextContent(t *testing.T) {
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG\r\n\x1a\n"))
+	})
+	withFetchConfig(t, "127.0.0.1")
+
+	if _, _, err := StageURL(context.Background(), srv.URL+"/logo.png", 0, nil); !errors.Is(err, ErrUnsupportedContent) {
+		t.Errorf("err = %v, want ErrUnsupportedContent", err)
+	}
+}
+
+func TestStageURLRejectsBadSchemes(t *testing.T) {
+	withFetchConfig(t)
+
+	for _, target := range []string{"file:///etc/passwd", "ftp://example.com/a.txt", "http:///no-host", "://bad"} {
+		if _, _, err := StageURL(context.Background(), target, 0, nil); !errors.Is(err, ErrFetchNotAllowed) {
+			t.Errorf("%s: err = %v, want ErrFetchNotAllowed", target, err)
+		}
+	}
+}
+
+func TestExtractHTMLText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "script and style are dropped",
+			html: `<head><style>p { color: red }</style></head><body>visible<script>alert("x")</script> text</body>`,
+			want: "visible text",
+		},
+		{
+			name: "nested hidden elements",
+			html: `<noscript><div>no <b>js</b></div></noscript>after`,
+			want: "after",
+		},
+		{
+			name: "block elements on separate lines",
+			html: `<h1>Title</h1><p>first</p><p>second</p><ul><li>one</li><li>two</li></ul>`,
+			want: "Title\nfirst\nsecond\none\ntwo",
+		},
+		{
+			name: "br and inline elements",
+			html: `line<br/>next <b>bold</b><span>glued</span>`,
+			want: "line\nnext boldglued",
+		},
+		{
+			name: "table cells",
+			html: `<table><tr><td>a</td><td>b</td></tr></table>`,
+			want: "a\nb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := ExtractHTMLText(&b, strings.NewReader(tt.html)); err != nil {
+				t.Fatal(err)
+			}
+			if got := collapseLines(b.String()); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// collapseLines убирает пустые строки: сколько переводов строк пишет каждый блок, неважно
+func collapseLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCapReader(t *testing.T) {
+	r := &capReader{r: strings.NewReader("12345"), n: 5}
+	buf := make([]byte, 10)
+	n, err := r.Read(buf)
+	if n != 5 || (err != nil && !errors.Is(err, io.EOF)) {
+		t.Fatalf("exactly the limit: n=%d err=%v", n, err)
+	}
+
+	r = &capReader{r: strings.NewReader("123456"), n: 5}
+	var readErr error
+	for readErr == nil {
+		_, readErr = r.Read(buf)
+	}
+	if !errors.Is(readErr, ErrTooLarge) {
+		t.Errorf("over the limit: err = %v, want ErrTooLarge", readErr)
+	}
+}
+
+func TestHostInList(t *testing.T) {
+	list := []string{"Example.com", "*.docs.org"}
+	tests := map[string]bool{
+		"example.com":     true,
+		"EXAMPLE.COM":     true,
+		"sub.example.com": false,
+		"docs.org":        true,
+		"a.docs.org":      true,
+		"a.b.docs.org":    true,
+		"evildocs.org":    false,
+		"127.0.0.1":       false,
+	}
+	for host, want := range tests {
+		if got := hostInList(host, list); got != want {
+			t.Errorf("hostInList(%q) = %v, want %v", host, got, want)
+		}
+	}
+
+	u, _ := url.Parse("http://a.docs.org:8080/x")
+	if !hostInList(u.Hostname(), list) {
+		t.Error("port must not affect the match")
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"html/template"
+	"io"
+	"math"
+	"sort"
+)
+
+// На сколько ступеней яркости делятся выделенные слова
+const HighlightLevels = 5
+
+// Вес слова в документе
+type TermWeight struct {
+	Word  string  `json:"word"`
+	TFIDF float64 `json:"tf_idf"`
+	Level int     `json:"level"`
+}
+
+// Кусок текста документа: слово или текст между словами
+type HighlightSegment struct {
+	Text  string  `json:"text"`           // как в документе, с исходным регистром
+	Word  string  `json:"word,omitempty"` // слово в нижнем регистре, пусто для текста между словами
+	TFIDF float64 `json:"tf_idf,omitempty"`
+	Level int     `json:"level,omitempty"` // 1..HighlightLevels для топ-слов, 0 - не выделяется
+}
+
+type Highlight struct {
+	TopTerms []TermWeight       `json:"top_terms"`
+	Segments []HighlightSegment `json:"segments"`
+}
+
+// HighlightDocument режет текст на слова тем же токенайзером, что и при подсчете TF,
+// и проставляет каждому слову его TF-IDF. top самых весомых слов получают уровень выделения.
+func HighlightDocument(content []byte, profile map[string]float64, top int) Highlight {
+	terms := make([]TermWeight, 0, len(profile))
+	for word, weight := range profile {
+		terms = append(terms, TermWeight{Word: word, TFIDF: weight})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].TFIDF != terms[j].TFIDF {
+			return terms[i].TFIDF > terms[j].TFIDF
+		}
+		return terms[i].Word < terms[j].Word
+	})
+	if len(terms) > top {
+		terms = terms[:top]
+	}
+
+	// Уровень - доля от веса самого весомого слова
+	levels := make(map[string]int, len(terms))
+	for i := range terms {
+		level := 1
+		if maxWeight := terms[0].TFIDF; maxWeight > 0 {
+			level = int(math.Ceil(terms[i].TFIDF / maxWeight * HighlightLevels))
+		}
+		terms[i].Level = max(1, min(level, HighlightLevels))
+		levels[terms[i].Word] = terms[i].Level
+	}
+
+	var segments []HighlightSegment
+	var offset int64
+	for _, token := range Tokenize(content) {
+		if token.Start > offset {
+			segments = append(segments, HighlightSegment{Text: string(content[offset:token.Start])})
+		}
+		segments = append(segments, HighlightSegment{
+			Text:  string(content[token.Start:token.End]),
+			Word:  token.Word,
+			TFIDF: profile[token.Word],
+			Level: levels[token.Word],
+		})
+		offset = token.End
+	}
+	if offset < int64(len(content)) {
+		segments = append(segments, HighlightSegment{Text: string(content[offset:])})
+	}
+
+	return Highlight{TopTerms: terms, Segments: segments}
+}
+
+var highlightTemplate = template.Must(template.New("highlight").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.text { white-space: pre-wrap; line-height: 1.6; }
+mark { border-radius: 3px; padding: 0 2px; background: rgba(255, 140, 0, 0.2); }
+mark.tfidf-2 { background: rgba(255, 140, 0, 0.35); }
+mark.tfidf-3 { background: rgba(255, 120, 0, 0.5); }
+mark.tfidf-4 { background: rgba(255, 90, 0, 0.7); }
+mark.tfidf-5 { background: rgba(255, 60, 0, 0.9); color: #fff; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{range .Highlight.TopTerms}}<mark class="tfidf-{{.Level}}" title="{{printf "%.6f" .TFIDF}}">{{.Word}}</mark> {{end}}</p>
+<div class="text">{{range .Highlight.Segments}}{{if .Level}}<mark class="tfidf-{{.Level}}" title="{{printf "%.6f" .TFIDF}}">{{.Text}}</mark>{{else}}{{.Text}}{{end}}{{end}}</div>
+</body>
+</html>
+`))
+
+// RenderHighlightHTML рисует текст с топ-словами в <mark class="tfidf-N">, чем выше N, тем ярче
+func RenderHighlightHTML(w io.Writer, title string, highlight Highlight) error {
+	return highlightTemplate.Execute(w, struct {
+		Title     string
+		Highlight Highlight
+	}{title, highlight})
+}
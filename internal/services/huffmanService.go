@@ -1,9 +1,13 @@
 package services
 
 import (
+	"bytes"
 	"container/heap"
+	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"tfidf-app/internal/models"
 )
 
 type Node struct {
@@ -144,3 +148,65 @@ func HuffmanDecoding(bits string, root *Node) ([]byte, error) {
 	}
 	return out, nil
 }
+
+// HuffmanCode - закодированное содержимое, упакованное по 8 бит в байт.
+// В кэше оно в 8 раз меньше строки из "0" и "1", которую отдает API.
+type HuffmanCode struct {
+	Bits int    `json:"bits"`
+	Data []byte `json:"data"`
+}
+
+// PackHuffmanBits упаковывает строку из "0" и "1", старший бит байта - первый
+func PackHuffmanBits(bits string) HuffmanCode {
+	code := HuffmanCode{Bits: len(bits), Data: make([]byte, (len(bits)+7)/8)}
+	for i := 0; i < len(bits); i++ {
+		if bits[i] == '1' {
+			code.Data[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return code
+}
+
+// String возвращает биты строкой из "0" и "1"
+func (code HuffmanCode) String() string {
+	var b strings.Builder
+	b.Grow(code.Bits)
+	for i := 0; i < code.Bits; i++ {
+		if code.Data[i/8]&(0x80>>(i%8)) != 0 {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+// Читает документ, кодирует и проверяет декодированием только при промахе кэша,
+// повторные запросы того же содержимого отдаются из кэша по хэшу без чтения файла
+func HuffmanEncodingCached(ctx context.Context, document models.Document) (string, error) {
+	code, err := GetOrCompute(DocumentCache, document.ContentHash, CacheKindHuffman, func() (HuffmanCode, error) {
+		content, err := ReadDocumentContent(ctx, document)
+		if err != nil {
+			return HuffmanCode{}, err
+		}
+
+		encoded, root, err := HuffmanEncoding(content)
+		if err != nil {
+			return HuffmanCode{}, fmt.Errorf("failed to encode the content: %w", err)
+		}
+
+		decoded, err := HuffmanDecoding(encoded, root)
+		if err != nil {
+			return HuffmanCode{}, fmt.Errorf("decoding error: %w", err)
+		}
+		if !bytes.Equal(decoded, content) {
+			return HuffmanCode{}, errors.New("decoded content doesn't match original")
+		}
+
+		return PackHuffmanBits(encoded), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return code.String(), nil
+}
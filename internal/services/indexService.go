@@ -0,0 +1,516 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidTerm = errors.New("term must be a single word")
+
+// Postings - вхождения слов, накопленные в памяти: для каждого слова все его вхождения по порядку
+type Postings map[string]models.TermPositions
+
+const (
+	// Сколько записей индекса вставляем за один запрос
+	postingsBatchSize = 500
+	// И сколько байт закодированных вхождений в них, чтобы частые слова большого текста не раздували запрос
+	postingsBatchBytes = 8 << 20
+	// Сколько вхождений PostingsSpool держит в памяти (около 24 байт каждое), прежде чем сбросить их на диск
+	maxBufferedPositions = 1 << 20
+)
+
+// PostingsSpool строит позиционный индекс содержимого любого размера в ограниченной памяти.
+// Вхождения копятся в памяти, а набрав maxBufferedPositions, сбрасываются во временный файл-кусок:
+// слова по алфавиту и их закодированные вхождения. Кусок кодируется от последнего вхождения слова
+// в предыдущих кусках, поэтому при сохранении куски одного слова просто склеиваются по порядку.
+type PostingsSpool struct {
+	dir   string
+	limit int
+
+	buffer   Postings
+	buffered int
+	last     map[string]models.TermPosition // последнее уже сброшенное вхождение слова
+	runs     []string                       // файлы кусков в порядке сброса
+	err      error
+}
+
+// NewPostingsSpool создает пустой индекс, куски сбрасываются в папку dir
+func NewPostingsSpool(dir string) *PostingsSpool {
+	return &PostingsSpool{
+		dir:    dir,
+		limit:  maxBufferedPositions,
+		buffer: make(Postings),
+		last:   make(map[string]models.TermPosition),
+	}
+}
+
+// Add добавляет вхождение слова, токены приходят по порядку. Ошибку сброса на диск вернет Err
+func (s *PostingsSpool) Add(token Token) {
+	if s.err != nil {
+		return
+	}
+
+	s.buffer[token.Word] = append(s.buffer[token.Word], models.TermPosition{Pos: token.Pos, Start: token.Start, End: token.End})
+	s.buffered++
+	if s.buffered >= s.limit {
+		s.err = s.spill()
+	}
+}
+
+// Err - ошибка сброса вхождений на диск, после нее индекс неполный
+func (s *PostingsSpool) Err() error {
+	return s.err
+}
+
+// Remove удаляет файлы кусков
+func (s *PostingsSpool) Remove() {
+	for _, path := range s.runs {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove postings file %s: %v", path, err)
+		}
+	}
+	s.runs = nil
+}
+
+// spill сбрасывает накопленные вхождения в новый файл-кусок
+func (s *PostingsSpool) spill() error {
+	if err := os.MkdirAll(s.dir, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, "postings-*.tmp")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	var record []byte
+	for _, term := range sortedTerms(s.buffer) {
+		record = s.appendRecord(record[:0], term)
+		if _, err := w.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	s.buffer = make(Postings)
+	s.buffered = 0
+	return nil
+}
+
+// appendRecord кодирует накопленные вхождения слова: длина слова, слово, число вхождений,
+// длина закодированных вхождений и они сами
+func (s *PostingsSpool) appendRecord(record []byte, term string) []byte {
+	positions := s.buffer[term]
+	encoded := positions.AppendEncoded(nil, s.last[term])
+	s.last[term] = positions[len(positions)-1]
+
+	record = binary.AppendUvarint(record, uint64(len(term)))
+	record = append(record, term...)
+	record = binary.AppendUvarint(record, uint64(len(positions)))
+	record = binary.AppendUvarint(record, uint64(len(encoded)))
+	return append(record, encoded...)
+}
+
+// postingRecord - вхождения слова из одного куска
+type postingRecord struct {
+	term      string
+	count     int
+	positions []byte // закодированы как models.TermPositions
+}
+
+// each отдает слова по алфавиту с числом вхождений и всеми вхождениями, закодированными как models.TermPositions.
+// В памяти одновременно только вхождения одного слова.
+func (s *PostingsSpool) each(fn func(record postingRecord) error) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	var runs []func() (*postingRecord, error)
+	for _, path := range s.runs {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		runs = append(runs, fileRun(bufio.NewReader(f)))
+	}
+	// Несброшенный остаток - последний кусок
+	runs = append(runs, s.memoryRun())
+
+	heads := make([]*postingRecord, len(runs))
+	for i, next := range runs {
+		var err error
+		if heads[i], err = next(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var term string
+		found := false
+		for _, head := range heads {
+			if head != nil && (!found || head.term < term) {
+				term, found = head.term, true
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		// Куски идут в порядке сброса, значит и вхождения склеиваются по порядку
+		merged := postingRecord{term: term}
+		for i, head := range heads {
+			if head == nil || head.term != term {
+				continue
+			}
+			merged.count += head.count
+			merged.positions = append(merged.positions, head.positions...)
+
+			var err error
+			if heads[i], err = runs[i](); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(merged); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *PostingsSpool) memoryRun() func() (*postingRecord, error) {
+	terms := sortedTerms(s.buffer)
+	return func() (*postingRecord, error) {
+		if len(terms) == 0 {
+			return nil, nil
+		}
+		term := terms[0]
+		terms = terms[1:]
+
+		positions := s.buffer[term]
+		return &postingRecord{term: term, count: len(positions), positions: positions.AppendEncoded(nil, s.last[term])}, nil
+	}
+}
+
+// fileRun читает записи куска, записанные appendRecord
+func fileRun(r *bufio.Reader) func() (*postingRecord, error) {
+	return func() (*postingRecord, error) {
+		termLen, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		term := make([]byte, termLen)
+		if _, err := io.ReadFull(r, term); err != nil {
+			return nil, err
+		}
+		count, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		positions := make([]byte, size)
+		if _, err := io.ReadFull(r, positions); err != nil {
+			return nil, err
+		}
+		return &postingRecord{term: string(term), count: int(count), positions: positions}, nil
+	}
+}
+
+func sortedTerms(postings Postings) []string {
+	terms := make([]string, 0, len(postings))
+	for term := range postings {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// termPostingRow - запись term_postings с уже закодированными вхождениями:
+// склеенные куски не нужно раскодировать в models.TermPositions ради вставки
+type termPostingRow struct {
+	ContentHash string
+	Term        string
+	Count       int
+	Positions   []byte
+}
+
+func (termPostingRow) TableName() string {
+	return "term_postings"
+}
+
+// SaveContentIndex сохраняет позиционный индекс содержимого, если его еще нет.
+// Индекс общий для всех документов и версий с таким хэшем.
+func SaveContentIndex(tx *gorm.DB, hash string, postings *PostingsSpool) error {
+	var exists int64
+	if err := tx.Model(&models.TermPosting{}).Where("content_hash = ?", hash).Limit(1).Count(&exists).Error; err != nil {
+		return err
+	}
+	if exists > 0 {
+		return markContentIndexed(tx, hash)
+	}
+
+	rows := make([]termPostingRow, 0, postingsBatchSize)
+	size := 0
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+		rows, size = rows[:0], 0
+		return err
+	}
+
+	err := postings.each(func(record postingRecord) error {
+		rows = append(rows, termPostingRow{ContentHash: hash, Term: record.term, Count: record.count, Positions: record.positions})
+		size += len(record.positions)
+		if len(rows) >= postingsBatchSize || size >= postingsBatchBytes {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return err
+	}
+	return markContentIndexed(tx, hash)
+}
+
+// markContentIndexed отмечает blob проиндексированным. По наличию записей в term_postings
+// этого не понять: у текста без слов индекс пустой.
+func markContentIndexed(tx *gorm.DB, hash string) error {
+	return tx.Model(&models.Blob{}).Where("hash = ?", hash).Update("indexed", true).Error
+}
+
+// DeleteContentIndex удаляет индекс содержимого, на которое больше никто не ссылается
+func DeleteContentIndex(tx *gorm.DB, hash string) error {
+	return tx.Where("content_hash = ?", hash).Delete(&models.TermPosting{}).Error
+}
+
+// FindTermPositions возвращает вхождения слова в содержимое с таким хэшем.
+// Если слова в тексте нет, вернется пустой список.
+func FindTermPositions(db *gorm.DB, hash, term string) (models.TermPositions, error) {
+	var posting models.TermPosting
+	err := db.Where("content_hash = ? AND term = ?", hash, term).Limit(1).Find(&posting).Error
+	return posting.Positions, err
+}
+
+// Concordance - вхождение слова вместе с окружающими его словами (KWIC)
+type Concordance struct {
+	Position int    `json:"position"` // номер слова в тексте
+	Start    int64  `json:"start"`    // смещение слова в байтах
+	End      int64  `json:"end"`
+	Left     string `json:"left"`
+	Match    string `json:"match"`
+	Right    string `json:"right"`
+	// Границы всего фрагмента вместе с контекстом
+	ContextStart int64 `json:"context_start"`
+	ContextEnd   int64 `json:"context_end"`
+}
+
+// BuildConcordance вырезает для каждого вхождения window слов слева и справа.
+// Фрагменты берутся из исходного текста как есть, с пунктуацией и регистром.
+func BuildConcordance(content []byte, positions models.TermPositions, window int) []Concordance {
+	if len(positions) == 0 {
+		return []Concordance{}
+	}
+
+	tokens := Tokenize(content)
+	lines := make([]Concordance, 0, len(positions))
+	for _, position := range positions {
+		// Индекс устарел относительно содержимого - такого быть не должно, но и падать незачем
+		if position.Pos >= len(tokens) || tokens[position.Pos].Start != position.Start {
+			continue
+		}
+
+		first := tokens[max(position.Pos-window, 0)]
+		last := tokens[min(position.Pos+window, len(tokens)-1)]
+
+		lines = append(lines, Concordance{
+			Position:     position.Pos,
+			Start:        position.Start,
+			End:          position.End,
+			Left:         string(content[first.Start:position.Start]),
+			Match:        string(content[position.Start:position.End]),
+			Right:        string(content[position.End:last.End]),
+			ContextStart: first.Start,
+			ContextEnd:   last.End,
+		})
+	}
+	return lines
+}
+
+// NormalizeTerm приводит слово запроса к виду, в котором оно лежит в индексе
+func NormalizeTerm(term string) (string, error) {
+	tokens := Tokenize([]byte(term))
+	if len(tokens) != 1 {
+		return "", ErrInvalidTerm
+	}
+	return tokens[0].Word, nil
+}
+
+// DocumentConcordance - вхождения слова в один документ
+type DocumentConcordance struct {
+	DocumentID   uint          `json:"document_id"`
+	DocumentName string        `json:"document_name"`
+	Count        int           `json:"count"` // сколько всего вхождений, даже если в lines попали не все
+	Lines        []Concordance `json:"lines"`
+}
+
+type ConcordanceResult struct {
+	Term      string                `json:"term"`
+	Window    int                   `json:"window"`
+	Total     int                   `json:"total"`
+	Truncated bool                  `json:"truncated"` // вхождений больше, чем limit
+	Documents []DocumentConcordance `json:"documents"`
+}
+
+// FindConcordance ищет слово в документах по позиционному индексу.
+// Содержимое читается только у документов, где слово есть, и только пока не набрано limit строк.
+func FindConcordance(ctx context.Context, db *gorm.DB, documents []models.Document, term string, window, limit int) (ConcordanceResult, error) {
+	result := ConcordanceResult{Term: term, Window: window, Documents: []DocumentConcordance{}}
+
+	hashes := make([]string, 0, len(documents))
+	for _, document := range documents {
+		hashes = append(hashes, document.ContentHash)
+	}
+
+	var postings []models.TermPosting
+	if len(hashes) > 0 {
+		if err := db.Where("term = ? AND content_hash IN ?", term, hashes).Find(&postings).Error; err != nil {
+			return result, err
+		}
+	}
+	positionsByHash := make(map[string]models.TermPositions, len(postings))
+	for _, posting := range postings {
+		positionsByHash[posting.ContentHash] = posting.Positions
+	}
+
+	remaining := limit
+	for _, document := range documents {
+		positions := positionsByHash[document.ContentHash]
+		if len(positions) == 0 {
+			continue
+		}
+
+		entry := DocumentConcordance{
+			DocumentID:   document.ID,
+			DocumentName: document.Name,
+			Count:        len(positions),
+			Lines:        []Concordance{},
+		}
+		result.Total += len(positions)
+
+		if remaining > 0 {
+			content, err := ReadDocumentContent(ctx, document)
+			if err != nil {
+				return result, err
+			}
+			entry.Lines = BuildConcordance(content, positions[:min(remaining, len(positions))], window)
+			remaining -= len(entry.Lines)
+		}
+		if len(entry.Lines) < entry.Count {
+			result.Truncated = true
+		}
+
+		result.Documents = append(result.Documents, entry)
+	}
+
+	return result, nil
+}
+
+// BackfillContentIndex строит позиционный индекс содержимому, загруженному до его появления
+func BackfillContentIndex(ctx context.Context) {
+	// Blob'ы, проиндексированные до появления флага, отмечаем по их записям в term_postings
+	err := database.DB.Model(&models.Blob{}).
+		Where("NOT indexed AND EXISTS (SELECT 1 FROM term_postings WHERE term_postings.content_hash = blobs.hash)").
+		Update("indexed", true).Error
+	if err != nil {
+		log.Printf("Failed to mark indexed blobs: %v", err)
+		return
+	}
+
+	var blobs []models.Blob
+	if err := database.DB.Where("NOT indexed").Find(&blobs).Error; err != nil {
+		log.Printf("Failed to get blobs without index: %v", err)
+		return
+	}
+
+	indexed := 0
+	for _, blob := range blobs {
+		postings, err := indexStoredContent(ctx, blob)
+		if err != nil {
+			log.Printf("Failed to read blob %s for indexing: %v", blob.Hash, err)
+			continue
+		}
+
+		// В транзакции: индекс, оборванный на середине, не должен выглядеть построенным
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			return SaveContentIndex(tx, blob.Hash, postings)
+		})
+		postings.Remove()
+		if err != nil {
+			log.Printf("Failed to index blob %s: %v", blob.Hash, err)
+			continue
+		}
+		indexed++
+	}
+
+	if indexed > 0 {
+		log.Printf("INFO: Positional index built for %d blobs.", indexed)
+	}
+}
+
+// indexStoredContent строит позиционный индекс blob'а, читая его из хранилища потоком
+func indexStoredContent(ctx context.Context, blob models.Blob) (*PostingsSpool, error) {
+	rc, err := storage.Files.Get(ctx, blob.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	content, err := NewDecompressReader(rc, blob.Compression)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	postings := NewPostingsSpool(config.Init.UploadsDir)
+	tokenizer := NewTokenizer(postings.Add)
+	_, err = io.Copy(tokenizer, content)
+	tokenizer.Close()
+	if err == nil {
+		err = postings.Err()
+	}
+	if err != nil {
+		postings.Remove()
+		return nil, err
+	}
+	return postings, nil
+}
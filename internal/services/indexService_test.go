@@ -0,0 +1,116 @@
+package services
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"tfidf-app/internal/models"
+)
+
+// spoolContents собирает все записи индекса, раскодируя вхождения
+func spoolContents(t *testing.T, spool *PostingsSpool) (terms []string, postings Postings) {
+	t.Helper()
+
+	postings = make(Postings)
+	err := spool.each(func(record postingRecord) error {
+		var positions models.TermPositions
+		if err := positions.Scan(record.positions); err != nil {
+			return err
+		}
+		if len(positions) != record.count {
+			t.Errorf("%s: count %d, decoded %d positions", record.term, record.count, len(positions))
+		}
+		terms = append(terms, record.term)
+		postings[record.term] = positions
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("each: %v", err)
+	}
+	return terms, postings
+}
+
+func TestPostingsSpoolSpillsToDisk(t *testing.T) {
+	text := strings.Repeat("мама мыла раму, а рама мыла маму. ", 50) + "конец"
+	tokens := Tokenize([]byte(text))
+
+	want := make(Postings)
+	for _, token := range tokens {
+		want[token.Word] = append(want[token.Word], models.TermPosition{Pos: token.Pos, Start: token.Start, End: token.End})
+	}
+
+	for _, limit := range []int{1, 7, 64, len(tokens), maxBufferedPositions} {
+		dir := t.TempDir()
+		spool := NewPostingsSpool(dir)
+		spool.limit = limit
+		for _, token := range tokens {
+			spool.Add(token)
+		}
+		if err := spool.Err(); err != nil {
+			t.Fatalf("limit %d: %v", limit, err)
+		}
+
+		if wantRuns := len(tokens) / limit; len(spool.runs) != wantRuns {
+			t.Errorf("limit %d: %d files, want %d", limit, len(spool.runs), wantRuns)
+		}
+
+		terms, got := spoolContents(t, spool)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("limit %d: postings differ from the in-memory ones", limit)
+		}
+		if !sortedStrings(terms) {
+			t.Errorf("limit %d: terms are not sorted: %v", limit, terms)
+		}
+
+		// Склеенные куски кодируются так же, как весь список вхождений сразу
+		spool.each(func(record postingRecord) error {
+			encoded, _ := want[record.term].Value()
+			if string(encoded.([]byte)) != string(record.positions) {
+				t.Errorf("limit %d: %s encoded differently", limit, record.term)
+			}
+			return nil
+		})
+
+		spool.Remove()
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("limit %d: %d files left after Remove", limit, len(entries))
+		}
+	}
+}
+
+func TestPostingsSpoolEmpty(t *testing.T) {
+	spool := NewPostingsSpool(t.TempDir())
+	terms, _ := spoolContents(t, spool)
+	if len(terms) != 0 {
+		t.Errorf("terms = %v", terms)
+	}
+}
+
+func TestStageContentPostings(t *testing.T) {
+	withFetchConfig(t)
+
+	staged, err := StageContent(strings.NewReader("один два один"), 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer staged.Remove()
+
+	_, postings := spoolContents(t, staged.Postings)
+	want := Postings{
+		"один": {{Pos: 0, Start: 0, End: 8}, {Pos: 2, Start: 16, End: 24}},
+		"два":  {{Pos: 1, Start: 9, End: 15}},
+	}
+	if !reflect.DeepEqual(postings, want) {
+		t.Errorf("postings = %v, want %v", postings, want)
+	}
+}
+
+func sortedStrings(values []string) bool {
+	for i := 1; i < len(values); i++ {
+		if values[i-1] >= values[i] {
+			return false
+		}
+	}
+	return true
+}
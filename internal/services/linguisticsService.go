@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"tfidf-app/internal/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+const CacheKindSentences = "sentences"
+
+// LinguisticSummary - сводка по тексту, считается по подсчету слов и числу предложений
+type LinguisticSummary struct {
+	Tokens            int          `json:"tokens"`              // всего слов
+	Types             int          `json:"types"`               // разных слов
+	TypeTokenRatio    float64      `json:"type_token_ratio"`    // types / tokens
+	Hapaxes           int          `json:"hapaxes"`             // слов, встретившихся один раз
+	AvgWordLength     float64      `json:"avg_word_length"`     // в буквах
+	Sentences         int          `json:"sentences"`           // предложений
+	AvgSentenceLength float64      `json:"avg_sentence_length"` // слов в предложении
+	ZipfExponent      float64      `json:"zipf_exponent"`       // s в f(r) ~ 1/r^s, подобран МНК в логарифмах
+	ZipfR2            float64      `json:"zipf_r2"`             // насколько хорошо прямая описывает частоты
+	Entropy           float64      `json:"entropy"`             // энтропия Шеннона распределения слов, в битах
+	Readability       *Readability `json:"readability,omitempty"`
+}
+
+// Readability - индекс удобочитаемости Флеша: для английского - исходная формула,
+// для русского - адаптация Оборневой. Чем больше, тем текст проще.
+type Readability struct {
+	Language            string  `json:"language"` // en или ru, по большинству слов
+	Formula             string  `json:"formula"`
+	Score               float64 `json:"score"`
+	Syllables           int     `json:"syllables"`
+	AvgSyllablesPerWord float64 `json:"avg_syllables_per_word"`
+}
+
+// CountDocumentText отдает подсчет слов и число предложений документа (оба через кэш по хэшу содержимого).
+// Файл читается, только если чего-то нет в кэше, и не больше одного раза.
+// computed = true значит в кэше чего-то не было и текст разобран заново.
+func CountDocumentText(ctx context.Context, document models.Document) (counts WordCounts, sentences int, computed bool, err error) {
+	var content []byte
+	read := func() ([]byte, error) {
+		computed = true
+		if content != nil {
+			return content, nil
+		}
+		var err error
+		content, err = ReadDocumentContent(ctx, document)
+		return content, err
+	}
+
+	counts, err = GetOrCompute(DocumentCache, document.ContentHash, CacheKindWordCounts, func() (WordCounts, error) {
+		content, err := read()
+		if err != nil {
+			return WordCounts{}, err
+		}
+		return countContentWords(content), nil
+	})
+	if err != nil {
+		return WordCounts{}, 0, false, err
+	}
+
+	sentences, err = GetOrCompute(DocumentCache, document.ContentHash, CacheKindSentences, func() (int, error) {
+		content, err := read()
+		if err != nil {
+			return 0, err
+		}
+		return CountSentences(content), nil
+	})
+	if err != nil {
+		return WordCounts{}, 0, false, err
+	}
+	return counts, sentences, computed, nil
+}
+
+// Sentence - предложение в исходном тексте, смещения в байтах
+type Sentence struct {
+	Start int64
+	End   int64
+}
+
+// SplitSentences делит текст на предложения: куски со словами, разделенные . ! ? или многоточием.
+// Предложение начинается с первого непробельного символа и заканчивается знаками препинания после него,
+// кусок без единого слова ("1.", "***") предложением не считается.
+// Сокращения вроде "т.е." тоже делят предложение - для сводки этой точности хватает.
+func SplitSentences(content []byte) []Sentence {
+	var sentences []Sentence
+	var offset int64
+	current := Sentence{Start: -1}
+	hasWord, terminated := false, false
+
+	for len(content) > 0 {
+		r, size := utf8.DecodeRune(content)
+		content = content[size:]
+		position := offset
+		offset += int64(size)
+
+		if unicode.IsSpace(r) {
+			continue
+		}
+		isTerminator := r == '.' || r == '!' || r == '?' || r == '…'
+
+		if terminated && !isTerminator {
+			sentences = append(sentences, current)
+			current = Sentence{Start: -1}
+			hasWord, terminated = false, false
+		}
+
+		if isTerminator {
+			if current.Start < 0 {
+				continue
+			}
+			current.End = offset
+			if hasWord {
+				terminated = true
+			} else {
+				current = Sentence{Start: -1}
+			}
+			continue
+		}
+
+		if current.Start < 0 {
+			current.Start = position
+		}
+		current.End = offset
+		hasWord = hasWord || isWordRune(r)
+	}
+
+	if current.Start >= 0 && hasWord {
+		sentences = append(sentences, current)
+	}
+	return sentences
+}
+
+// CountSentences считает предложения текста, как SplitSentences
+func CountSentences(content []byte) int {
+	return len(SplitSentences(content))
+}
+
+// Summarize строит сводку. Для коллекции counts - слова всех документов вместе, sentences - сумма.
+func Summarize(counts WordCounts, sentences int) LinguisticSummary {
+	summary := LinguisticSummary{
+		Tokens:    counts.Total,
+		Types:     len(counts.Counts),
+		Sentences: sentences,
+	}
+	if counts.Total == 0 {
+		return summary
+	}
+
+	frequencies := make([]int, 0, len(counts.Counts))
+	letters := 0
+	cyrillic := 0
+	for word, count := range counts.Counts {
+		frequencies = append(frequencies, count)
+		if count == 1 {
+			summary.Hapaxes++
+		}
+
+		length := utf8.RuneCountInString(word)
+		letters += length * count
+		if first, _ := utf8.DecodeRuneInString(word); first >= 'а' && first <= 'я' {
+			cyrillic += count
+		}
+
+		p := float64(count) / float64(counts.Total)
+		summary.Entropy -= p * math.Log2(p)
+	}
+
+	summary.TypeTokenRatio = float64(summary.Types) / float64(counts.Total)
+	summary.AvgWordLength = float64(letters) / float64(counts.Total)
+	if sentences > 0 {
+		summary.AvgSentenceLength = float64(counts.Total) / float64(sentences)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(frequencies)))
+	summary.ZipfExponent, summary.ZipfR2 = fitZipf(frequencies)
+
+	if sentences > 0 {
+		summary.Readability = readability(counts, sentences, cyrillic*2 > counts.Total)
+	}
+	return summary
+}
+
+// fitZipf подбирает прямую log f = a - s * log r методом наименьших квадратов
+func fitZipf(frequencies []int) (exponent, r2 float64) {
+	n := float64(len(frequencies))
+	if len(frequencies) < 2 {
+		return 0, 0
+	}
+
+	var sumX, sumY, sumXX, sumXY, sumYY float64
+	for i, frequency := range frequencies {
+		x := math.Log(float64(i + 1))
+		y := math.Log(float64(frequency))
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+		sumYY += y * y
+	}
+
+	varX := n*sumXX - sumX*sumX
+	varY := n*sumYY - sumY*sumY
+	cov := n*sumXY - sumX*sumY
+	if varX == 0 {
+		return 0, 0
+	}
+
+	if varY == 0 {
+		// Все слова встретились одинаково часто - прямая горизонтальная и описывает их точно
+		return 0, 1
+	}
+	return -cov / varX, cov * cov / (varX * varY)
+}
+
+func readability(counts WordCounts, sentences int, russian bool) *Readability {
+	result := &Readability{Language: "en", Formula: "flesch"}
+	if russian {
+		result.Language, result.Formula = "ru", "flesch_oborneva"
+	}
+
+	for word, count := range counts.Counts {
+		result.Syllables += countSyllables(word) * count
+	}
+
+	wordsPerSentence := float64(counts.Total) / float64(sentences)
+	result.AvgSyllablesPerWord = float64(result.Syllables) / float64(counts.Total)
+	if russian {
+		result.Score = 206.835 - 1.3*wordsPerSentence - 60.1*result.AvgSyllablesPerWord
+	} else {
+		result.Score = 206.835 - 1.015*wordsPerSentence - 84.6*result.AvgSyllablesPerWord
+	}
+	return result
+}
+
+// countSyllables: в русском слове слогов столько же, сколько гласных,
+// в английском - групп гласных без немой "e" на конце. Меньше одного слога не бывает.
+func countSyllables(word string) int {
+	syllables := 0
+	inVowels := false
+	for _, r := range word {
+		switch {
+		case strings.ContainsRune("аеёиоуыэюя", r):
+			syllables++
+		case strings.ContainsRune("aeiouy", r):
+			if !inVowels {
+				syllables++
+			}
+			inVowels = true
+			continue
+		}
+		inVowels = false
+	}
+
+	if syllables > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") {
+		syllables--
+	}
+	return max(syllables, 1)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"tfidf-app/internal/models"
+)
+
+func TestCountDocumentTextReportsComputed(t *testing.T) {
+	store := withStreamStorage(t)
+	saved := DocumentCache
+	DocumentCache = NewContentCache(t.TempDir(), 16, 1<<20, 0)
+	t.Cleanup(func() { DocumentCache = saved })
+
+	ctx := context.Background()
+	content := "Кот спит. Кот ест."
+	if err := store.Put(ctx, "user_1/a.txt", strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+	document := models.Document{StorageKey: "user_1/a.txt", Size: int64(len(content)), ContentHash: hashOf(content)}
+
+	counts, sentences, computed, err := CountDocumentText(ctx, document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !computed || counts.Counts["кот"] != 2 || sentences != 2 {
+		t.Errorf("first call: computed %v, кот %d, sentences %d", computed, counts.Counts["кот"], sentences)
+	}
+
+	// Второй раз все из кэша: файл не читается, статистика не считается заново
+	_, _, computed, err = CountDocumentText(ctx, document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if computed {
+		t.Error("second call computed again, want cache hit")
+	}
+	if len(store.opens) != 1 {
+		t.Errorf("content opened %d times, want 1", len(store.opens))
+	}
+
+	// Без хэша кэшировать не по чему - каждый раз считается
+	document.ContentHash = ""
+	if _, _, computed, _ = CountDocumentText(ctx, document); !computed {
+		t.Error("document without hash reported a cache hit")
+	}
+}
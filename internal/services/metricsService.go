@@ -63,12 +63,7 @@ func UpdateMetrics(tx *gorm.DB, processingTime float64, fileSizeMB float64) (mod
 	return metric, nil
 }
 
-func SaveWords(tx *gorm.DB, words []string, metricID uint) error {
-	wordCount := make(map[string]int)
-	for _, word := range words {
-		wordCount[word]++
-	}
-
+func SaveWords(tx *gorm.DB, wordCount map[string]int, metricID uint) error {
 	wordList := make([]string, 0, len(wordCount))
 	for word := range wordCount {
 		wordList = append(wordList, word)
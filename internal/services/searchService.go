@@ -0,0 +1,87 @@
+package services
+
+import (
+	"sort"
+	"tfidf-app/internal/models"
+	"tfidf-app/internal/query"
+
+	"gorm.io/gorm"
+)
+
+// SearchHit - документ, подошедший под запрос
+type SearchHit struct {
+	DocumentID   uint   `json:"document_id"`
+	DocumentName string `json:"document_name"`
+	Hits         int    `json:"hits"` // сколько совпадений слов, фраз и NEAR нашлось
+}
+
+type SearchResult struct {
+	Query     string      `json:"query"`
+	Total     int         `json:"total"` // сколько документов подошло, даже если в documents попали не все
+	Documents []SearchHit `json:"documents"`
+}
+
+// ParseQuery разбирает поисковый запрос, слова приводятся к виду индекса
+func ParseQuery(input string) (query.Node, error) {
+	return query.Parse(input, func(text string) []string {
+		return ExtractWords([]byte(text))
+	})
+}
+
+// contentIndex - позиции слов запроса в одном содержимом
+type contentIndex map[string][]int
+
+func (i contentIndex) Positions(term string) []int {
+	return i[term]
+}
+
+// SearchDocuments проверяет документы по запросу, используя только позиционный индекс:
+// из базы берутся записи лишь для слов запроса, содержимое не читается.
+// Документы с большим числом совпадений идут первыми.
+func SearchDocuments(db *gorm.DB, documents []models.Document, input string, node query.Node, limit int) (SearchResult, error) {
+	result := SearchResult{Query: input, Documents: []SearchHit{}}
+
+	hashes := make([]string, 0, len(documents))
+	for _, document := range documents {
+		hashes = append(hashes, document.ContentHash)
+	}
+
+	indexes := make(map[string]contentIndex)
+	if terms := query.Terms(node); len(hashes) > 0 && len(terms) > 0 {
+		var postings []models.TermPosting
+		if err := db.Where("term IN ? AND content_hash IN ?", terms, hashes).Find(&postings).Error; err != nil {
+			return result, err
+		}
+
+		for _, posting := range postings {
+			index, ok := indexes[posting.ContentHash]
+			if !ok {
+				index = make(contentIndex)
+				indexes[posting.ContentHash] = index
+			}
+			positions := make([]int, len(posting.Positions))
+			for i, position := range posting.Positions {
+				positions[i] = position.Pos
+			}
+			index[posting.Term] = positions
+		}
+	}
+
+	var hits []SearchHit
+	for _, document := range documents {
+		// У содержимого без слов запроса индекса нет, но под NOT оно подходит
+		matched, count := query.Match(node, indexes[document.ContentHash])
+		if matched {
+			hits = append(hits, SearchHit{DocumentID: document.ID, DocumentName: document.Name, Hits: count})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Hits > hits[j].Hits })
+
+	result.Total = len(hits)
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	result.Documents = append(result.Documents, hits...)
+	return result, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"tfidf-app/internal/config"
+)
+
+var ErrTooLarge = errors.New("file is too large")
+
+// StagedContent - загружаемое содержимое во временном файле.
+// Пока оно писалось, уже посчитаны его SHA-256 и слова, перечитывать его не нужно.
+type StagedContent struct {
+	Path     string
+	Size     int64
+	Hash     string
+	Counts   WordCounts
+	Postings *PostingsSpool // позиционный индекс, строится вместе с подсчетом слов
+	MimeType string         // определяется по первым байтам, вызывающий может уточнить
+	Source   string         // откуда содержимое, заполняет вызывающий (SourceUpload, SourceText, ссылка)
+}
+
+// StageContent потоково пишет содержимое во временный файл, одновременно считая хэш и слова,
+// поэтому в памяти целиком оно не держится. limit <= 0 - без ограничения размера.
+// progress (может быть nil) получает байты по мере того, как их разбирает токенизатор.
+func StageContent(r io.Reader, limit int64, progress *UploadProgress) (*StagedContent, error) {
+	if err := os.MkdirAll(config.Init.UploadsDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(config.Init.UploadsDir, "stage-*")
+	if err != nil {
+		return nil, err
+	}
+
+	src := r
+	if limit > 0 {
+		src = io.LimitReader(r, limit+1)
+	}
+
+	staged := &StagedContent{Path: tmp.Name()}
+	err = staged.scan(src, tmp, progress)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && limit > 0 && staged.Size > limit {
+		err = ErrTooLarge
+	}
+	if progress != nil {
+		progress.Done(err)
+	}
+	if err != nil {
+		staged.Remove()
+		return nil, err
+	}
+
+	return staged, nil
+}
+
+// StageFile считает хэш и слова уже записанного файла (например, собранного из кусков).
+// Файл переходит во владение StagedContent и удаляется вместе с ним.
+// progress (может быть nil) получает ход разбора.
+func StageFile(path string, progress *UploadProgress) (*StagedContent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	staged := &StagedContent{Path: path}
+	err = staged.scan(f, io.Discard, progress)
+	if progress != nil {
+		progress.Done(err)
+	}
+	if err != nil {
+		// Сам файл при ошибке остается у вызывающего, удаляем только куски индекса
+		staged.Postings.Remove()
+		return nil, err
+	}
+	return staged, nil
+}
+
+// scan копирует r в dst, попутно считая размер, SHA-256, слова и их позиции
+func (s *StagedContent) scan(r io.Reader, dst io.Writer, progress *UploadProgress) error {
+	counts := make(map[string]int)
+	total := 0
+	// Вхождения не держатся в памяти целиком: большой текст сбрасывает их кусками рядом с собой
+	postings := NewPostingsSpool(config.Init.UploadsDir)
+	s.Postings = postings
+	hasher := sha256.New()
+	head := &headWriter{limit: sniffLen}
+	tokenizer := NewTokenizer(func(token Token) {
+		counts[token.Word]++
+		total++
+		postings.Add(token)
+	})
+
+	writers := []io.Writer{dst, hasher, tokenizer, head}
+	if progress != nil {
+		// После токенизатора: байт засчитывается, когда его слова уже посчитаны
+		writers = append(writers, progress)
+	}
+	size, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return err
+	}
+	tokenizer.Close()
+	if err := postings.Err(); err != nil {
+		return err
+	}
+
+	s.Size = size
+	s.Hash = hex.EncodeToString(hasher.Sum(nil))
+	s.MimeType = MediaType(http.DetectContentType(head.data))
+
+	// Слова уже посчитаны, кладем их в кэш, чтобы статистика не перечитывала файл
+	s.Counts, _ = GetOrCompute(DocumentCache, s.Hash, CacheKindWordCounts, func() (WordCounts, error) {
+		return WordCounts{Counts: counts, Total: total}, nil
+	})
+	return nil
+}
+
+// Сколько первых байт смотрит http.DetectContentType
+const sniffLen = 512
+
+// headWriter запоминает первые limit байт потока
+type headWriter struct {
+	data  []byte
+	limit int
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if rest := h.limit - len(h.data); rest > 0 {
+		h.data = append(h.data, p[:min(rest, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// MediaType отрезает параметры от Content-Type: "text/plain; charset=utf-8" -> "text/plain"
+func MediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+func (s *StagedContent) Remove() {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove staged file %s: %v", s.Path, err)
+	}
+	if s.Postings != nil {
+		s.Postings.Remove()
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// События потока /events, кроме событий жизненного цикла (document.created и т.п. идут туда же)
+const (
+	EventUploadProgress = "upload.progress"
+	EventMetricsUpdated = "metrics.updated"
+)
+
+const (
+	// Сколько событий ждет медленного подписчика, дальше новые для него пропускаются
+	streamBuffer = 64
+	// Без известного размера прогресс сообщается через каждый мегабайт
+	progressStep = 1 << 20
+)
+
+// StreamEvent - событие для подписчиков потока /events
+type StreamEvent struct {
+	Event string
+	Data  any
+}
+
+// Подписчики потока по пользователям. Поток живет в памяти процесса:
+// подписчик получает события, случившиеся в этом экземпляре сервиса, пока он подключен.
+var streams = struct {
+	sync.Mutex
+	subscribers map[int]map[chan StreamEvent]struct{}
+}{subscribers: make(map[int]map[chan StreamEvent]struct{})}
+
+// SubscribeStream подписывает на события пользователя. Вызывающий обязан вызвать unsubscribe
+func SubscribeStream(userID int) (events <-chan StreamEvent, unsubscribe func()) {
+	ch := make(chan StreamEvent, streamBuffer)
+
+	streams.Lock()
+	if streams.subscribers[userID] == nil {
+		streams.subscribers[userID] = make(map[chan StreamEvent]struct{})
+	}
+	streams.subscribers[userID][ch] = struct{}{}
+	streams.Unlock()
+
+	return ch, func() {
+		streams.Lock()
+		delete(streams.subscribers[userID], ch)
+		if len(streams.subscribers[userID]) == 0 {
+			delete(streams.subscribers, userID)
+		}
+		streams.Unlock()
+	}
+}
+
+// PublishStream отправляет событие всем подключенным клиентам пользователя, не блокируясь
+func PublishStream(userID int, event string, data any) {
+	streams.Lock()
+	defer streams.Unlock()
+
+	for ch := range streams.subscribers[userID] {
+		select {
+		case ch <- StreamEvent{Event: event, Data: data}:
+		default:
+		}
+	}
+}
+
+// BroadcastStream отправляет событие всем подключенным клиентам (общие для сервиса метрики)
+func BroadcastStream(event string, data any) {
+	streams.Lock()
+	defer streams.Unlock()
+
+	for _, subscribers := range streams.subscribers {
+		for ch := range subscribers {
+			select {
+			case ch <- StreamEvent{Event: event, Data: data}:
+			default:
+			}
+		}
+	}
+}
+
+// UploadProgressEvent - данные события upload.progress
+type UploadProgressEvent struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Processed int64  `json:"processed"`         // байт уже разобрано токенизатором
+	Total     int64  `json:"total,omitempty"`   // ожидаемый размер, если известен
+	Percent   int    `json:"percent,omitempty"` // только при известном размере
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+// UploadProgress считает байты, прошедшие через токенизатор, и публикует upload.progress
+// при каждом новом проценте (или мегабайте, если размер неизвестен).
+// До Done процент не поднимается выше 99: размер запроса - лишь оценка размера файла.
+type UploadProgress struct {
+	UserID int
+	ID     string // по нему клиент связывает события со своей загрузкой
+	Name   string
+	Total  int64
+
+	processed int64
+	percent   int
+	reported  int64
+}
+
+// NewUploadProgress создает счетчик прогресса. Пустой id - сгенерировать случайный
+func NewUploadProgress(userID int, id, name string, total int64) *UploadProgress {
+	if id == "" {
+		random := make([]byte, 8)
+		rand.Read(random)
+		id = hex.EncodeToString(random)
+	}
+	return &UploadProgress{UserID: userID, ID: id, Name: name, Total: max(total, 0)}
+}
+
+func (p *UploadProgress) Write(b []byte) (int, error) {
+	p.processed += int64(len(b))
+
+	if p.Total > 0 {
+		if percent := min(int(p.processed*100/p.Total), 99); percent > p.percent {
+			p.percent = percent
+			p.publish(false, nil)
+		}
+	} else if p.processed-p.reported >= progressStep {
+		p.publish(false, nil)
+	}
+	return len(b), nil
+}
+
+// Done сообщает, что содержимое разобрано (или что разбор прервался с ошибкой err)
+func (p *UploadProgress) Done(err error) {
+	if err == nil {
+		p.percent = 100
+	}
+	p.publish(true, err)
+}
+
+func (p *UploadProgress) publish(done bool, err error) {
+	p.reported = p.processed
+
+	event := UploadProgressEvent{ID: p.ID, Name: p.Name, Processed: p.processed, Total: p.Total, Done: done}
+	if p.Total > 0 || done {
+		event.Percent = p.percent
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	PublishStream(p.UserID, EventUploadProgress, event)
+}
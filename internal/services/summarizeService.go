@@ -0,0 +1,80 @@
+package services
+
+import (
+	"math"
+	"sort"
+)
+
+// SummarySentence - предложение, попавшее в выжимку
+type SummarySentence struct {
+	Index int     `json:"index"` // номер предложения в тексте
+	Text  string  `json:"text"`
+	Start int64   `json:"start"` // смещения в байтах
+	End   int64   `json:"end"`
+	Score float64 `json:"score"` // сумма весов TF-IDF слов предложения
+}
+
+type ExtractiveSummary struct {
+	TotalSentences int               `json:"total_sentences"`
+	Lambda         float64           `json:"lambda"`
+	Sentences      []SummarySentence `json:"sentences"` // в порядке текста
+}
+
+// ExtractSummary выбирает count предложений методом MMR (maximal marginal relevance):
+// каждый шаг берет предложение с наибольшим lambda * вес - (1 - lambda) * сходство с уже выбранными.
+// lambda = 1 - только вес, чем меньше, тем сильнее штраф за повторы.
+func ExtractSummary(content []byte, profile map[string]float64, count int, lambda float64) ExtractiveSummary {
+	sentences := SplitSentences(content)
+	summary := ExtractiveSummary{TotalSentences: len(sentences), Lambda: lambda, Sentences: []SummarySentence{}}
+
+	// Вектор предложения: слово -> число вхождений * вес TF-IDF слова в документе
+	candidates := make([]SummarySentence, len(sentences))
+	vectors := make([]map[string]float64, len(sentences))
+	maxScore := 0.0
+	for i, sentence := range sentences {
+		text := content[sentence.Start:sentence.End]
+		vector := make(map[string]float64)
+		score := 0.0
+		for _, token := range Tokenize(text) {
+			vector[token.Word] += profile[token.Word]
+			score += profile[token.Word]
+		}
+
+		candidates[i] = SummarySentence{Index: i, Text: string(text), Start: sentence.Start, End: sentence.End, Score: score}
+		vectors[i] = vector
+		maxScore = max(maxScore, score)
+	}
+
+	selected := make([]int, 0, count)
+	used := make([]bool, len(candidates))
+	for len(selected) < min(count, len(candidates)) {
+		best, bestValue := -1, math.Inf(-1)
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+
+			relevance := 0.0
+			if maxScore > 0 {
+				relevance = candidate.Score / maxScore
+			}
+			redundancy := 0.0
+			for _, j := range selected {
+				redundancy = max(redundancy, cosine(vectors[i], vectors[j], nil))
+			}
+
+			if value := lambda*relevance - (1-lambda)*redundancy; value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+
+		used[best] = true
+		selected = append(selected, best)
+	}
+
+	sort.Ints(selected)
+	for _, i := range selected {
+		summary.Sentences = append(summary.Sentences, candidates[i])
+	}
+	return summary
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestExtractSummaryMMR(t *testing.T) {
+	// Второе предложение почти повторяет первое и весит чуть больше, третье - про другое
+	content := []byte("Кошка ловит мышь. Кошка ловит мышь снова. Собака лает.")
+	profile := map[string]float64{"кошка": 3, "ловит": 2, "мышь": 3, "снова": 0.5, "собака": 2, "лает": 1}
+
+	tests := []struct {
+		name   string
+		count  int
+		lambda float64
+		want   []int
+	}{
+		// Только вес: два самых тяжелых, хоть они и повторяют друг друга
+		{"relevance only", 2, 1, []int{0, 1}},
+		// Сходство первых двух ~0.99: после "снова" выгоднее взять про собаку
+		{"redundancy penalty", 2, 0.5, []int{1, 2}},
+		{"one sentence", 1, 0.5, []int{1}},
+		{"more than the text has", 10, 0.7, []int{0, 1, 2}},
+		{"nothing", 0, 0.7, []int{}},
+	}
+	for _, tt := range tests {
+		summary := ExtractSummary(content, profile, tt.count, tt.lambda)
+		if summary.TotalSentences != 3 || summary.Lambda != tt.lambda {
+			t.Errorf("%s: total %d, lambda %v", tt.name, summary.TotalSentences, summary.Lambda)
+		}
+
+		got := []int{}
+		for _, sentence := range summary.Sentences {
+			got = append(got, sentence.Index)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: sentences %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractSummarySentences(t *testing.T) {
+	content := []byte("Кошка ловит мышь. Собака лает.")
+	profile := map[string]float64{"кошка": 1.5, "мышь": 1, "собака": 2}
+
+	summary := ExtractSummary(content, profile, 2, 0.7)
+	if len(summary.Sentences) != 2 {
+		t.Fatalf("sentences = %+v", summary.Sentences)
+	}
+	for i, want := range []float64{2.5, 2} {
+		sentence := summary.Sentences[i]
+		if sentence.Text != string(content[sentence.Start:sentence.End]) {
+			t.Errorf("sentence %d: text %q does not match offsets %d-%d", i, sentence.Text, sentence.Start, sentence.End)
+		}
+		// Слова без веса в профиле ("ловит", "лает") в оценку не входят
+		if math.Abs(sentence.Score-want) > 1e-9 {
+			t.Errorf("sentence %d: score %v, want %v", i, sentence.Score, want)
+		}
+	}
+}
+
+func TestExtractSummaryWithoutWeights(t *testing.T) {
+	// Пустой профиль: все веса нулевые, берутся первые предложения по порядку
+	summary := ExtractSummary([]byte("Раз. Два. Три."), nil, 2, 0.7)
+	if len(summary.Sentences) != 2 || summary.Sentences[0].Index != 0 || summary.Sentences[1].Index != 1 {
+		t.Errorf("sentences = %+v", summary.Sentences)
+	}
+
+	summary = ExtractSummary(nil, nil, 3, 0.7)
+	if summary.TotalSentences != 0 || summary.Sentences == nil || len(summary.Sentences) != 0 {
+		t.Errorf("empty text: %+v", summary)
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"tfidf-app/internal/models"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidTag = errors.New("tag must be 1-50 characters long")
+
+// NormalizeTags приводит теги к нижнему регистру без пробелов по краям и убирает повторы
+func NormalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool)
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || utf8.RuneCountInString(name) > 50 {
+			return nil, ErrInvalidTag
+		}
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	return tags, nil
+}
+
+// SetDocumentTags заменяет теги документа, недостающие теги пользователя создаются
+func SetDocumentTags(tx *gorm.DB, document *models.Document, names []string) error {
+	tags := make([]*models.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, &models.Tag{Name: name, UserID: document.UserID})
+	}
+
+	if len(tags) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+		// При конфликте ID не заполняется, перечитываем все
+		tags = tags[:0]
+		if err := tx.Where("user_id = ? AND name IN ?", document.UserID, names).Find(&tags).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(document).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+	return DeleteUnusedTags(tx, document.UserID)
+}
+
+// DeleteUnusedTags удаляет теги пользователя, которых нет ни на одном документе
+func DeleteUnusedTags(tx *gorm.DB, userID int) error {
+	return tx.Where("user_id = ? AND NOT EXISTS (SELECT 1 FROM document_tags dt WHERE dt.tag_id = tags.id)", userID).
+		Delete(&models.Tag{}).Error
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"tfidf-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TermDocument - вхождение слова в один документ
+type TermDocument struct {
+	DocumentID   uint    `json:"document_id"`
+	DocumentName string  `json:"document_name"`
+	Count        int     `json:"count"`
+	TF           float64 `json:"tf"`
+	TFIDF        float64 `json:"tf_idf"`
+}
+
+// TermLookup - слово в корпусе: в скольких документах и сколько раз встречается
+type TermLookup struct {
+	Term       string         `json:"term"`
+	Documents  int            `json:"documents"`   // размер корпуса
+	DF         int            `json:"df"`          // в скольких документах есть слово
+	IDF        float64        `json:"idf"`         // как в статистике: ln(documents / df)
+	TotalCount int            `json:"total_count"` // сколько раз встречается во всех документах
+	Postings   []TermDocument `json:"postings"`    // по убыванию TF-IDF
+}
+
+// LookupTerm собирает статистику слова по корпусу из позиционного индекса.
+// Файлы не читаются: число вхождений берется из индекса, число слов документа - из его метаданных.
+func LookupTerm(db *gorm.DB, documents []models.Document, term string, limit int) (TermLookup, error) {
+	lookup := TermLookup{Term: term, Documents: len(documents), Postings: []TermDocument{}}
+
+	hashes := make([]string, 0, len(documents))
+	for _, document := range documents {
+		hashes = append(hashes, document.ContentHash)
+	}
+
+	counts := make(map[string]int)
+	if len(hashes) > 0 {
+		var postings []models.TermPosting
+		err := db.Select("content_hash", "count").
+			Where("term = ? AND content_hash IN ?", term, hashes).Find(&postings).Error
+		if err != nil {
+			return lookup, err
+		}
+		for _, posting := range postings {
+			counts[posting.ContentHash] = posting.Count
+		}
+	}
+
+	var postings []TermDocument
+	for _, document := range documents {
+		count := counts[document.ContentHash]
+		if count == 0 {
+			continue
+		}
+
+		var tf float64
+		if document.TokenCount > 0 {
+			tf = float64(count) / float64(document.TokenCount)
+		}
+		postings = append(postings, TermDocument{
+			DocumentID:   document.ID,
+			DocumentName: document.Name,
+			Count:        count,
+			TF:           tf,
+		})
+		lookup.TotalCount += count
+	}
+
+	lookup.DF = len(postings)
+	if lookup.DF > 0 {
+		lookup.IDF = math.Log(float64(lookup.Documents) / float64(lookup.DF))
+	}
+	for i := range postings {
+		postings[i].TFIDF = postings[i].TF * lookup.IDF
+	}
+
+	// При IDF = 0 (слово во всех документах) порядок задает TF
+	sort.SliceStable(postings, func(i, j int) bool {
+		if postings[i].TFIDF != postings[j].TFIDF {
+			return postings[i].TFIDF > postings[j].TFIDF
+		}
+		return postings[i].TF > postings[j].TF
+	})
+
+	if len(postings) > limit {
+		postings = postings[:limit]
+	}
+	lookup.Postings = append(lookup.Postings, postings...)
+	return lookup, nil
+}
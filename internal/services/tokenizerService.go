@@ -0,0 +1,97 @@
+package services
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token - слово текста вместе с его местом в исходных байтах
+type Token struct {
+	Word  string
+	Pos   int   // порядковый номер слова в тексте
+	Start int64 // смещение начала слова в байтах
+	End   int64 // смещение конца слова в байтах
+}
+
+// Tokenizer разбивает текст на слова потоково: его можно писать кусками любого размера
+// (например, прямо из тела запроса), разорванные между кусками UTF-8 символы склеиваются.
+// Словом считается последовательность латинских и русских букв, в нижнем регистре.
+type Tokenizer struct {
+	onToken func(Token)
+
+	pending []byte // начало UTF-8 символа, которое не поместилось в прошлый кусок
+	word    []byte
+	start   int64
+	offset  int64
+	pos     int
+}
+
+func NewTokenizer(onToken func(Token)) *Tokenizer {
+	return &Tokenizer{onToken: onToken}
+}
+
+func isWordRune(r rune) bool {
+	return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('а' <= r && r <= 'я') || ('А' <= r && r <= 'Я')
+}
+
+func (t *Tokenizer) Write(p []byte) (int, error) {
+	data := p
+	if len(t.pending) > 0 {
+		data = append(t.pending, p...)
+		t.pending = nil
+	}
+
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size <= 1 && !utf8.FullRune(data[i:]) {
+			// Символ продолжится в следующем куске
+			t.pending = append([]byte(nil), data[i:]...)
+			break
+		}
+
+		r = unicode.ToLower(r)
+		if isWordRune(r) {
+			if len(t.word) == 0 {
+				t.start = t.offset
+			}
+			t.word = utf8.AppendRune(t.word, r)
+		} else {
+			t.flush()
+		}
+
+		i += size
+		t.offset += int64(size)
+	}
+
+	return len(p), nil
+}
+
+// Close дописывает последнее слово, после него токенайзер использовать нельзя
+func (t *Tokenizer) Close() error {
+	// Оборванный в конце текста символ - просто мусор, он слово не продолжает
+	t.offset += int64(len(t.pending))
+	t.pending = nil
+	t.flush()
+	return nil
+}
+
+func (t *Tokenizer) flush() {
+	if len(t.word) == 0 {
+		return
+	}
+
+	t.onToken(Token{Word: string(t.word), Pos: t.pos, Start: t.start, End: t.offset})
+	t.pos++
+	t.word = t.word[:0]
+}
+
+// Tokenize разбивает на слова уже прочитанный текст
+func Tokenize(data []byte) []Token {
+	var tokens []Token
+	tokenizer := NewTokenizer(func(token Token) {
+		tokens = append(tokens, token)
+	})
+	tokenizer.Write(data)
+	tokenizer.Close()
+	return tokens
+}
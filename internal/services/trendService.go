@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"tfidf-app/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Размеры интервалов, по которым группируются документы
+const (
+	BucketDay     = "day"
+	BucketWeek    = "week"
+	BucketMonth   = "month"
+	BucketQuarter = "quarter"
+	BucketYear    = "year"
+)
+
+const (
+	// Больше интервалов в ответе не строим, пусть выберут интервал крупнее
+	maxTrendBuckets = 1000
+
+	emergingTermsLimit = 20
+	// Слово, встретившееся в последнем интервале один раз, - еще не тенденция
+	emergingMinimumCount = 2
+)
+
+var (
+	ErrInvalidBucket  = errors.New("bucket must be day, week, month, quarter or year")
+	ErrTooManyBuckets = fmt.Errorf("too many buckets, max %d: choose a larger bucket", maxTrendBuckets)
+)
+
+// TrendBucket - интервал времени и документы, попавшие в него
+type TrendBucket struct {
+	Bucket    string    `json:"bucket"` // 2024-03, 2024-W09, 2024-Q1...
+	Start     time.Time `json:"start"`
+	Documents int       `json:"documents"`
+	Tokens    int       `json:"tokens"`
+}
+
+// TrendPoint - слово в одном интервале
+type TrendPoint struct {
+	Bucket            string  `json:"bucket"`
+	Count             int     `json:"count"`
+	RelativeFrequency float64 `json:"relative_frequency"` // доля от всех слов интервала
+	TFIDF             float64 `json:"tf_idf"`             // relative_frequency * IDF слова по документам коллекции
+}
+
+type TermTrend struct {
+	Term   string       `json:"term"`
+	IDF    float64      `json:"idf"`
+	Points []TrendPoint `json:"points"` // по всем интервалам подряд, в том числе пустым
+}
+
+// EmergingTerm - слово, которое в последнем интервале встречается значимо чаще, чем во всех предыдущих
+type EmergingTerm struct {
+	Term              string  `json:"term"`
+	LatestCount       int     `json:"latest_count"`
+	BaselineCount     int     `json:"baseline_count"`
+	LatestFrequency   float64 `json:"latest_frequency"`
+	BaselineFrequency float64 `json:"baseline_frequency"`
+	LogLikelihood     float64 `json:"log_likelihood"`
+}
+
+type TrendResult struct {
+	BucketSize string         `json:"bucket_size"`
+	Buckets    []TrendBucket  `json:"buckets"`
+	Terms      []TermTrend    `json:"terms"`
+	Emerging   []EmergingTerm `json:"emerging"` // по убыванию log-likelihood, пусто, если интервал всего один
+}
+
+// DocumentTime - дата, по которой документ попадает в интервал: document_date, а если ее нет - дата загрузки
+func DocumentTime(document models.Document) time.Time {
+	if document.DocumentDate != nil {
+		return *document.DocumentDate
+	}
+	return document.CreatedAt
+}
+
+// CollectionTrends строит временные ряды слов по документам коллекции.
+// Все считается по позиционному индексу и token_count документов, файлы не читаются.
+func CollectionTrends(db *gorm.DB, documents []models.Document, terms []string, bucketSize string) (TrendResult, error) {
+	result := TrendResult{BucketSize: bucketSize, Buckets: []TrendBucket{}, Terms: []TermTrend{}, Emerging: []EmergingTerm{}}
+	if _, err := truncateTime(time.Now(), bucketSize); err != nil {
+		return result, err
+	}
+	if len(documents) == 0 {
+		for _, term := range terms {
+			result.Terms = append(result.Terms, TermTrend{Term: term, Points: []TrendPoint{}})
+		}
+		return result, nil
+	}
+
+	// Интервалы подряд от самого раннего документа до самого позднего
+	first, last := DocumentTime(documents[0]), DocumentTime(documents[0])
+	for _, document := range documents {
+		t := DocumentTime(document)
+		if t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	start, _ := truncateTime(first, bucketSize)
+	index := make(map[time.Time]int)
+	for t := start; !t.After(last); t = nextBucket(t, bucketSize) {
+		if len(result.Buckets) == maxTrendBuckets {
+			return result, ErrTooManyBuckets
+		}
+		index[t] = len(result.Buckets)
+		result.Buckets = append(result.Buckets, TrendBucket{Bucket: bucketLabel(t, bucketSize), Start: t})
+	}
+
+	// Число вхождений каждого слова в каждое содержимое, без позиций
+	hashes := make([]string, 0, len(documents))
+	for _, document := range documents {
+		hashes = append(hashes, document.ContentHash)
+	}
+	var postings []models.TermPosting
+	if err := db.Select("content_hash", "term", "count").Where("content_hash IN ?", hashes).Find(&postings).Error; err != nil {
+		return result, err
+	}
+	byHash := make(map[string]map[string]int)
+	for _, posting := range postings {
+		if byHash[posting.ContentHash] == nil {
+			byHash[posting.ContentHash] = make(map[string]int)
+		}
+		byHash[posting.ContentHash][posting.Term] = posting.Count
+	}
+
+	bucketCounts := make([]map[string]int, len(result.Buckets))
+	for i := range bucketCounts {
+		bucketCounts[i] = make(map[string]int)
+	}
+	df := make(map[string]int)
+	for _, document := range documents {
+		t, _ := truncateTime(DocumentTime(document), bucketSize)
+		i := index[t]
+		result.Buckets[i].Documents++
+		result.Buckets[i].Tokens += document.TokenCount
+		for term, count := range byHash[document.ContentHash] {
+			bucketCounts[i][term] += count
+			df[term]++
+		}
+	}
+
+	for _, term := range terms {
+		trend := TermTrend{Term: term, Points: make([]TrendPoint, 0, len(result.Buckets))}
+		if df[term] > 0 {
+			trend.IDF = math.Log(float64(len(documents)) / float64(df[term]))
+		}
+		for i, bucket := range result.Buckets {
+			point := TrendPoint{Bucket: bucket.Bucket, Count: bucketCounts[i][term]}
+			if bucket.Tokens > 0 {
+				point.RelativeFrequency = float64(point.Count) / float64(bucket.Tokens)
+			}
+			point.TFIDF = point.RelativeFrequency * trend.IDF
+			trend.Points = append(trend.Points, point)
+		}
+		result.Terms = append(result.Terms, trend)
+	}
+
+	result.Emerging = emergingTerms(result.Buckets, bucketCounts)
+	return result, nil
+}
+
+// emergingTerms сравнивает последний непустой интервал со всеми предыдущими вместе (keyness по log-likelihood)
+func emergingTerms(buckets []TrendBucket, bucketCounts []map[string]int) []EmergingTerm {
+	emerging := []EmergingTerm{}
+
+	latest := len(buckets) - 1
+	for latest >= 0 && buckets[latest].Documents == 0 {
+		latest--
+	}
+	if latest <= 0 {
+		return emerging
+	}
+
+	baseline := make(map[string]int)
+	baselineTokens := 0
+	for i := 0; i < latest; i++ {
+		for term, count := range bucketCounts[i] {
+			baseline[term] += count
+		}
+		baselineTokens += buckets[i].Tokens
+	}
+	latestTokens := buckets[latest].Tokens
+	if baselineTokens == 0 || latestTokens == 0 {
+		return emerging
+	}
+
+	for term, count := range bucketCounts[latest] {
+		if count < emergingMinimumCount {
+			continue
+		}
+		candidate := EmergingTerm{
+			Term:              term,
+			LatestCount:       count,
+			BaselineCount:     baseline[term],
+			LatestFrequency:   float64(count) / float64(latestTokens),
+			BaselineFrequency: float64(baseline[term]) / float64(baselineTokens),
+		}
+		if candidate.LatestFrequency <= candidate.BaselineFrequency {
+			continue
+		}
+		candidate.LogLikelihood = logLikelihood(candidate.LatestCount, candidate.BaselineCount, latestTokens, baselineTokens)
+		if candidate.LogLikelihood >= KeynessThreshold {
+			emerging = append(emerging, candidate)
+		}
+	}
+
+	sort.Slice(emerging, func(i, j int) bool {
+		if emerging[i].LogLikelihood != emerging[j].LogLikelihood {
+			return emerging[i].LogLikelihood > emerging[j].LogLikelihood
+		}
+		return emerging[i].Term < emerging[j].Term
+	})
+	return emerging[:min(emergingTermsLimit, len(emerging))]
+}
+
+// truncateTime - начало интервала, в который попадает t (неделя начинается с понедельника)
+func truncateTime(t time.Time, bucketSize string) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucketSize {
+	case BucketDay:
+		return day, nil
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), nil
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	case BucketQuarter:
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC), nil
+	case BucketYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, ErrInvalidBucket
+}
+
+func nextBucket(t time.Time, bucketSize string) time.Time {
+	switch bucketSize {
+	case BucketDay:
+		return t.AddDate(0, 0, 1)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	case BucketQuarter:
+		return t.AddDate(0, 3, 0)
+	}
+	return t.AddDate(1, 0, 0)
+}
+
+func bucketLabel(t time.Time, bucketSize string) string {
+	switch bucketSize {
+	case BucketDay:
+		return t.Format(time.DateOnly)
+	case BucketWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case BucketMonth:
+		return t.Format("2006-01")
+	case BucketQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	}
+	return t.Format("2006")
+}
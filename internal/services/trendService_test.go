@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEmergingTerms(t *testing.T) {
+	buckets := []TrendBucket{
+		{Bucket: "2024-01", Documents: 1, Tokens: 100},
+		{Bucket: "2024-02"},
+		{Bucket: "2024-03", Documents: 1, Tokens: 100},
+		{Bucket: "2024-04"}, // пустой интервал в конце не считается последним
+	}
+	counts := []map[string]int{
+		{"кот": 10, "дом": 10, "сад": 2},
+		{},
+		{"кот": 10, "дом": 30, "мышь": 20, "слон": 1, "сад": 3},
+		{},
+	}
+
+	emerging := emergingTerms(buckets, counts)
+
+	// кот - та же частота, слон - одно вхождение, сад - рост случайный (LL ниже порога)
+	want := []struct {
+		term                 string
+		latest, baseline     int
+		latestFreq, baseFreq float64
+	}{
+		{"мышь", 20, 0, 0.2, 0},
+		{"дом", 30, 10, 0.3, 0.1},
+	}
+	if len(emerging) != len(want) {
+		t.Fatalf("emerging = %+v", emerging)
+	}
+	for i, w := range want {
+		got := emerging[i]
+		if got.Term != w.term || got.LatestCount != w.latest || got.BaselineCount != w.baseline {
+			t.Errorf("emerging[%d] = %+v, want %s %d/%d", i, got, w.term, w.latest, w.baseline)
+		}
+		if math.Abs(got.LatestFrequency-w.latestFreq) > 1e-9 || math.Abs(got.BaselineFrequency-w.baseFreq) > 1e-9 {
+			t.Errorf("%s: frequencies %v/%v, want %v/%v", got.Term, got.LatestFrequency, got.BaselineFrequency, w.latestFreq, w.baseFreq)
+		}
+		if ll := logLikelihood(w.latest, w.baseline, 100, 100); math.Abs(got.LogLikelihood-ll) > 1e-9 {
+			t.Errorf("%s: log_likelihood = %v, want %v", got.Term, got.LogLikelihood, ll)
+		}
+	}
+	// 2 * 20 * ln(20 / 10)
+	if math.Abs(emerging[0].LogLikelihood-40*math.Ln2) > 1e-9 {
+		t.Errorf("мышь: log_likelihood = %v, want %v", emerging[0].LogLikelihood, 40*math.Ln2)
+	}
+}
+
+func TestEmergingTermsNothingToCompare(t *testing.T) {
+	tests := []struct {
+		name    string
+		buckets []TrendBucket
+	}{
+		{"single bucket", []TrendBucket{{Documents: 1, Tokens: 10}}},
+		{"only first bucket has documents", []TrendBucket{{Documents: 1, Tokens: 10}, {}, {}}},
+		{"baseline without words", []TrendBucket{{Documents: 1}, {Documents: 1, Tokens: 10}}},
+		{"no buckets", nil},
+	}
+	for _, tt := range tests {
+		counts := make([]map[string]int, len(tt.buckets))
+		for i := range counts {
+			counts[i] = map[string]int{"кот": 5}
+		}
+		if emerging := emergingTerms(tt.buckets, counts); len(emerging) != 0 {
+			t.Errorf("%s: emerging = %+v", tt.name, emerging)
+		}
+	}
+}
+
+func TestEmergingTermsLimit(t *testing.T) {
+	latest := make(map[string]int)
+	for i := range emergingTermsLimit + 5 {
+		latest[fmt.Sprintf("слово%02d", i)] = 5
+	}
+	buckets := []TrendBucket{{Documents: 1, Tokens: 1000}, {Documents: 1, Tokens: 1000}}
+	emerging := emergingTerms(buckets, []map[string]int{{"кот": 5}, latest})
+
+	// Log-likelihood у всех одинаковый - порядок по слову
+	if len(emerging) != emergingTermsLimit {
+		t.Fatalf("got %d emerging terms, want %d", len(emerging), emergingTermsLimit)
+	}
+	for i, term := range emerging {
+		if want := fmt.Sprintf("слово%02d", i); term.Term != want {
+			t.Errorf("emerging[%d] = %s, want %s", i, term.Term, want)
+		}
+	}
+}
+
+func TestTruncateTime(t *testing.T) {
+	// Среда 14 февраля 2024, вечер
+	moment := time.Date(2024, 2, 14, 21, 30, 0, 0, time.UTC)
+	tests := []struct {
+		bucket string
+		start  time.Time
+		label  string
+		next   time.Time
+	}{
+		{BucketDay, time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC), "2024-02-14", time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)},
+		{BucketWeek, time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC), "2024-W07", time.Date(2024, 2, 19, 0, 0, 0, 0, time.UTC)},
+		{BucketMonth, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), "2024-02", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{BucketQuarter, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "2024-Q1", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{BucketYear, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "2024", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		start, err := truncateTime(moment, tt.bucket)
+		if err != nil || !start.Equal(tt.start) {
+			t.Errorf("%s: start = %v, %v, want %v", tt.bucket, start, err, tt.start)
+		}
+		if label := bucketLabel(start, tt.bucket); label != tt.label {
+			t.Errorf("%s: label = %s, want %s", tt.bucket, label, tt.label)
+		}
+		if next := nextBucket(start, tt.bucket); !next.Equal(tt.next) {
+			t.Errorf("%s: next = %v, want %v", tt.bucket, next, tt.next)
+		}
+	}
+
+	// Воскресенье относится к неделе, начавшейся в понедельник
+	sunday := time.Date(2024, 2, 18, 12, 0, 0, 0, time.UTC)
+	if start, _ := truncateTime(sunday, BucketWeek); !start.Equal(time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("sunday week start = %v", start)
+	}
+	if _, err := truncateTime(moment, "hour"); !errors.Is(err, ErrInvalidBucket) {
+		t.Errorf("invalid bucket: %v", err)
+	}
+}
+
+func TestCollectionTrendsWithoutDocuments(t *testing.T) {
+	// Без документов база не нужна
+	result, err := CollectionTrends(nil, nil, []string{"кот"}, BucketMonth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Buckets) != 0 || len(result.Emerging) != 0 || len(result.Terms) != 1 || len(result.Terms[0].Points) != 0 {
+		t.Errorf("result = %+v", result)
+	}
+
+	if _, err := CollectionTrends(nil, nil, nil, "hour"); !errors.Is(err, ErrInvalidBucket) {
+		t.Errorf("invalid bucket: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/models"
+	"time"
+)
+
+var ErrOffsetMismatch = errors.New("upload offset does not match")
+
+// Через сколько незавершенная загрузка считается брошенной
+const uploadSessionTTL = 24 * time.Hour
+
+// Куски одной загрузки дописываются строго по очереди
+var uploadLocks sync.Map
+
+func lockUpload(id string) func() {
+	mu, _ := uploadLocks.LoadOrStore(id, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// Временный файл, в который собирается загрузка
+func UploadSessionPath(id string) string {
+	return filepath.Join(config.Init.UploadsDir, "upload-"+id)
+}
+
+// CreateUploadSession начинает докачиваемую загрузку файла размером length.
+// Лимиты и имя проверяются сразу, чтобы не принимать гигабайты, которые потом некуда или не под чем сохранить.
+func CreateUploadSession(userID int, filename string, length int64) (models.UploadSession, error) {
+	if err := ValidateDocumentName(filename); err != nil {
+		return models.UploadSession{}, err
+	}
+	if config.Init.MaxUploadSize > 0 && length > config.Init.MaxUploadSize {
+		return models.UploadSession{}, ErrTooLarge
+	}
+	if err := CheckUserQuota(database.DB, userID, length); err != nil {
+		return models.UploadSession{}, err
+	}
+
+	CleanupStaleUploads()
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return models.UploadSession{}, err
+	}
+	session := models.UploadSession{
+		ID:       hex.EncodeToString(id),
+		UserID:   userID,
+		Filename: filename,
+		Length:   length,
+	}
+
+	if err := os.MkdirAll(config.Init.UploadsDir, os.ModePerm); err != nil {
+		return models.UploadSession{}, err
+	}
+	f, err := os.Create(UploadSessionPath(session.ID))
+	if err != nil {
+		return models.UploadSession{}, err
+	}
+	f.Close()
+
+	if err := database.DB.Create(&session).Error; err != nil {
+		os.Remove(UploadSessionPath(session.ID))
+		return models.UploadSession{}, err
+	}
+	return session, nil
+}
+
+// AppendUploadChunk дописывает кусок с позиции offset, она должна совпадать с уже принятым.
+// Даже если соединение оборвалось посреди куска, принятые байты сохраняются и учитываются.
+func AppendUploadChunk(session *models.UploadSession, offset int64, r io.Reader) error {
+	defer lockUpload(session.ID)()
+
+	// Пока ждали блокировку, параллельный запрос мог сдвинуть смещение
+	if err := database.DB.First(session, "id = ?", session.ID).Error; err != nil {
+		return err
+	}
+	if offset != session.Offset {
+		return ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(UploadSessionPath(session.ID), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Хвост от прерванной записи, не попавший в смещение, отбрасываем
+	if err := f.Truncate(session.Offset); err != nil {
+		return err
+	}
+	if _, err := f.Seek(session.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	remaining := session.Length - session.Offset
+	n, copyErr := io.Copy(f, io.LimitReader(r, remaining+1))
+	if n > remaining {
+		f.Truncate(session.Offset)
+		return ErrTooLarge
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	session.Offset += n
+	if err := database.DB.Model(session).Update("upload_offset", session.Offset).Error; err != nil {
+		return err
+	}
+	return copyErr
+}
+
+// CompleteUpload сохраняет полностью принятый файл как документ и закрывает загрузку
+func CompleteUpload(ctx context.Context, session models.UploadSession, startTime time.Time) (IngestResult, error) {
+	defer lockUpload(session.ID)()
+
+	// Файл уже принят целиком, остается разобрать его - прогресс этого разбора видно в /events
+	staged, err := StageFile(UploadSessionPath(session.ID), NewUploadProgress(session.UserID, session.ID, session.Filename, session.Length))
+	if err != nil {
+		return IngestResult{}, err
+	}
+	staged.Source = SourceUpload
+
+	result, err := IngestDocument(ctx, session.UserID, session.Filename, staged, startTime)
+	if err != nil {
+		// Файл остается, загрузку можно будет завершить повторным запросом - он заново построит индекс,
+		// поэтому куски индекса этой попытки удаляем
+		staged.Postings.Remove()
+		return IngestResult{}, err
+	}
+
+	staged.Remove()
+	if err := database.DB.Delete(&session).Error; err != nil {
+		log.Printf("Failed to delete upload session %s: %v", session.ID, err)
+	}
+	uploadLocks.Delete(session.ID)
+	return result, nil
+}
+
+// RemoveUploadFiles удаляет временные файлы загрузок (записи удаляет вызывающий или каскад)
+func RemoveUploadFiles(sessions []models.UploadSession) {
+	for _, session := range sessions {
+		if err := os.Remove(UploadSessionPath(session.ID)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove upload file %s: %v", session.ID, err)
+		}
+		uploadLocks.Delete(session.ID)
+	}
+}
+
+// CleanupStaleUploads удаляет загрузки, в которые давно ничего не дописывали
+func CleanupStaleUploads() {
+	var sessions []models.UploadSession
+	if err := database.DB.Where("updated_at < ?", time.Now().Add(-uploadSessionTTL)).Find(&sessions).Error; err != nil {
+		log.Printf("Failed to get stale upload sessions: %v", err)
+		return
+	}
+	if len(sessions) == 0 {
+		return
+	}
+
+	if err := database.DB.Delete(&sessions).Error; err != nil {
+		log.Printf("Failed to delete stale upload sessions: %v", err)
+		return
+	}
+	RemoveUploadFiles(sessions)
+}
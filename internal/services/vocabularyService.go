@@ -0,0 +1,351 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Насколько далеко (в правках Левенштейна) ищем похожие слова
+const MaxSuggestDistance = 3
+
+// Suggestion - похожее слово из словаря пользователя
+type Suggestion struct {
+	Term     string `json:"term"`
+	Distance int    `json:"distance"` // сколько правок до запроса
+	DF       int    `json:"df"`       // в скольких документах встречается
+}
+
+// Vocabulary - все слова документов пользователя с документной частотой,
+// разложенные в BK-дерево для нечеткого поиска
+type Vocabulary struct {
+	root *bkNode
+	df   map[string]int
+}
+
+// bkNode - узел BK-дерева: у детей расстояние до слова узла равно ключу
+type bkNode struct {
+	term     string
+	children map[int]*bkNode
+}
+
+func NewVocabulary(df map[string]int) *Vocabulary {
+	v := &Vocabulary{df: df}
+
+	// Порядок вставки влияет на форму дерева, сортируем, чтобы она не зависела от map
+	terms := make([]string, 0, len(df))
+	for term := range df {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	for _, term := range terms {
+		v.insert(term)
+	}
+	return v
+}
+
+func (v *Vocabulary) insert(term string) {
+	if v.root == nil {
+		v.root = &bkNode{term: term}
+		return
+	}
+
+	node := v.root
+	for {
+		distance := Levenshtein(term, node.term)
+		child, ok := node.children[distance]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[distance] = &bkNode{term: term}
+			return
+		}
+		node = child
+	}
+}
+
+// Примерно столько байт на слово, кроме самих букв: узел дерева, запись в df и в карте детей родителя
+const vocabularyTermOverhead = 160
+
+// size - примерный объем словаря в памяти, для лимита кэша
+func (v *Vocabulary) size() int64 {
+	size := int64(len(v.df)) * vocabularyTermOverhead
+	for term := range v.df {
+		size += int64(len(term))
+	}
+	return size
+}
+
+// DF возвращает документную частоту слова, 0 - слова в словаре нет
+func (v *Vocabulary) DF(term string) int {
+	return v.df[term]
+}
+
+// Suggest ищет слова не дальше maxDistance правок от term, кроме него самого.
+// Сначала ближайшие, среди одинаково далеких - встречающиеся в большем числе документов.
+func (v *Vocabulary) Suggest(term string, maxDistance, limit int) []Suggestion {
+	suggestions := []Suggestion{}
+	if v.root == nil {
+		return suggestions
+	}
+
+	// По неравенству треугольника подходящие слова лежат только в детях
+	// с ключом из [d - maxDistance, d + maxDistance]
+	stack := []*bkNode{v.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		distance := Levenshtein(term, node.term)
+		if distance <= maxDistance && distance > 0 {
+			suggestions = append(suggestions, Suggestion{Term: node.term, Distance: distance, DF: v.df[node.term]})
+		}
+		for key, child := range node.children {
+			if key >= distance-maxDistance && key <= distance+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.DF != b.DF {
+			return a.DF > b.DF
+		}
+		return a.Term < b.Term
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// DefaultSuggestDistance - сколько опечаток допускаем по умолчанию: в коротком слове одну, в длинном две
+func DefaultSuggestDistance(term string) int {
+	if len([]rune(term)) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// Levenshtein считает расстояние редактирования между словами по символам
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Словари пользователей строятся по позиционному индексу и живут, пока не поменяются документы.
+// В памяти держим не больше CACHE_SIZE словарей и CACHE_MEMORY_BYTES байт, давно не нужные вытесняются.
+var vocabularies = NewContentCache("", config.Init.CacheSize, config.Init.CacheMemoryBytes, 0) // userID -> *cachedVocabulary
+
+type cachedVocabulary struct {
+	fingerprint string
+	vocabulary  *Vocabulary
+}
+
+// UserVocabulary возвращает словарь всех документов пользователя.
+// Дерево строится заново, только если документы добавлялись, менялись или удалялись.
+func UserVocabulary(db *gorm.DB, userID int) (*Vocabulary, error) {
+	var state struct {
+		Count     int64
+		UpdatedAt *string
+	}
+	err := db.Model(&models.Document{}).Select("COUNT(*) AS count, MAX(updated_at)::text AS updated_at").
+		Where("user_id = ?", userID).Scan(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := fmt.Sprint(state.Count)
+	if state.UpdatedAt != nil {
+		fingerprint += "/" + *state.UpdatedAt
+	}
+
+	key := strconv.Itoa(userID)
+	if cached, ok := vocabularies.getMemory(key); ok && cached.(*cachedVocabulary).fingerprint == fingerprint {
+		return cached.(*cachedVocabulary).vocabulary, nil
+	}
+
+	var rows []struct {
+		Term string
+		DF   int
+	}
+	err = db.Table("term_postings").
+		Select("term_postings.term, COUNT(DISTINCT documents.id) AS df").
+		Joins("JOIN documents ON documents.content_hash = term_postings.content_hash").
+		Where("documents.user_id = ?", userID).
+		Group("term_postings.term").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	df := make(map[string]int, len(rows))
+	for _, row := range rows {
+		df[row.Term] = row.DF
+	}
+
+	vocabulary := NewVocabulary(df)
+	vocabularies.setMemory(key, &cachedVocabulary{fingerprint: fingerprint, vocabulary: vocabulary}, vocabulary.size())
+	return vocabulary, nil
+}
+
+// VocabularyTerm - слово словаря коллекции
+type VocabularyTerm struct {
+	Term     string  `json:"term"`
+	DF       int     `json:"df"`        // в скольких документах встречается
+	CF       int     `json:"cf"`        // сколько раз встречается во всей коллекции
+	IDF      float64 `json:"idf"`       // ln(documents / df)
+	ZipfRank int     `json:"zipf_rank"` // место по частоте cf, 1 - самое частое
+	Hapax    bool    `json:"hapax"`     // встречается в коллекции ровно один раз
+}
+
+type VocabularyFilter struct {
+	Prefix  string
+	MinDF   int
+	MaxDF   int // 0 - без ограничения
+	Page    int
+	PerPage int
+}
+
+type VocabularyPage struct {
+	Documents      int              `json:"documents"`       // документов в коллекции
+	VocabularySize int              `json:"vocabulary_size"` // разных слов во всей коллекции
+	Tokens         int              `json:"tokens"`          // слов во всей коллекции
+	Hapaxes        int              `json:"hapaxes"`         // слов, встретившихся один раз
+	Total          int              `json:"total"`           // слов, подходящих под фильтр
+	Page           int              `json:"page"`
+	PerPage        int              `json:"per_page"`
+	Terms          []VocabularyTerm `json:"terms"` // от частых к редким
+}
+
+// Словарь коллекции по позиционному индексу: df и cf каждого слова, ранг по cf
+const collectionVocabularySQL = `
+WITH vocabulary AS (
+	SELECT term_postings.term, COUNT(*) AS df, SUM(term_postings.count) AS cf
+	FROM collection_documents
+	JOIN documents ON documents.id = collection_documents.document_id
+	JOIN term_postings ON term_postings.content_hash = documents.content_hash
+	WHERE collection_documents.collection_id = @collection
+	GROUP BY term_postings.term
+)`
+
+// CollectionVocabulary возвращает страницу словаря коллекции. Ранг Ципфа считается по всему словарю,
+// поэтому у отфильтрованных слов он тот же, что и без фильтра.
+func CollectionVocabulary(db *gorm.DB, collectionID uint, filter VocabularyFilter) (VocabularyPage, error) {
+	page := VocabularyPage{Page: filter.Page, PerPage: filter.PerPage, Terms: []VocabularyTerm{}}
+
+	var documents int64
+	if err := db.Model(&models.CollectionDocument{}).Where("collection_id = ?", collectionID).Count(&documents).Error; err != nil {
+		return page, err
+	}
+	page.Documents = int(documents)
+
+	var summary struct {
+		VocabularySize int
+		Tokens         int
+		Hapaxes        int
+	}
+	err := db.Raw(collectionVocabularySQL+`
+		SELECT COUNT(*) AS vocabulary_size, COALESCE(SUM(cf), 0) AS tokens, COUNT(*) FILTER (WHERE cf = 1) AS hapaxes
+		FROM vocabulary`,
+		map[string]any{"collection": collectionID},
+	).Scan(&summary).Error
+	if err != nil {
+		return page, err
+	}
+	page.VocabularySize, page.Tokens, page.Hapaxes = summary.VocabularySize, summary.Tokens, summary.Hapaxes
+
+	maxDF := filter.MaxDF
+	if maxDF <= 0 {
+		maxDF = page.Documents
+	}
+
+	var rows []struct {
+		Term     string
+		DF       int
+		CF       int
+		ZipfRank int
+		Total    int
+	}
+	err = db.Raw(collectionVocabularySQL+`,
+		ranked AS (
+			SELECT term, df, cf, RANK() OVER (ORDER BY cf DESC) AS zipf_rank FROM vocabulary
+		)
+		SELECT term, df, cf, zipf_rank, COUNT(*) OVER () AS total
+		FROM ranked
+		WHERE term LIKE @prefix AND df BETWEEN @min_df AND @max_df
+		ORDER BY zipf_rank, term
+		LIMIT @limit OFFSET @offset`,
+		map[string]any{
+			"collection": collectionID,
+			// В словах только буквы, экранировать % и _ не нужно
+			"prefix": filter.Prefix + "%",
+			"min_df": filter.MinDF,
+			"max_df": maxDF,
+			"limit":  filter.PerPage,
+			"offset": (filter.Page - 1) * filter.PerPage,
+		},
+	).Scan(&rows).Error
+	if err != nil {
+		return page, err
+	}
+
+	for _, row := range rows {
+		page.Total = row.Total
+		page.Terms = append(page.Terms, VocabularyTerm{
+			Term:     row.Term,
+			DF:       row.DF,
+			CF:       row.CF,
+			IDF:      math.Log(float64(page.Documents) / float64(row.DF)),
+			ZipfRank: row.ZipfRank,
+			Hapax:    row.CF == 1,
+		})
+	}
+
+	// Страница за концом списка пуста, но сколько всего подходящих слов все равно нужно знать
+	if len(rows) == 0 && filter.Page > 1 {
+		var total int
+		err := db.Raw(collectionVocabularySQL+`
+			SELECT COUNT(*) FROM vocabulary WHERE term LIKE @prefix AND df BETWEEN @min_df AND @max_df`,
+			map[string]any{"collection": collectionID, "prefix": filter.Prefix + "%", "min_df": filter.MinDF, "max_df": maxDF},
+		).Scan(&total).Error
+		if err != nil {
+			return page, err
+		}
+		page.Total = total
+	}
+
+	return page, nil
+}
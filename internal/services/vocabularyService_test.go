@@ -0,0 +1,113 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"кот", "", 3},
+		{"кот", "кот", 0},
+		{"кот", "кит", 1},    // замена
+		{"кот", "крот", 1},   // вставка
+		{"кошка", "кошк", 1}, // удаление
+		{"кот", "ток", 2},
+		{"молоко", "малако", 2},
+		{"kitten", "sitting", 3},
+		{"ёж", "еж", 1}, // считаются буквы, а не байты
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Levenshtein(tt.b, tt.a); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultSuggestDistance(t *testing.T) {
+	tests := []struct {
+		term string
+		want int
+	}{
+		{"кот", 1},
+		{"кошк", 1},
+		{"кошка", 2},
+		{"programming", 2},
+	}
+	for _, tt := range tests {
+		if got := DefaultSuggestDistance(tt.term); got != tt.want {
+			t.Errorf("DefaultSuggestDistance(%q) = %d, want %d", tt.term, got, tt.want)
+		}
+	}
+}
+
+var testVocabulary = map[string]int{
+	"кот": 5, "кит": 2, "код": 7, "крот": 1, "кошка": 4, "мошка": 1, "окошко": 2,
+	"ток": 3, "рот": 2, "кто": 6, "котлета": 1, "собака": 3, "кости": 1, "кота": 2,
+}
+
+func TestVocabularySuggest(t *testing.T) {
+	vocabulary := NewVocabulary(testVocabulary)
+
+	// Сначала ближе, при равном расстоянии - чаще встречающиеся, затем по алфавиту; само слово не предлагается
+	got := vocabulary.Suggest("кот", 1, 10)
+	want := []Suggestion{
+		{"код", 1, 7}, {"кит", 1, 2}, {"кота", 1, 2}, {"рот", 1, 2}, {"крот", 1, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest(кот, 1) = %v, want %v", got, want)
+	}
+
+	if got := vocabulary.Suggest("кот", 1, 2); !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("Suggest(кот, 1, limit 2) = %v, want %v", got, want[:2])
+	}
+	if got := vocabulary.Suggest("кошка", 1, 10); !reflect.DeepEqual(got, []Suggestion{{"мошка", 1, 1}}) {
+		t.Errorf("Suggest(кошка, 1) = %v", got)
+	}
+	if got := vocabulary.Suggest("жираф", 1, 10); len(got) != 0 {
+		t.Errorf("Suggest(жираф, 1) = %v, want nothing", got)
+	}
+	if got := NewVocabulary(nil).Suggest("кот", 3, 10); got == nil || len(got) != 0 {
+		t.Errorf("empty vocabulary: %v", got)
+	}
+	if vocabulary.DF("кошка") != 4 || vocabulary.DF("жираф") != 0 {
+		t.Errorf("DF: кошка %d, жираф %d", vocabulary.DF("кошка"), vocabulary.DF("жираф"))
+	}
+}
+
+// Отсечение веток по неравенству треугольника не должно терять слов: сверяем с перебором
+func TestVocabularySuggestMatchesBruteForce(t *testing.T) {
+	vocabulary := NewVocabulary(testVocabulary)
+
+	for _, term := range []string{"кот", "кошки", "сабака", "котлеты", "о", "точка", "кто"} {
+		for distance := 0; distance <= MaxSuggestDistance; distance++ {
+			want := []Suggestion{}
+			for word, df := range testVocabulary {
+				if d := Levenshtein(term, word); d > 0 && d <= distance {
+					want = append(want, Suggestion{Term: word, Distance: d, DF: df})
+				}
+			}
+			sort.Slice(want, func(i, j int) bool {
+				if want[i].Distance != want[j].Distance {
+					return want[i].Distance < want[j].Distance
+				}
+				if want[i].DF != want[j].DF {
+					return want[i].DF > want[j].DF
+				}
+				return want[i].Term < want[j].Term
+			})
+
+			if got := vocabulary.Suggest(term, distance, len(testVocabulary)); !reflect.DeepEqual(got, want) {
+				t.Errorf("Suggest(%q, %d) = %v, want %v", term, distance, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"tfidf-app/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultWatchTopN = 20
+	MaxWatchTopN     = 100
+	// Событие вебхука о срабатывании отслеживания
+	EventWatchAlert = "watch.alert"
+)
+
+var ErrInvalidWatch = errors.New("watch needs either a term or a query, not both")
+
+// Тело вебхука о срабатывании
+type WatchAlertPayload struct {
+	Event string            `json:"event"`
+	Watch models.Watch      `json:"watch"`
+	Alert models.WatchAlert `json:"alert"`
+}
+
+// Слово документа в его топе TF-IDF
+type rankedTerm struct {
+	Rank  int
+	Score float64
+}
+
+// MatchWatches проверяет новое содержимое документа по отслеживаниям пользователя,
+// записывает срабатывания и ставит в очередь вебхуки. Ошибки только логируются:
+// документ уже сохранен, отслеживание не должно ломать загрузку.
+func MatchWatches(db *gorm.DB, document models.Document, counts WordCounts) []models.WatchAlert {
+	var watches []models.Watch
+	if err := db.Where("user_id = ?", document.UserID).Order("id").Find(&watches).Error; err != nil {
+		log.Printf("Failed to get watches: %v", err)
+		return nil
+	}
+	if len(watches) == 0 {
+		return nil
+	}
+
+	// Топ слов нужен один на все отслеживания слов - берем самый длинный
+	topN := 0
+	for _, watch := range watches {
+		if watch.Term != "" {
+			topN = max(topN, watch.TopN)
+		}
+	}
+	var ranks map[string]rankedTerm
+	if topN > 0 {
+		var err error
+		if ranks, err = rankDocumentTerms(db, document.UserID, counts, topN); err != nil {
+			log.Printf("Failed to rank document %d terms: %v", document.ID, err)
+			return nil
+		}
+	}
+
+	var alerts []models.WatchAlert
+	var matched []models.Watch
+	for _, watch := range watches {
+		alert := models.WatchAlert{
+			WatchID:      watch.ID,
+			DocumentID:   document.ID,
+			DocumentName: document.Name,
+			Version:      document.Version,
+		}
+
+		if watch.Term != "" {
+			ranked, ok := ranks[watch.Term]
+			if !ok || ranked.Rank > watch.TopN {
+				continue
+			}
+			alert.Rank, alert.Score = ranked.Rank, ranked.Score
+		} else {
+			node, err := ParseQuery(watch.Query)
+			if err != nil {
+				log.Printf("Invalid query of watch %d: %v", watch.ID, err)
+				continue
+			}
+			// Индекс содержимого уже сохранен вместе с документом
+			result, err := SearchDocuments(db, []models.Document{document}, watch.Query, node, 1)
+			if err != nil {
+				log.Printf("Failed to match watch %d: %v", watch.ID, err)
+				continue
+			}
+			if result.Total == 0 {
+				continue
+			}
+			alert.Hits = result.Documents[0].Hits
+		}
+
+		alerts = append(alerts, alert)
+		matched = append(matched, watch)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	queued := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&alerts).Error; err != nil {
+			return err
+		}
+
+		for i, watch := range matched {
+			if watch.WebhookURL == "" {
+				continue
+			}
+			delivery, err := NewWebhookDelivery(watch.UserID, watch.WebhookURL, EventWatchAlert,
+				WatchAlertPayload{Event: EventWatchAlert, Watch: watch, Alert: alerts[i]})
+			if err != nil {
+				return err
+			}
+			delivery.WatchID = &watch.ID
+			if err := tx.Create(&delivery).Error; err != nil {
+				return err
+			}
+			queued = true
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to save watch alerts for document %d: %v", document.ID, err)
+		return nil
+	}
+
+	if queued {
+		WakeWebhookWorker()
+	}
+	return alerts
+}
+
+// rankDocumentTerms возвращает limit самых весомых слов документа: TF * ln(N / df),
+// где N и df - по всей библиотеке пользователя (вместе с этим документом).
+// При равном весе выше более частое слово, затем - по алфавиту.
+func rankDocumentTerms(db *gorm.DB, userID int, counts WordCounts, limit int) (map[string]rankedTerm, error) {
+	vocabulary, err := UserVocabulary(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents int64
+	if err := db.Model(&models.Document{}).Where("user_id = ?", userID).Count(&documents).Error; err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		term  string
+		count int
+		score float64
+	}
+	terms := make([]scored, 0, len(counts.Counts))
+	for term, count := range counts.Counts {
+		// Слова нет в словаре, только если индекс еще не догнал документ - считаем его единственным
+		df := max(vocabulary.DF(term), 1)
+		tf := float64(count) / float64(max(counts.Total, 1))
+		terms = append(terms, scored{term: term, count: count, score: tf * math.Log(float64(max(documents, 1))/float64(df))})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].score != terms[j].score {
+			return terms[i].score > terms[j].score
+		}
+		if terms[i].count != terms[j].count {
+			return terms[i].count > terms[j].count
+		}
+		return terms[i].term < terms[j].term
+	})
+
+	ranks := make(map[string]rankedTerm, min(limit, len(terms)))
+	for i, term := range terms {
+		if i == limit {
+			break
+		}
+		ranks[term.term] = rankedTerm{Rank: i + 1, Score: term.score}
+	}
+	return ranks, nil
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/database"
+	"tfidf-app/internal/models"
+	"time"
+)
+
+// Заголовки запроса вебхука. Подпись - HMAC-SHA256 тела ключом получателя: "sha256=<hex>"
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	webhookPollInterval = 15 * time.Second
+	webhookBatchSize    = 50
+	// Повторы с экспоненциальной задержкой: 30 с, 1 мин, 2 мин ... но не больше часа
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = time.Hour
+	// Сколько байт ответа получателя сохраняем в last_error
+	webhookErrorBodyLimit = 512
+)
+
+var ErrInvalidWebhookURL = errors.New("webhook_url must be an absolute http or https url")
+
+// Отслеживание или подписку удалили, пока доставка ждала в очереди: подписать и отправить ее нечем
+var errDeliveryOwnerGone = errors.New("delivery owner was deleted")
+
+// Будит обработчик доставок, чтобы новое событие ушло сразу, а не на следующем тике
+var webhookWake = make(chan struct{}, 1)
+
+// ValidateWebhookURL проверяет адрес при сохранении. Внутренние адреса отсекаются при отправке (WEBHOOK_ALLOWLIST)
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// NewWebhookSecret генерирует ключ подписи: 32 случайных байта в hex
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// SignWebhookPayload возвращает значение заголовка X-Webhook-Signature для тела body
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookDelivery готовит доставку события event на адрес target, первая попытка - сразу
+func NewWebhookDelivery(userID int, target, event string, payload any) (models.WebhookDelivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	now := time.Now()
+	return models.WebhookDelivery{
+		UserID:        userID,
+		Event:         event,
+		URL:           target,
+		Payload:       string(body),
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+	}, nil
+}
+
+// WakeWebhookWorker просит обработчик доставок проверить очередь, не дожидаясь тика
+func WakeWebhookWorker() {
+	select {
+	case webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunWebhookDeliveries - фоновый обработчик очереди доставок, работает до отмены ctx.
+// Очередь лежит в базе, поэтому недоставленное переживает перезапуск.
+func RunWebhookDeliveries(ctx context.Context) {
+	client := newWebhookClient()
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliverPendingWebhooks(ctx, client)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-webhookWake:
+		}
+	}
+}
+
+// deliverPendingWebhooks делает очередную попытку для доставок, время которых подошло
+func deliverPendingWebhooks(ctx context.Context, client *http.Client) {
+	var deliveries []models.WebhookDelivery
+	if err := database.DB.Preload("Watch").Preload("Webhook").
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at").Limit(webhookBatchSize).
+		Find(&deliveries).Error; err != nil {
+		log.Printf("Failed to get pending webhook deliveries: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		if !claimDelivery(delivery) {
+			continue
+		}
+		attemptDelivery(ctx, client, delivery)
+	}
+}
+
+// claimDelivery откладывает следующую попытку на время текущей:
+// другой экземпляр сервиса, выбравший ту же доставку, ее не получит
+func claimDelivery(delivery models.WebhookDelivery) bool {
+	lease := time.Now().Add(2 * config.Init.WebhookTimeout)
+	result := database.DB.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", lease)
+	if result.Error != nil {
+		log.Printf("Failed to claim webhook delivery %d: %v", delivery.ID, result.Error)
+		return false
+	}
+	return result.RowsAffected == 1
+}
+
+// attemptDelivery отправляет событие и записывает результат попытки
+func attemptDelivery(ctx context.Context, client *http.Client, delivery models.WebhookDelivery) {
+	status, err := postWebhook(ctx, client, delivery)
+	updates := deliveryUpdates(delivery, status, err, time.Now())
+
+	if err := database.DB.Model(&delivery).Updates(updates).Error; err != nil {
+		log.Printf("Failed to save webhook delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// deliveryUpdates - изменения доставки после попытки, ответившей status с ошибкой err:
+// успех, повтор через webhookBackoff или провал, если попытки кончились или владельца уже нет
+func deliveryUpdates(delivery models.WebhookDelivery, status int, err error, now time.Time) map[string]any {
+	updates := map[string]any{
+		"attempts":        delivery.Attempts + 1,
+		"response_status": status,
+		"last_error":      "",
+	}
+	switch {
+	case err == nil:
+		updates["status"] = models.DeliveryDelivered
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	case errors.Is(err, errDeliveryOwnerGone), delivery.Attempts+1 >= config.Init.WebhookMaxAttempts:
+		updates["status"] = models.DeliveryFailed
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = nil
+	default:
+		updates["last_error"] = err.Error()
+		updates["next_attempt_at"] = now.Add(webhookBackoff(delivery.Attempts + 1))
+	}
+	return updates
+}
+
+// postWebhook отправляет подписанный POST. Успех - любой ответ 2xx
+func postWebhook(ctx context.Context, client *http.Client, delivery models.WebhookDelivery) (int, error) {
+	secret, err := deliverySecret(delivery)
+	if err != nil {
+		return 0, err
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tfidf-app-webhook")
+	req.Header.Set(WebhookEventHeader, delivery.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, webhookErrorBodyLimit))
+		return resp.StatusCode, fmt.Errorf("receiver responded %s: %s", resp.Status, reply)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookErrorBodyLimit))
+	return resp.StatusCode, nil
+}
+
+// deliverySecret - ключ подписи владельца доставки
+func deliverySecret(delivery models.WebhookDelivery) (string, error) {
+	switch {
+	case delivery.Watch != nil:
+		return delivery.Watch.Secret, nil
+	case delivery.Webhook != nil:
+		return delivery.Webhook.Secret, nil
+	}
+	return "", errDeliveryOwnerGone
+}
+
+// webhookBackoff - задержка перед попыткой после attempts неудачных
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
+
+// newWebhookClient - клиент без редиректов, во внутреннюю сеть - только хосты из WEBHOOK_ALLOWLIST
+func newWebhookClient() *http.Client {
+	return &http.Client{
+		Timeout: config.Init.WebhookTimeout,
+		Transport: &http.Transport{
+			DialContext:           publicDialContext(config.Init.WebhookTimeout, config.Init.WebhookAllowlist),
+			TLSHandshakeTimeout:   config.Init.WebhookTimeout,
+			ResponseHeaderTimeout: config.Init.WebhookTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
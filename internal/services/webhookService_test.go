@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"tfidf-app/internal/config"
+	"tfidf-app/internal/models"
+	"time"
+)
+
+// withWebhookConfig разрешает вебхуки на локальный httptest-сервер
+func withWebhookConfig(t *testing.T, maxAttempts int) {
+	t.Helper()
+
+	saved := config.Init
+	t.Cleanup(func() { config.Init = saved })
+
+	config.Init.WebhookAllowlist = []string{"127.0.0.1"}
+	config.Init.WebhookTimeout = 5 * time.Second
+	config.Init.WebhookMaxAttempts = maxAttempts
+}
+
+func newTestDelivery(t *testing.T, target string) models.WebhookDelivery {
+	t.Helper()
+
+	delivery, err := NewWebhookDelivery(1, target, EventWatchAlert, map[string]any{"watch_id": 7, "score": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery.ID = 42
+	delivery.Watch = &models.Watch{Secret: "watch-secret"}
+	return delivery
+}
+
+func TestPostWebhookSignsPayload(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Header, body}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	withWebhookConfig(t, 3)
+
+	delivery := newTestDelivery(t, srv.URL+"/hook")
+	status, err := postWebhook(context.Background(), newWebhookClient(), delivery)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("postWebhook = %d, %v", status, err)
+	}
+
+	got := <-requests
+	if string(got.body) != delivery.Payload {
+		t.Errorf("body = %s, want %s", got.body, delivery.Payload)
+	}
+	if want := SignWebhookPayload("watch-secret", got.body); got.header.Get(WebhookSignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", got.header.Get(WebhookSignatureHeader), want)
+	}
+	if got.header.Get(WebhookEventHeader) != EventWatchAlert {
+		t.Errorf("event = %q", got.header.Get(WebhookEventHeader))
+	}
+	if got.header.Get(WebhookDeliveryHeader) != strconv.Itoa(42) {
+		t.Errorf("delivery id = %q", got.header.Get(WebhookDeliveryHeader))
+	}
+
+	// Подписка на события подписывает своим ключом
+	delivery.Watch, delivery.Webhook = nil, &models.Webhook{Secret: "webhook-secret"}
+	if _, err := postWebhook(context.Background(), newWebhookClient(), delivery); err != nil {
+		t.Fatal(err)
+	}
+	got = <-requests
+	if want := SignWebhookPayload("webhook-secret", got.body); got.header.Get(WebhookSignatureHeader) != want {
+		t.Errorf("webhook signature = %q, want %q", got.header.Get(WebhookSignatureHeader), want)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// HMAC-SHA256 из RFC 4231, тест 2
+	got := SignWebhookPayload("Jefe", []byte("what do ya want for nothing?"))
+	if want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestWebhookRetriesUntilFailed(t *testing.T) {
+	srv, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "receiver is down", http.StatusServiceUnavailable)
+	})
+	withWebhookConfig(t, 3)
+
+	client := newWebhookClient()
+	delivery := newTestDelivery(t, srv.URL)
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	for _, wantDelay := range []time.Duration{30 * time.Second, time.Minute} {
+		status, err := postWebhook(context.Background(), client, delivery)
+		if status != http.StatusServiceUnavailable || err == nil {
+			t.Fatalf("postWebhook = %d, %v; want 503 error", status, err)
+		}
+
+		updates := deliveryUpdates(delivery, status, err, now)
+		if _, ok := updates["status"]; ok {
+			t.Fatalf("attempt %d: status changed to %v", delivery.Attempts+1, updates["status"])
+		}
+		if next := updates["next_attempt_at"]; next != now.Add(wantDelay) {
+			t.Errorf("attempt %d: next attempt at %v, want %v", delivery.Attempts+1, next, now.Add(wantDelay))
+		}
+		if !strings.Contains(updates["last_error"].(string), "receiver is down") {
+			t.Errorf("last_error = %q", updates["last_error"])
+		}
+		delivery.Attempts = updates["attempts"].(int)
+	}
+
+	// Третья неудачная попытка из WEBHOOK_MAX_ATTEMPTS=3 - последняя
+	status, err := postWebhook(context.Background(), client, delivery)
+	updates := deliveryUpdates(delivery, status, err, now)
+	if updates["status"] != models.DeliveryFailed || updates["next_attempt_at"] != nil || updates["attempts"] != 3 {
+		t.Errorf("after max attempts: %v", updates)
+	}
+	if updates["response_status"] != http.StatusServiceUnavailable {
+		t.Errorf("response_status = %v", updates["response_status"])
+	}
+	if hits.Load() != 3 {
+		t.Errorf("receiver got %d requests, want 3", hits.Load())
+	}
+}
+
+func TestWebhookWithoutOwnerFailsImmediately(t *testing.T) {
+	srv, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	withWebhookConfig(t, 3)
+
+	delivery := newTestDelivery(t, srv.URL)
+	delivery.Watch = nil
+
+	status, err := postWebhook(context.Background(), newWebhookClient(), delivery)
+	if !errors.Is(err, errDeliveryOwnerGone) {
+		t.Fatalf("err = %v, want errDeliveryOwnerGone", err)
+	}
+	updates := deliveryUpdates(delivery, status, err, time.Now())
+	if updates["status"] != models.DeliveryFailed || updates["next_attempt_at"] != nil || updates["attempts"] != 1 {
+		t.Errorf("updates = %v", updates)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("receiver got %d requests", hits.Load())
+	}
+}
+
+func TestDeliveryUpdatesOnSuccess(t *testing.T) {
+	withWebhookConfig(t, 3)
+
+	now := time.Now()
+	delivery := models.WebhookDelivery{Attempts: 2, Status: models.DeliveryPending}
+	updates := deliveryUpdates(delivery, http.StatusOK, nil, now)
+	if updates["status"] != models.DeliveryDelivered || updates["delivered_at"] != now || updates["next_attempt_at"] != nil {
+		t.Errorf("updates = %v", updates)
+	}
+	if updates["attempts"] != 3 || updates["last_error"] != "" {
+		t.Errorf("updates = %v", updates)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:   30 * time.Second,
+		2:   time.Minute,
+		3:   2 * time.Minute,
+		4:   4 * time.Minute,
+		7:   32 * time.Minute,
+		8:   time.Hour,
+		9:   time.Hour,
+		100: time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := webhookBackoff(attempts); got != want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestPostWebhookDoesNotFollowRedirects(t *testing.T) {
+	target, targetHits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv, _ := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+"/internal", http.StatusTemporaryRedirect)
+	})
+	withWebhookConfig(t, 3)
+
+	status, err := postWebhook(context.Background(), newWebhookClient(), newTestDelivery(t, srv.URL))
+	if status != http.StatusTemporaryRedirect || err == nil {
+		t.Errorf("postWebhook = %d, %v; want 307 error", status, err)
+	}
+	if targetHits.Load() != 0 {
+		t.Errorf("redirect target got %d requests", targetHits.Load())
+	}
+}
+
+func TestPostWebhookRejectsHostNotInAllowlist(t *testing.T) {
+	srv, hits := newPageServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	withWebhookConfig(t, 3)
+	config.Init.WebhookAllowlist = nil
+
+	if _, err := postWebhook(context.Background(), newWebhookClient(), newTestDelivery(t, srv.URL)); !errors.Is(err, ErrFetchNotAllowed) {
+		t.Errorf("err = %v, want ErrFetchNotAllowed", err)
+	}
+	if hits.Load() != 0 {
+		t.Errorf("receiver got %d requests", hits.Load())
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutGetStatDelete(t *testing.T) {
+	root := t.TempDir()
+	local := NewLocalStorage(root)
+	ctx := context.Background()
+	key := "user_1/docs/a.txt"
+
+	if err := local.Put(ctx, key, strings.NewReader("hello"), -1); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "user_1", "docs", "a.txt")); err != nil || string(data) != "hello" {
+		t.Fatalf("file on disk = %q, %v", data, err)
+	}
+
+	// Перезапись заменяет файл целиком
+	if err := local.Put(ctx, key, strings.NewReader("hi"), 2); err != nil {
+		t.Fatalf("Put again: %v", err)
+	}
+
+	rc, err := local.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hi" {
+		t.Errorf("Get = %q, want %q", data, "hi")
+	}
+
+	obj, err := local.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if obj.Key != key || obj.Size != 2 {
+		t.Errorf("Stat = %+v", obj)
+	}
+
+	if err := local.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := local.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v, want ErrNotFound", err)
+	}
+	if _, err := local.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete: %v, want ErrNotFound", err)
+	}
+	if err := local.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of missing key: %v", err)
+	}
+}
+
+func TestLocalList(t *testing.T) {
+	root := t.TempDir()
+	local := NewLocalStorage(root)
+	ctx := context.Background()
+
+	for _, key := range []string{"user_1/a.txt", "user_1/sub/b.txt", "user_2/c.txt"} {
+		if err := local.Put(ctx, key, strings.NewReader(key), -1); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+	// Недописанный временный файл в список не попадает
+	if err := os.WriteFile(filepath.Join(root, "user_1", "a.txt.123.tmp"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := local.List(ctx, "user_1/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var got []string
+	for _, obj := range objects {
+		got = append(got, obj.Key)
+	}
+	if want := "user_1/a.txt,user_1/sub/b.txt"; strings.Join(got, ",") != want {
+		t.Errorf("List = %v, want %s", got, want)
+	}
+
+	// Хранилище, в которое еще ничего не писали
+	if objects, err := NewLocalStorage(filepath.Join(root, "missing")).List(ctx, ""); err != nil || len(objects) != 0 {
+		t.Errorf("List of missing root = %v, %v", objects, err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "storage")
+	local := NewLocalStorage(root)
+	ctx := context.Background()
+
+	for _, key := range []string{"../escape.txt", "user_1/../../escape.txt", "/etc/passwd", `user_1\..\escape.txt`, "user_1/./a.txt", ""} {
+		if err := local.Put(ctx, key, strings.NewReader("x"), 1); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := local.Get(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want invalid key error", key, err)
+		}
+		if err := local.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+		if _, err := local.Stat(ctx, key); err == nil {
+			t.Errorf("Stat(%q) succeeded", key)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "escape.txt")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file was written outside the storage root")
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"user_1/a.txt", true},
+		{"user_1/sub/отчет.txt", true},
+		{"blobs/ab/abcdef", true},
+		{"a..b/c", true},
+		{"", false},
+		{"..", false},
+		{"../a.txt", false},
+		{"user_1/../a.txt", false},
+		{"user_1/..", false},
+		{"./a.txt", false},
+		{"/a.txt", false},
+		{"user_1//a.txt", false},
+		{"user_1/", false},
+		{`user_1\a.txt`, false},
+	}
+
+	for _, tt := range tests {
+		if err := validateKey(tt.key); (err == nil) != tt.valid {
+			t.Errorf("validateKey(%q) = %v, want valid=%v", tt.key, err, tt.valid)
+		}
+	}
+}
+
+func TestLocalGetRange(t *testing.T) {
+	local := NewLocalStorage(t.TempDir())
+	ctx := context.Background()
+	if err := local.Put(ctx, "user_1/a.txt", strings.NewReader("0123456789"), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	for offset, want := range map[int64]string{0: "0123456789", 3: "3456789", 9: "9", 10: "", 20: ""} {
+		rc, err := local.GetRange(ctx, "user_1/a.txt", offset)
+		if err != nil {
+			t.Fatalf("GetRange(%d): %v", offset, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(data) != want {
+			t.Errorf("GetRange(%d) = %q, want %q", offset, data, want)
+		}
+	}
+
+	if _, err := local.GetRange(ctx, "user_1/missing.txt", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRange of missing key: %v, want ErrNotFound", err)
+	}
+	if _, err := local.GetRange(ctx, "../a.txt", 1); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRange outside the root: %v", err)
+	}
+}
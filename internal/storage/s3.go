@@ -0,0 +1,489 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type S3Options struct {
+	Endpoint  string // например http://minio:9000
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Storage - S3-совместимое хранилище (AWS S3, MinIO и т.п.).
+// Запросы подписываются AWS Signature V4, адресация path-style: endpoint/bucket/key.
+type S3Storage struct {
+	endpoint *url.URL
+	opts     S3Options
+	client   *http.Client
+	partSize int // размер части multipart-загрузки
+}
+
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Файл неизвестного размера грузится частями такого размера (S3 требует от 5 МБ на часть, кроме последней),
+// в памяти держится одна часть
+const s3PartSize = 8 << 20
+
+// Таймауты только на установку соединения и ожидание ответа: тело большого файла может идти сколько угодно долго
+// (в том числе при отдаче медленному клиенту), его ограничивает контекст запроса
+const (
+	s3DialTimeout           = 30 * time.Second
+	s3TLSHandshakeTimeout   = 10 * time.Second
+	s3ResponseHeaderTimeout = time.Minute
+)
+
+func NewS3Storage(opts S3Options) (*S3Storage, error) {
+	if opts.Endpoint == "" || opts.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	return &S3Storage{
+		endpoint: endpoint,
+		opts:     opts,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				DialContext:           (&net.Dialer{Timeout: s3DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+				TLSHandshakeTimeout:   s3TLSHandshakeTimeout,
+				ResponseHeaderTimeout: s3ResponseHeaderTimeout,
+				ExpectContinueTimeout: time.Second,
+				IdleConnTimeout:       90 * time.Second,
+				MaxIdleConnsPerHost:   16,
+			},
+		},
+		partSize: s3PartSize,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	// S3 требует Content-Length, поэтому неизвестный размер грузим частями
+	if size < 0 {
+		return s.putMultipart(ctx, key, r)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, r, size)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// putMultipart читает r кусками по partSize и грузит каждый отдельной частью.
+// Если все уместилось в одну часть, это обычный PUT. При ошибке загрузка отменяется, части удаляются.
+func (s *S3Storage) putMultipart(ctx context.Context, key string, r io.Reader) error {
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return s.Put(ctx, key, bytes.NewReader(buf[:n]), int64(n))
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	var parts []completedPart
+	for n > 0 {
+		etag, err := s.uploadPart(ctx, key, uploadID, len(parts)+1, buf[:n])
+		if err != nil {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: len(parts) + 1, ETag: etag})
+
+		n, err = io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+	}
+
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		s.abortMultipartUpload(ctx, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+func (s *S3Storage) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+	var result initiateMultipartUploadResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("s3: no upload id in the response")
+	}
+	return result.UploadID, nil
+}
+
+func (s *S3Storage) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3Storage) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	// S3 может ответить 200 и все равно вернуть ошибку в теле
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if bytes.Contains(reply, []byte("<Error>")) {
+		return fmt.Errorf("s3: complete multipart upload: %s", strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
+
+// abortMultipartUpload отменяет загрузку, чтобы принятые части не занимали место.
+// Отменяем и после отмены ctx: части иначе так и остались бы в бакете.
+func (s *S3Storage) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	resp, err := s.do(context.WithoutCancel(ctx), http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, 0)
+	if err == nil {
+		err = checkResponse(resp)
+		resp.Body.Close()
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		log.Printf("Failed to abort multipart upload of %s: %v", key, err)
+	}
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GetRange - GET с заголовком Range: S3 отдает только нужный хвост файла
+func (s *S3Storage) GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	if offset <= 0 {
+		return s.Get(ctx, key)
+	}
+
+	header := http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+	resp, err := s.doWithHeader(ctx, http.MethodGet, key, nil, header, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Начало за концом файла
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if err := checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	// Сервер без поддержки Range отдает файл целиком - проматываем начало сами
+	if resp.StatusCode != http.StatusPartialContent {
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil && err != io.EOF {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (Object, error) {
+	if err := validateKey(key); err != nil {
+		return Object{}, err
+	}
+
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, 0)
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return Object{}, err
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Object{Key: key, Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var result listBucketResult
+		err = checkResponse(resp)
+		if err == nil {
+			err = xml.NewDecoder(resp.Body).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range result.Contents {
+			objects = append(objects, Object{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3Storage) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	return s.doWithHeader(ctx, method, key, query, nil, body, size)
+}
+
+// doWithHeader - do с дополнительными заголовками, в подпись они не входят
+func (s *S3Storage) doWithHeader(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	path := "/" + s.opts.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + encodePath(path)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	s.sign(req, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign подписывает запрос по AWS Signature Version 4
+func (s *S3Storage) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + unsignedPayload + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretKey), date)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// Кодирование по правилам SigV4: не трогаем только A-Z a-z 0-9 - _ . ~
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func encodePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		parts[i] = uriEncode(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,499 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testBucket    = "documents"
+	testRegion    = "eu-central-1"
+	testAccessKey = "minio"
+	testSecretKey = "minio-secret"
+)
+
+// fakeS3 - минимальная замена MinIO: path-style бакет в памяти, проверка подписи SigV4,
+// постраничный ListObjectsV2 по pageSize ключей и multipart-загрузки.
+type fakeS3 struct {
+	t        *testing.T
+	pageSize int
+	failPart int // номер части, на которой отвечать ошибкой, 0 - не отвечать
+
+	mu       sync.Mutex
+	objects  map[string][]byte
+	modified time.Time
+	tokens   []string // continuation-token всех запросов списка
+	uploads  map[string]map[int][]byte
+	maxBody  int64 // самое большое тело PUT, то есть объекта или части
+	aborted  int
+	sent     int // байт отдано телами GET
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3Storage) {
+	t.Helper()
+
+	fake := &fakeS3{
+		t:        t,
+		pageSize: 1000,
+		objects:  make(map[string][]byte),
+		modified: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+		uploads:  make(map[string]map[int][]byte),
+	}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	s3, err := NewS3Storage(S3Options{
+		Endpoint:  srv.URL,
+		Bucket:    testBucket,
+		Region:    testRegion,
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	return fake, s3
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := verifySignature(r); err != nil {
+		f.t.Errorf("%s %s: %v", r.Method, r.URL, err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(path, "/")
+	if bucket != testBucket {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Method == http.MethodPut {
+		f.maxBody = max(f.maxBody, r.ContentLength)
+	}
+	query := r.URL.Query()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r)
+	case query.Has("uploads") || query.Has("uploadId"):
+		f.multipart(w, r, key)
+	case r.Method == http.MethodPut:
+		if r.ContentLength < 0 {
+			http.Error(w, "MissingContentLength", http.StatusLengthRequired)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Last-Modified", f.modified.Format(http.TimeFormat))
+		status := http.StatusOK
+		// Только открытый диапазон "bytes=N-", другие клиент не шлет
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			if err != nil || start >= len(data) {
+				http.Error(w, "InvalidRange", http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+			data, status = data[start:], http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+			f.sent += len(data)
+		}
+	case r.Method == http.MethodDelete:
+		// Как и S3, удаление несуществующего ключа - не ошибка
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) multipart(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	if r.Method == http.MethodPost && query.Has("uploads") {
+		id := fmt.Sprintf("upload-%d", len(f.uploads)+f.aborted+1)
+		f.uploads[id] = make(map[int][]byte)
+		xml.NewEncoder(w).Encode(struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: id})
+		return
+	}
+
+	id := query.Get("uploadId")
+	parts, ok := f.uploads[id]
+	if !ok {
+		http.Error(w, "NoSuchUpload", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			http.Error(w, "InternalError", http.StatusInternalServerError)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		parts[number] = data
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case http.MethodPost:
+		var complete completeMultipartUpload
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			http.Error(w, "MalformedXML", http.StatusBadRequest)
+			return
+		}
+		var data []byte
+		for i, part := range complete.Parts {
+			if part.PartNumber != i+1 || part.ETag != fmt.Sprintf(`"etag-%d"`, i+1) {
+				http.Error(w, "InvalidPart", http.StatusBadRequest)
+				return
+			}
+			data = append(data, parts[part.PartNumber]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, id)
+		w.Write([]byte("<CompleteMultipartUploadResult></CompleteMultipartUploadResult>"))
+	case http.MethodDelete:
+		delete(f.uploads, id)
+		f.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("list-type") != "2" {
+		http.Error(w, "list-type=2 expected", http.StatusBadRequest)
+		return
+	}
+	token := query.Get("continuation-token")
+	f.tokens = append(f.tokens, token)
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, query.Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Токен - последний отданный ключ
+	start := 0
+	if token != "" {
+		start = sort.SearchStrings(keys, token) + 1
+	}
+	end := min(start+f.pageSize, len(keys))
+
+	result := listBucketResult{IsTruncated: end < len(keys)}
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		}{Key: key, Size: int64(len(f.objects[key])), LastModified: f.modified})
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = keys[end-1]
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// verifySignature заново считает подпись SigV4 по пришедшему запросу, как это делает сервер S3
+func verifySignature(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	algorithm, rest, ok := strings.Cut(auth, " ")
+	if !ok || algorithm != "AWS4-HMAC-SHA256" {
+		return fmt.Errorf("unexpected Authorization %q", auth)
+	}
+
+	fields := map[string]string{}
+	for _, part := range strings.Split(rest, ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		fields[name] = value
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	date, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return fmt.Errorf("invalid X-Amz-Date %q", amzDate)
+	}
+
+	scope := date.Format("20060102") + "/" + testRegion + "/s3/aws4_request"
+	if fields["Credential"] != testAccessKey+"/"+scope {
+		return fmt.Errorf("unexpected credential %q", fields["Credential"])
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(fields["SignedHeaders"], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalRequest := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		canonicalHeaders.String() + "\n" +
+		fields["SignedHeaders"] + "\n" +
+		r.Header.Get("X-Amz-Content-Sha256")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{date.Format("20060102"), testRegion, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	if want := hex.EncodeToString(hmacSHA256(key, stringToSign)); fields["Signature"] != want {
+		return fmt.Errorf("signature %s, want %s", fields["Signature"], want)
+	}
+	return nil
+}
+
+func TestS3PutGetStatDelete(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	ctx := context.Background()
+	key := "user_1/отчет 2024.txt"
+
+	if err := s3.Put(ctx, key, strings.NewReader("hello world"), 11); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// Неизвестный размер, уместившийся в одну часть, - обычный PUT с Content-Length
+	if err := s3.Put(ctx, "user_1/unknown.txt", strings.NewReader("streamed"), -1); err != nil {
+		t.Fatalf("Put with unknown size: %v", err)
+	}
+	if got := string(fake.objects["user_1/unknown.txt"]); got != "streamed" {
+		t.Errorf("stored %q, want %q", got, "streamed")
+	}
+
+	rc, err := s3.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello world" {
+		t.Errorf("Get = %q, want %q", data, "hello world")
+	}
+
+	obj, err := s3.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if obj.Key != key || obj.Size != 11 || !obj.ModTime.Equal(fake.modified) {
+		t.Errorf("Stat = %+v", obj)
+	}
+
+	if err := s3.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s3.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: %v, want ErrNotFound", err)
+	}
+	if _, err := s3.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after delete: %v, want ErrNotFound", err)
+	}
+	if err := s3.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of missing key: %v", err)
+	}
+}
+
+func TestS3RejectsInvalidKeys(t *testing.T) {
+	_, s3 := newFakeS3(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "../secret", "user_1/../../etc/passwd", "/abs", "a//b"} {
+		if err := s3.Put(ctx, key, strings.NewReader("x"), 1); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := s3.Get(ctx, key); err == nil {
+			t.Errorf("Get(%q) succeeded", key)
+		}
+	}
+}
+
+func TestS3SignatureHeader(t *testing.T) {
+	s3, err := NewS3Storage(S3Options{Endpoint: "http://minio:9000", Bucket: testBucket, Region: testRegion, AccessKey: testAccessKey, SecretKey: testSecretKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://minio:9000/documents/user_1/a%20b.txt?list-type=2&prefix=user_1%2F", nil)
+	s3.sign(req, time.Date(2024, 3, 31, 12, 30, 0, 0, time.UTC))
+
+	auth := req.Header.Get("Authorization")
+	prefix := "AWS4-HMAC-SHA256 Credential=minio/20240331/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(auth, prefix) {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	if sig := strings.TrimPrefix(auth, prefix); len(sig) != 64 {
+		t.Errorf("signature %q is not a hex SHA-256", sig)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20240331T123000Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+
+	req.Host = req.URL.Host
+	if err := verifySignature(req); err != nil {
+		t.Error(err)
+	}
+
+	// Подпись покрывает путь: тот же заголовок для другого ключа не подходит
+	tampered, _ := http.NewRequest(http.MethodGet, "http://minio:9000/documents/user_2/a%20b.txt?list-type=2&prefix=user_1%2F", nil)
+	tampered.Header = req.Header.Clone()
+	tampered.Host = tampered.URL.Host
+	if err := verifySignature(tampered); err == nil {
+		t.Error("signature of another path was accepted")
+	}
+
+	// Другой секрет - другая подпись
+	other := *s3
+	other.opts.SecretKey = "wrong"
+	req2, _ := http.NewRequest(http.MethodGet, req.URL.String(), nil)
+	other.sign(req2, time.Date(2024, 3, 31, 12, 30, 0, 0, time.UTC))
+	if req2.Header.Get("Authorization") == auth {
+		t.Error("signature does not depend on the secret key")
+	}
+}
+
+func TestS3ListPagination(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	fake.pageSize = 2
+	ctx := context.Background()
+
+	keys := []string{"user_1/a.txt", "user_1/b.txt", "user_1/c.txt", "user_1/d.txt", "user_1/e.txt", "user_2/x.txt"}
+	for _, key := range keys {
+		if err := s3.Put(ctx, key, strings.NewReader(key), int64(len(key))); err != nil {
+			t.Fatalf("Put(%q): %v", key, err)
+		}
+	}
+
+	objects, err := s3.List(ctx, "user_1/")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	var got []string
+	for _, obj := range objects {
+		got = append(got, obj.Key)
+		if obj.Size != int64(len(obj.Key)) {
+			t.Errorf("%s size = %d", obj.Key, obj.Size)
+		}
+	}
+	if want := keys[:5]; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	// 5 ключей по 2 на страницу: первый запрос без токена, затем по токенам
+	if want := []string{"", "user_1/b.txt", "user_1/d.txt"}; strings.Join(fake.tokens, ",") != strings.Join(want, ",") {
+		t.Errorf("continuation tokens = %q, want %q", fake.tokens, want)
+	}
+}
+
+func TestS3PutUnknownSizeMultipart(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	s3.partSize = 10
+	ctx := context.Background()
+
+	for _, size := range []int{10, 25, 30} {
+		content := strings.Repeat("0123456789", 3)[:size]
+		key := fmt.Sprintf("user_1/stream-%d.txt", size)
+		fake.maxBody = 0
+
+		// Читатель без Len: размер узнать неоткуда
+		if err := s3.Put(ctx, key, io.MultiReader(strings.NewReader(content)), -1); err != nil {
+			t.Fatalf("%d bytes: Put: %v", size, err)
+		}
+		if got := string(fake.objects[key]); got != content {
+			t.Errorf("%d bytes: stored %q", size, got)
+		}
+		if fake.maxBody > 10 {
+			t.Errorf("%d bytes: request body of %d bytes, part size is 10", size, fake.maxBody)
+		}
+	}
+	if len(fake.uploads) != 0 {
+		t.Errorf("%d multipart uploads left open", len(fake.uploads))
+	}
+}
+
+func TestS3PutMultipartAbortsOnError(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	s3.partSize = 10
+	fake.failPart = 2
+
+	err := s3.Put(context.Background(), "user_1/broken.txt", io.MultiReader(strings.NewReader(strings.Repeat("x", 35))), -1)
+	if err == nil {
+		t.Fatal("Put succeeded")
+	}
+	if _, ok := fake.objects["user_1/broken.txt"]; ok {
+		t.Error("object was created")
+	}
+	if fake.aborted != 1 || len(fake.uploads) != 0 {
+		t.Errorf("aborted %d uploads, %d left open", fake.aborted, len(fake.uploads))
+	}
+}
+
+func TestS3GetRange(t *testing.T) {
+	fake, s3 := newFakeS3(t)
+	ctx := context.Background()
+	content := strings.Repeat("0123456789", 100)
+	if err := s3.Put(ctx, "user_1/big.txt", strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offset := range []int64{0, 1, 990, 999, 1000, 5000} {
+		fake.sent = 0
+		rc, err := s3.GetRange(ctx, "user_1/big.txt", offset)
+		if err != nil {
+			t.Fatalf("GetRange(%d): %v", offset, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+
+		want := content[min(int(offset), len(content)):]
+		if string(data) != want {
+			t.Errorf("GetRange(%d) = %d bytes, want %d", offset, len(data), len(want))
+		}
+		// С S3 идет только запрошенный хвост, а не весь файл
+		if fake.sent != len(want) {
+			t.Errorf("GetRange(%d): server sent %d bytes, want %d", offset, fake.sent, len(want))
+		}
+	}
+
+	if _, err := s3.GetRange(ctx, "user_1/missing.txt", 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRange of missing key: %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"tfidf-app/internal/config"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Object - метаинформация о файле в хранилище
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage - хранилище файлов документов. Ключи имеют вид "user_1/file.txt".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// GetRange открывает файл с байта offset до конца. offset за концом файла - пустой поток
+	GetRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	Stat(ctx context.Context, key string) (Object, error)
+}
+
+var Files Storage
+
+func ConnectStorage() {
+	store, err := New(config.Init)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	Files = store
+	log.Printf("INFO: Storage %q successfully initialized.", config.Init.StorageBackend)
+}
+
+func New(cfg config.Config) (Storage, error) {
+	switch cfg.StorageBackend {
+	case "local":
+		return NewLocalStorage(cfg.StorageLocalDir), nil
+	case "s3":
+		return NewS3Storage(S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// validateKey не дает выйти за пределы хранилища через ".." и абсолютные пути
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+	return nil
+}